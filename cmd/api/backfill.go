@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/didil/inhooks/pkg/lib"
+	"github.com/didil/inhooks/pkg/services"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// backfill replays the requests of a source ingest log received in a time range into one sink of the flow, e.g. after adding the sink
+func backfill(appConf *lib.AppConfig, logger *zap.Logger, flowID string, sinkID string, fromStr string, toStr string) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	if flowID == "" || sinkID == "" || fromStr == "" {
+		return fmt.Errorf("backfill-flow, backfill-sink and backfill-from are required")
+	}
+
+	timeSvc := services.NewTimeService()
+	now := timeSvc.Now()
+
+	from, err := parseBackfillTime(fromStr, now)
+	if err != nil {
+		return errors.Wrapf(err, "invalid backfill-from")
+	}
+	to := now
+	if toStr != "" {
+		to, err = parseBackfillTime(toStr, now)
+		if err != nil {
+			return errors.Wrapf(err, "invalid backfill-to")
+		}
+	}
+	if to.Before(from) {
+		return fmt.Errorf("backfill-to cannot be before backfill-from")
+	}
+
+	inhooksConfigSvc := services.NewInhooksConfigService(logger, appConf)
+	err = inhooksConfigSvc.Load(appConf.InhooksConfigFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load inhooks config")
+	}
+
+	flow := inhooksConfigSvc.GetFlow(flowID)
+	if flow == nil {
+		return fmt.Errorf("flow not found: %s", flowID)
+	}
+
+	redisClient, err := lib.InitRedisClient(appConf)
+	if err != nil {
+		return errors.Wrapf(err, "failed to init redis client")
+	}
+	redisStore, err := services.NewRedisStore(redisClient, appConf.Redis.InhooksDBName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to init redis store")
+	}
+
+	recordCodec, err := services.NewRecordCodec(&appConf.Storage)
+	if err != nil {
+		return errors.Wrapf(err, "failed to init record codec")
+	}
+
+	payloadStore, err := services.NewPayloadStore(&appConf.Storage, lib.NewHttpClient(appConf))
+	if err != nil {
+		return errors.Wrapf(err, "failed to init payload store")
+	}
+
+	ingestLogSvc := services.NewIngestLogService(redisStore, timeSvc, recordCodec, payloadStore, appConf.Storage.PayloadStoreThreshold)
+	messageEnqueuer := services.NewMessageEnqueuer(redisStore, timeSvc, recordCodec, payloadStore, appConf.Storage.PayloadStoreThreshold)
+	backfillSvc := services.NewBackfillService(ingestLogSvc, services.NewMessageBuilder(timeSvc), services.NewMessageVerifier(), messageEnqueuer)
+
+	logger.Info("backfilling sink", zap.String("flowID", flowID), zap.String("sinkID", sinkID), zap.Time("from", from), zap.Time("to", to))
+
+	count, err := backfillSvc.Backfill(ctx, flow, sinkID, from, to)
+	logger.Info("backfilled messages", zap.Int("count", count))
+
+	return err
+}
+
+// parseBackfillTime parses an RFC3339 time or a duration before now
+func parseBackfillTime(s string, now time.Time) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, s)
+	if err == nil {
+		return t, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected an RFC3339 time or a duration: %s", s)
+	}
+
+	return now.Add(-d), nil
+}
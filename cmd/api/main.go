@@ -29,6 +29,12 @@ func main() {
 
 	// handle version command
 	isVersionCmd := flag.Bool("version", false, "print the version")
+	isReencryptCmd := flag.Bool("reencrypt", false, "re-encrypt the stored messages with the current encryption key")
+	isBackfillCmd := flag.Bool("backfill", false, "replay the requests of a source ingest log into one sink")
+	backfillFlowID := flag.String("backfill-flow", "", "id of the flow to backfill")
+	backfillSinkID := flag.String("backfill-sink", "", "id of the sink to backfill")
+	backfillFrom := flag.String("backfill-from", "", "start of the replayed time range, as an RFC3339 time or a duration ago (e.g. 2h)")
+	backfillTo := flag.String("backfill-to", "", "end of the replayed time range, as an RFC3339 time or a duration ago, defaults to now")
 	flag.Parse()
 	if *isVersionCmd {
 		fmt.Println(version)
@@ -51,6 +57,24 @@ func main() {
 		log.Fatalf("failed to initialize logger: %v", err)
 	}
 
+	// handle reencrypt command
+	if *isReencryptCmd {
+		err = reencrypt(appConf, logger)
+		if err != nil {
+			logger.Fatal("failed to re-encrypt records", zap.Error(err))
+		}
+		os.Exit(0)
+	}
+
+	// handle backfill command
+	if *isBackfillCmd {
+		err = backfill(appConf, logger, *backfillFlowID, *backfillSinkID, *backfillFrom, *backfillTo)
+		if err != nil {
+			logger.Fatal("failed to backfill", zap.Error(err))
+		}
+		os.Exit(0)
+	}
+
 	logger.Info("starting Inhooks", zap.String("version", version))
 
 	inhooksConfigSvc := services.NewInhooksConfigService(logger, appConf)
@@ -61,6 +85,15 @@ func main() {
 		logger.Fatal("failed to load inhooks config", zap.Error(err))
 	}
 
+	ipAllowlistSvc, err := services.NewIPAllowlistService(inhooksConfigSvc, appConf)
+	if err != nil {
+		logger.Fatal("failed to init ip allowlist service", zap.Error(err))
+	}
+	err = ipAllowlistSvc.Reload()
+	if err != nil {
+		logger.Fatal("failed to load ip allowlists", zap.Error(err))
+	}
+
 	timeSvc := services.NewTimeService()
 
 	messageBuilder := services.NewMessageBuilder(timeSvc)
@@ -74,18 +107,49 @@ func main() {
 		logger.Fatal("failed to init redis store", zap.Error(err))
 	}
 
-	messageEnqueuer := services.NewMessageEnqueuer(redisStore, timeSvc)
-	messageFetcher := services.NewMessageFetcher(redisStore, timeSvc)
+	recordCodec, err := services.NewRecordCodec(&appConf.Storage)
+	if err != nil {
+		logger.Fatal("failed to init record codec", zap.Error(err))
+	}
+
+	httpClient := lib.NewHttpClient(appConf)
+
+	payloadStore, err := services.NewPayloadStore(&appConf.Storage, httpClient)
+	if err != nil {
+		logger.Fatal("failed to init payload store", zap.Error(err))
+	}
+
+	messageEnqueuer := services.NewMessageEnqueuer(redisStore, timeSvc, recordCodec, payloadStore, appConf.Storage.PayloadStoreThreshold)
+	messageFetcher := services.NewMessageFetcher(redisStore, timeSvc, recordCodec, payloadStore)
 	messageVerifier := services.NewMessageVerifier()
 	messageTransformer := services.NewMessageTransformer(&appConf.Transform)
+	ingestLimiter := services.NewIngestLimiter(redisStore, timeSvc)
+	challengeResponder := services.NewChallengeResponder(messageVerifier)
+	messageDeduplicator := services.NewMessageDeduplicator(redisStore)
+	payloadValidator := services.NewPayloadValidator()
+	messageProcessor := services.NewMessageProcessor(httpClient)
+	retryCalculator := services.NewRetryCalculator()
+	processingResultsSvc := services.NewProcessingResultsService(timeSvc, redisStore, retryCalculator, recordCodec)
+	syncDeliverySvc := services.NewSyncDeliveryService(inhooksConfigSvc, messageTransformer, messageProcessor, processingResultsSvc)
+	captureSvc := services.NewCaptureService(redisStore, timeSvc, recordCodec, appConf)
+	ingestLogSvc := services.NewIngestLogService(redisStore, timeSvc, recordCodec, payloadStore, appConf.Storage.PayloadStoreThreshold)
 
 	app := handlers.NewApp(
 		handlers.WithLogger(logger),
+		handlers.WithAppConfig(appConf),
 		handlers.WithInhooksConfigService(inhooksConfigSvc),
 		handlers.WithMessageBuilder(messageBuilder),
 		handlers.WithMessageEnqueuer(messageEnqueuer),
 		handlers.WithMessageVerifier(messageVerifier),
 		handlers.WithMessageTransformer(messageTransformer),
+		handlers.WithIPAllowlistService(ipAllowlistSvc),
+		handlers.WithIngestLimiter(ingestLimiter),
+		handlers.WithChallengeResponder(challengeResponder),
+		handlers.WithMessageDeduplicator(messageDeduplicator),
+		handlers.WithSyncDeliveryService(syncDeliverySvc),
+		handlers.WithPayloadValidator(payloadValidator),
+		handlers.WithCaptureService(captureSvc),
+		handlers.WithIngestLogService(ingestLogSvc),
 	)
 
 	r := server.NewRouter(app)
@@ -109,11 +173,6 @@ func main() {
 		wg.Done()
 	}()
 
-	httpClient := lib.NewHttpClient(appConf)
-
-	messageProcessor := services.NewMessageProcessor(httpClient)
-	retryCalculator := services.NewRetryCalculator()
-	processingResultsSvc := services.NewProcessingResultsService(timeSvc, redisStore, retryCalculator)
 	schedulerSvc := services.NewSchedulerService(redisStore, timeSvc)
 
 	processingRecoverySvc, err := services.NewProcessingRecoveryService(redisStore)
@@ -121,7 +180,10 @@ func main() {
 		logger.Fatal("failed to init ProcessingRecoveryService", zap.Error(err))
 	}
 
-	cleanupSvc := services.NewCleanupService(redisStore, timeSvc)
+	cleanupSvc := services.NewCleanupService(redisStore, timeSvc, recordCodec, payloadStore)
+	streamConsumer := services.NewStreamConsumer(logger, redisStore, messageBuilder, messageEnqueuer, appConf)
+	cronEmitter := services.NewCronEmitter(redisStore, messageBuilder, messageEnqueuer)
+	poller := services.NewPoller(redisStore, httpClient, messageBuilder, messageEnqueuer, appConf)
 
 	svisor := supervisor.NewSupervisor(
 		supervisor.WithLogger(logger),
@@ -134,6 +196,11 @@ func main() {
 		supervisor.WithProcessingRecoveryService(processingRecoverySvc),
 		supervisor.WithCleanupService(cleanupSvc),
 		supervisor.WithMessageTransformer(messageTransformer),
+		supervisor.WithIPAllowlistService(ipAllowlistSvc),
+		supervisor.WithStreamConsumer(streamConsumer),
+		supervisor.WithCronEmitter(cronEmitter),
+		supervisor.WithPoller(poller),
+		supervisor.WithTimeService(timeSvc),
 	)
 
 	wg.Add(1)
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/didil/inhooks/pkg/lib"
+	"github.com/didil/inhooks/pkg/services"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// reencrypt re-encrypts the stored records with the current encryption key, e.g. after a key rotation
+func reencrypt(appConf *lib.AppConfig, logger *zap.Logger) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	redisClient, err := lib.InitRedisClient(appConf)
+	if err != nil {
+		return errors.Wrapf(err, "failed to init redis client")
+	}
+	redisStore, err := services.NewRedisStore(redisClient, appConf.Redis.InhooksDBName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to init redis store")
+	}
+
+	recordCodec, err := services.NewRecordCodec(&appConf.Storage)
+	if err != nil {
+		return errors.Wrapf(err, "failed to init record codec")
+	}
+
+	payloadStore, err := services.NewPayloadStore(&appConf.Storage, lib.NewHttpClient(appConf))
+	if err != nil {
+		return errors.Wrapf(err, "failed to init payload store")
+	}
+
+	logger.Info("re-encrypting records", zap.String("encryptionKeyID", recordCodec.EncryptionKeyID()))
+
+	reencryptionSvc := services.NewReencryptionService(redisStore, recordCodec, payloadStore)
+	count, err := reencryptionSvc.Reencrypt(ctx)
+	logger.Info("re-encrypted records", zap.Int("count", count))
+
+	return err
+}
@@ -2,8 +2,10 @@ package lib
 
 import (
 	"context"
+	"os"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/sethvargo/go-envconfig"
 )
 
@@ -16,12 +18,16 @@ type AppConfig struct {
 	HTTPClient        HTTPClientConfig
 	Sink              SinkConfig
 	Transform         TransformConfig
+	Ingest            IngestConfig
+	Storage           StorageConfig
 }
 
 type ServerConfig struct {
 	Host                string        `env:"HOST"`
 	Port                int           `env:"PORT,default=3000"`
 	ShutdownGracePeriod time.Duration `env:"SERVER_SHUTDOWN_GRACE_PERIOD,default=5s"`
+	// comma separated list of CIDR ranges of the proxies allowed to set the X-Forwarded-For header
+	TrustedProxies []string `env:"SERVER_TRUSTED_PROXIES"`
 }
 
 type RedisConfig struct {
@@ -44,6 +50,16 @@ type SupervisorConfig struct {
 	DoneQueueCleanupDelay time.Duration `env:"SUPERVISOR_DONE_QUEUE_CLEANUP_DELAY,default=336h"`
 	// interval between done queue cleanup runs
 	DoneQueueCleanupInterval time.Duration `env:"SUPERVISOR_DONE_QUEUE_CLEANUP_INTERVAL,default=60m"`
+	// interval between reloads of the sources allowed CIDRs files
+	IPAllowlistReloadInterval time.Duration `env:"SUPERVISOR_IP_ALLOWLIST_RELOAD_INTERVAL,default=1m"`
+	// max duration a redis-stream source read waits for new entries
+	StreamBlockTime time.Duration `env:"SUPERVISOR_STREAM_BLOCK_TIME,default=5s"`
+	// max number of entries read at once from a redis-stream source
+	StreamBatchSize int `env:"SUPERVISOR_STREAM_BATCH_SIZE,default=100"`
+	// name of the instance in the redis-stream sources consumer groups. Defaults to the hostname. Must be unique and stable across restarts.
+	StreamConsumerName string `env:"SUPERVISOR_STREAM_CONSUMER_NAME"`
+	// min idle duration of the redis-stream entries pending for another consumer, e.g. a crashed instance, before they are claimed
+	StreamClaimMinIdle time.Duration `env:"SUPERVISOR_STREAM_CLAIM_MIN_IDLE,default=5m"`
 }
 
 type HTTPClientConfig struct {
@@ -62,6 +78,75 @@ type TransformConfig struct {
 	JavascriptTimeout time.Duration `env:"TRANSFORM_JAVASCRIPT_TIMEOUT,default=1s"`
 }
 
+// Ingest requests handling settings
+type IngestConfig struct {
+	// Retry-After duration returned when an ingest request is rejected because the sink queues are full
+	BackpressureRetryAfter time.Duration `env:"INGEST_BACKPRESSURE_RETRY_AFTER,default=30s"`
+	// default max request body size in bytes. Default 10 MiB
+	MaxBodyBytes int64 `env:"INGEST_MAX_BODY_BYTES,default=10485760"`
+	// default max size in bytes of decompressed request bodies. Default 50 MiB
+	MaxDecompressedBytes int64 `env:"INGEST_MAX_DECOMPRESSED_BYTES,default=52428800"`
+	// default duration during which requests with the same idempotency key are dropped
+	DedupeTTL time.Duration `env:"INGEST_DEDUPE_TTL,default=24h"`
+	// default max duration of the inline delivery to sync sinks
+	SyncTimeout time.Duration `env:"INGEST_SYNC_TIMEOUT,default=10s"`
+	// default duration during which the requests of sources with an ingest log are kept
+	LogRetention time.Duration `env:"INGEST_LOG_RETENTION,default=48h"`
+	// number of requests kept per capture source
+	CaptureMaxRequests int `env:"INGEST_CAPTURE_MAX_REQUESTS,default=100"`
+	// duration after which the captured requests of a source expire when no new request is captured
+	CaptureTTL time.Duration `env:"INGEST_CAPTURE_TTL,default=24h"`
+	// bearer token required to list the captured requests. The captures endpoint is disabled when not set
+	CaptureAPIToken string `env:"INGEST_CAPTURE_API_TOKEN"`
+}
+
+type StorageCompression string
+
+const (
+	StorageCompressionNone StorageCompression = ""
+	StorageCompressionGzip StorageCompression = "gzip"
+	StorageCompressionZstd StorageCompression = "zstd"
+)
+
+// Redis records storage settings
+type StorageConfig struct {
+	// compression of the messages and ingested requests stored in redis: gzip or zstd. Disabled by default
+	Compression StorageCompression `env:"STORAGE_COMPRESSION"`
+	// comma separated list of AES encryption keys formatted as <keyID>:<base64 key>
+	EncryptionKeys []string `env:"STORAGE_ENCRYPTION_KEYS"`
+	// path to a file containing AES encryption keys formatted as <keyID>:<base64 key>, one per line
+	EncryptionKeysFile string `env:"STORAGE_ENCRYPTION_KEYS_FILE"`
+	// id of the key used to encrypt new records. Encryption is disabled if not set
+	EncryptionKeyID string `env:"STORAGE_ENCRYPTION_KEY_ID"`
+	// store used for payloads larger than PayloadStoreThreshold instead of redis: local or s3. Disabled by default
+	PayloadStore PayloadStoreType `env:"STORAGE_PAYLOAD_STORE"`
+	// size in bytes above which payloads are offloaded to the payload store. Default 256 KiB
+	PayloadStoreThreshold int `env:"STORAGE_PAYLOAD_STORE_THRESHOLD,default=262144"`
+	// directory of the local payload store
+	PayloadStoreDir string `env:"STORAGE_PAYLOAD_STORE_DIR,default=payloads"`
+	S3              S3Config
+}
+
+type PayloadStoreType string
+
+const (
+	PayloadStoreTypeNone  PayloadStoreType = ""
+	PayloadStoreTypeLocal PayloadStoreType = "local"
+	PayloadStoreTypeS3    PayloadStoreType = "s3"
+)
+
+// S3 compatible payload store settings
+type S3Config struct {
+	// endpoint url, e.g. https://s3.us-east-1.amazonaws.com or http://localhost:9000 for MinIO. Buckets are addressed path-style
+	Endpoint        string `env:"STORAGE_S3_ENDPOINT"`
+	Region          string `env:"STORAGE_S3_REGION,default=us-east-1"`
+	Bucket          string `env:"STORAGE_S3_BUCKET"`
+	AccessKeyID     string `env:"STORAGE_S3_ACCESS_KEY_ID"`
+	SecretAccessKey string `env:"STORAGE_S3_SECRET_ACCESS_KEY"`
+	// prefix of the object keys
+	Prefix string `env:"STORAGE_S3_PREFIX"`
+}
+
 func InitAppConfig(ctx context.Context) (*AppConfig, error) {
 	appConf := &AppConfig{}
 	err := envconfig.Process(ctx, appConf)
@@ -69,5 +154,12 @@ func InitAppConfig(ctx context.Context) (*AppConfig, error) {
 		return nil, err
 	}
 
+	if appConf.Supervisor.StreamConsumerName == "" {
+		appConf.Supervisor.StreamConsumerName, err = os.Hostname()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get hostname")
+		}
+	}
+
 	return appConf, nil
 }
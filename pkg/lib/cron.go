@@ -0,0 +1,187 @@
+package lib
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule is a standard cron expression made of 5 fields: minute, hour, day of month, month and day of week, e.g. 30 2 * * 1-5.
+// Fields accept *, values, ranges (1-5), lists (1,15) and steps (*/15, 0-30/10). Sunday is 0 or 7.
+// The @yearly, @monthly, @weekly, @daily and @hourly macros are also accepted.
+type CronSchedule struct {
+	raw    string
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+	// when both days of month and days of week are restricted, a day matches if either matches
+	domRestricted bool
+	dowRestricted bool
+}
+
+type cronField struct {
+	name string
+	min  int
+	max  int
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12},
+	{name: "day of week", min: 0, max: 7},
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCronSchedule parses a cron expression
+func ParseCronSchedule(expr string) (*CronSchedule, error) {
+	s := &CronSchedule{raw: expr}
+
+	expanded := strings.TrimSpace(expr)
+	if macro, ok := cronMacros[expanded]; ok {
+		expanded = macro
+	}
+
+	fields := strings.Fields(expanded)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("invalid cron expression %s: expected %d fields", expr, len(cronFields))
+	}
+
+	values := make([]uint64, len(fields))
+	for i, field := range fields {
+		v, err := parseCronField(field, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %s: %w", expr, err)
+		}
+		values[i] = v
+	}
+
+	s.minute, s.hour, s.dom, s.month, s.dow = values[0], values[1], values[2], values[3], values[4]
+	// sunday can be written 0 or 7
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	s.domRestricted = !strings.HasPrefix(fields[2], "*")
+	s.dowRestricted = !strings.HasPrefix(fields[4], "*")
+
+	if s.Next(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)).IsZero() {
+		return nil, fmt.Errorf("invalid cron expression %s: never matches", expr)
+	}
+
+	return s, nil
+}
+
+// parseCronField returns the bitset of the values matched by a field
+func parseCronField(field string, f cronField) (uint64, error) {
+	var values uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepStr, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepStr)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid %s step: %s", f.name, part)
+			}
+		}
+
+		var start, end int
+		switch {
+		case rangePart == "*":
+			start, end = f.min, f.max
+		case strings.Contains(rangePart, "-"):
+			startStr, endStr, _ := strings.Cut(rangePart, "-")
+			var err1, err2 error
+			start, err1 = strconv.Atoi(startStr)
+			end, err2 = strconv.Atoi(endStr)
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("invalid %s range: %s", f.name, part)
+			}
+		default:
+			var err error
+			start, err = strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, fmt.Errorf("invalid %s value: %s", f.name, part)
+			}
+			end = start
+			// 5/15 is 5-max/15
+			if hasStep {
+				end = f.max
+			}
+		}
+
+		if start < f.min || end > f.max || start > end {
+			return 0, fmt.Errorf("%s out of range %d-%d: %s", f.name, f.min, f.max, part)
+		}
+
+		for v := start; v <= end; v += step {
+			values |= 1 << uint(v)
+		}
+	}
+
+	return values, nil
+}
+
+// Next returns the first time matching the schedule strictly after t, in the location of t.
+// Returns the zero time if no time matches within 5 years.
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			// absolute increments do not loop on daylight saving time changes
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+			continue
+		}
+
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (s *CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.domRestricted && s.dowRestricted {
+		return domMatch || dowMatch
+	}
+
+	return domMatch && dowMatch
+}
+
+func (s *CronSchedule) String() string {
+	return s.raw
+}
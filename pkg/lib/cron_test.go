@@ -0,0 +1,66 @@
+package lib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	// Friday
+	now := time.Date(2023, 05, 5, 8, 9, 12, 0, time.UTC)
+
+	cases := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{expr: "* * * * *", expected: time.Date(2023, 05, 5, 8, 10, 0, 0, time.UTC)},
+		{expr: "*/15 * * * *", expected: time.Date(2023, 05, 5, 8, 15, 0, 0, time.UTC)},
+		{expr: "0 2 * * *", expected: time.Date(2023, 05, 6, 2, 0, 0, 0, time.UTC)},
+		{expr: "@daily", expected: time.Date(2023, 05, 6, 0, 0, 0, 0, time.UTC)},
+		{expr: "@hourly", expected: time.Date(2023, 05, 5, 9, 0, 0, 0, time.UTC)},
+		{expr: "30 9 * * 1-5", expected: time.Date(2023, 05, 5, 9, 30, 0, 0, time.UTC)},
+		{expr: "0 0 * * 7", expected: time.Date(2023, 05, 7, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 12 1,15 * *", expected: time.Date(2023, 05, 15, 12, 0, 0, 0, time.UTC)},
+		{expr: "0 0 29 2 *", expected: time.Date(2024, 02, 29, 0, 0, 0, 0, time.UTC)},
+		// day of month or day of week when both are restricted
+		{expr: "0 0 10 * 0", expected: time.Date(2023, 05, 7, 0, 0, 0, 0, time.UTC)},
+		{expr: "5/20 8 * * *", expected: time.Date(2023, 05, 5, 8, 25, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range cases {
+		s, err := ParseCronSchedule(tc.expr)
+		assert.NoError(t, err, tc.expr)
+		assert.Equal(t, tc.expected, s.Next(now), tc.expr)
+	}
+
+	// the schedule follows the location of the time
+	paris, err := time.LoadLocation("Europe/Paris")
+	assert.NoError(t, err)
+	s, err := ParseCronSchedule("0 2 * * *")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2023, 05, 6, 2, 0, 0, 0, paris), s.Next(now.In(paris)))
+	// 2am does not exist when daylight saving time starts
+	assert.Equal(t, time.Date(2023, 03, 27, 2, 0, 0, 0, paris), s.Next(time.Date(2023, 03, 26, 1, 0, 0, 0, paris)))
+}
+
+func TestParseCronSchedule_Invalid(t *testing.T) {
+	cases := []struct {
+		expr string
+		err  string
+	}{
+		{expr: "* * * *", err: "expected 5 fields"},
+		{expr: "60 * * * *", err: "minute out of range 0-59: 60"},
+		{expr: "* * 0 * *", err: "day of month out of range 1-31: 0"},
+		{expr: "*/0 * * * *", err: "invalid minute step: */0"},
+		{expr: "a * * * *", err: "invalid minute value: a"},
+		{expr: "5-1 * * * *", err: "minute out of range 0-59: 5-1"},
+		{expr: "0 0 30 2 *", err: "never matches"},
+	}
+
+	for _, tc := range cases {
+		_, err := ParseCronSchedule(tc.expr)
+		assert.ErrorContains(t, err, tc.err, tc.expr)
+	}
+}
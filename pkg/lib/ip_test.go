@@ -0,0 +1,34 @@
+package lib
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIPPrefix(t *testing.T) {
+	prefix, err := ParseIPPrefix("192.168.1.17/24")
+	assert.NoError(t, err)
+	assert.Equal(t, netip.MustParsePrefix("192.168.1.0/24"), prefix)
+
+	prefix, err = ParseIPPrefix(" 10.0.0.1 ")
+	assert.NoError(t, err)
+	assert.Equal(t, netip.MustParsePrefix("10.0.0.1/32"), prefix)
+
+	prefix, err = ParseIPPrefix("2001:db8::1")
+	assert.NoError(t, err)
+	assert.Equal(t, netip.MustParsePrefix("2001:db8::1/128"), prefix)
+
+	_, err = ParseIPPrefix("10.0.0.300/8")
+	assert.Error(t, err)
+}
+
+func TestPrefixesContain(t *testing.T) {
+	prefixes, err := ParseIPPrefixes([]string{"192.168.1.0/24", "10.0.0.1"})
+	assert.NoError(t, err)
+
+	assert.True(t, PrefixesContain(prefixes, netip.MustParseAddr("192.168.1.200")))
+	assert.True(t, PrefixesContain(prefixes, netip.MustParseAddr("::ffff:10.0.0.1")))
+	assert.False(t, PrefixesContain(prefixes, netip.MustParseAddr("10.0.0.2")))
+}
@@ -0,0 +1,166 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// JSONPath is a simple JSON path made of object keys and array indexes, e.g. $.data.items[0].id or $['key.with.dots']
+type JSONPath struct {
+	raw      string
+	segments []jsonPathSegment
+}
+
+type jsonPathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// ParseJSONPath parses a JSON path. The leading $ is optional, and $ alone refers to the whole document.
+func ParseJSONPath(path string) (*JSONPath, error) {
+	if strings.TrimSpace(path) == "" {
+		return nil, fmt.Errorf("empty json path")
+	}
+
+	p := &JSONPath{raw: path}
+	s, hasRoot := strings.CutPrefix(path, "$")
+	for i := 0; i < len(s); {
+		switch {
+		case s[i] == '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end == -1 {
+				return nil, fmt.Errorf("invalid json path %s: missing ]", path)
+			}
+			content := s[i+1 : i+end]
+			i += end + 1
+
+			if len(content) >= 2 && (content[0] == '\'' || content[0] == '"') && content[len(content)-1] == content[0] {
+				p.segments = append(p.segments, jsonPathSegment{key: content[1 : len(content)-1]})
+				continue
+			}
+
+			index, err := strconv.Atoi(content)
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid json path %s: invalid index %s", path, content)
+			}
+			p.segments = append(p.segments, jsonPathSegment{index: index, isIndex: true})
+		case s[i] == '.' || (i == 0 && !hasRoot):
+			if s[i] == '.' {
+				i++
+			}
+			end := strings.IndexAny(s[i:], ".[")
+			if end == -1 {
+				end = len(s) - i
+			}
+			key := s[i : i+end]
+			if key == "" {
+				return nil, fmt.Errorf("invalid json path %s: empty key", path)
+			}
+			p.segments = append(p.segments, jsonPathSegment{key: key})
+			i += end
+		default:
+			return nil, fmt.Errorf("invalid json path %s", path)
+		}
+	}
+
+	return p, nil
+}
+
+func (p *JSONPath) String() string {
+	return p.raw
+}
+
+// Lookup returns the value found at the path in a document decoded with DecodeJSON
+func (p *JSONPath) Lookup(doc interface{}) (interface{}, bool) {
+	value := doc
+	for _, segment := range p.segments {
+		if segment.isIndex {
+			arr, ok := value.([]interface{})
+			if !ok || segment.index >= len(arr) {
+				return nil, false
+			}
+			value = arr[segment.index]
+			continue
+		}
+
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		value, ok = obj[segment.key]
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return value, true
+}
+
+// LookupRaw returns the encoded value found at the path in a JSON document, without re-encoding it
+func (p *JSONPath) LookupRaw(payload []byte) (json.RawMessage, bool, error) {
+	value := json.RawMessage(payload)
+	if !json.Valid(value) {
+		return nil, false, fmt.Errorf("invalid json")
+	}
+
+	for _, segment := range p.segments {
+		if segment.isIndex {
+			var arr []json.RawMessage
+			if json.Unmarshal(value, &arr) != nil || segment.index >= len(arr) {
+				return nil, false, nil
+			}
+			value = arr[segment.index]
+			continue
+		}
+
+		var obj map[string]json.RawMessage
+		if json.Unmarshal(value, &obj) != nil || obj == nil {
+			return nil, false, nil
+		}
+		var ok bool
+		value, ok = obj[segment.key]
+		if !ok {
+			return nil, false, nil
+		}
+	}
+
+	return bytes.TrimSpace(value), true, nil
+}
+
+// DecodeJSON decodes a JSON document, keeping numbers as json.Number to preserve large ids
+func DecodeJSON(payload []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+
+	var doc interface{}
+	err := decoder.Decode(&doc)
+	if err != nil {
+		return nil, err
+	}
+
+	// the payload must contain a single JSON document
+	if err := decoder.Decode(&struct{}{}); err != io.EOF {
+		return nil, fmt.Errorf("invalid character after top-level value")
+	}
+
+	return doc, nil
+}
+
+// JSONValueString returns strings as is and other JSON values in their JSON encoding
+func JSONValueString(value interface{}) (string, error) {
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
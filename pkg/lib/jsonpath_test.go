@@ -0,0 +1,110 @@
+package lib
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONPathLookup(t *testing.T) {
+	doc, err := DecodeJSON([]byte(`{"id": 12345678901234567890, "data": {"items": [{"name": "a"}, {"name": "b"}], "key.with.dots": true}}`))
+	assert.NoError(t, err)
+
+	cases := []struct {
+		path     string
+		expected interface{}
+	}{
+		{path: "$.id", expected: json.Number("12345678901234567890")},
+		{path: "id", expected: json.Number("12345678901234567890")},
+		{path: "$.data.items[1].name", expected: "b"},
+		{path: "data.items[0].name", expected: "a"},
+		{path: `$.data['key.with.dots']`, expected: true},
+	}
+
+	for _, tc := range cases {
+		p, err := ParseJSONPath(tc.path)
+		assert.NoError(t, err)
+
+		value, ok := p.Lookup(doc)
+		assert.True(t, ok, tc.path)
+		assert.Equal(t, tc.expected, value, tc.path)
+	}
+
+	p, err := ParseJSONPath("$")
+	assert.NoError(t, err)
+	value, ok := p.Lookup(doc)
+	assert.True(t, ok)
+	assert.Equal(t, doc, value)
+
+	for _, path := range []string{"$.data.items[2]", "$.data.other", "$.id.sub", "$.data[0]"} {
+		p, err := ParseJSONPath(path)
+		assert.NoError(t, err)
+
+		_, ok := p.Lookup(doc)
+		assert.False(t, ok, path)
+	}
+}
+
+func TestDecodeJSON_TrailingData(t *testing.T) {
+	doc, err := DecodeJSON([]byte(`{"id": "abc"}` + "\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"id": "abc"}, doc)
+
+	for _, payload := range []string{`{"id": "abc"} {"id": "def"}`, `{"id": "abc"}]`, `1 2`} {
+		_, err = DecodeJSON([]byte(payload))
+		assert.EqualError(t, err, "invalid character after top-level value", payload)
+	}
+}
+
+func TestParseJSONPath_Invalid(t *testing.T) {
+	for _, path := range []string{"", "$.", "$.a..b", "$.a[", "$.a[-1]", "$.a[x]", "$a"} {
+		_, err := ParseJSONPath(path)
+		assert.Error(t, err, path)
+	}
+}
+
+func TestJSONValueString(t *testing.T) {
+	s, err := JSONValueString("abc")
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", s)
+
+	s, err = JSONValueString(json.Number("123"))
+	assert.NoError(t, err)
+	assert.Equal(t, "123", s)
+
+	s, err = JSONValueString(map[string]interface{}{"a": true})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a":true}`, s)
+}
+
+func TestJSONPathLookupRaw(t *testing.T) {
+	payload := []byte(` {"id": 12345678901234567890, "data": {"events": [{"b": 1, "a": "<x>"}, {"b": 2}]}} `)
+
+	p, err := ParseJSONPath("$.data.events[0]")
+	assert.NoError(t, err)
+	value, ok, err := p.LookupRaw(payload)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	// the value is not re-encoded
+	assert.Equal(t, `{"b": 1, "a": "<x>"}`, string(value))
+
+	p, err = ParseJSONPath("$")
+	assert.NoError(t, err)
+	value, ok, err = p.LookupRaw(payload)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, `{"id": 12345678901234567890, "data": {"events": [{"b": 1, "a": "<x>"}, {"b": 2}]}}`, string(value))
+
+	for _, path := range []string{"$.data.events[2]", "$.data.other", "$.id.sub", "$.data[0]"} {
+		p, err := ParseJSONPath(path)
+		assert.NoError(t, err)
+
+		_, ok, err := p.LookupRaw(payload)
+		assert.NoError(t, err)
+		assert.False(t, ok, path)
+	}
+
+	_, _, err = p.LookupRaw([]byte(`not json`))
+	assert.Error(t, err)
+}
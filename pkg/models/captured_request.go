@@ -0,0 +1,46 @@
+package models
+
+import (
+	"encoding/base64"
+	"net/http"
+	"time"
+	"unicode/utf8"
+)
+
+type VerificationStatus string
+
+const (
+	// the source does not verify requests
+	VerificationStatusNone     VerificationStatus = "none"
+	VerificationStatusVerified VerificationStatus = "verified"
+	VerificationStatusFailed   VerificationStatus = "failed"
+)
+
+// Request recorded by a capture source, as sent by the client
+type CapturedRequest struct {
+	ReqID      string      `json:"reqID"`
+	ReceivedAt time.Time   `json:"receivedAt"`
+	Method     string      `json:"method"`
+	Path       string      `json:"path"`
+	RawQuery   string      `json:"rawQuery"`
+	Headers    http.Header `json:"headers"`
+	ClientIP   string      `json:"clientIP,omitempty"`
+	// Request body, set if it is valid UTF-8
+	Body string `json:"body"`
+	// Base64 encoded request body, set if it is not valid UTF-8, e.g. compressed bodies
+	BodyBase64         string             `json:"bodyBase64,omitempty"`
+	VerificationStatus VerificationStatus `json:"verificationStatus"`
+	VerificationError  string             `json:"verificationError,omitempty"`
+}
+
+// SetBody sets the body, base64 encoded if it is not valid UTF-8
+func (c *CapturedRequest) SetBody(body []byte) {
+	if utf8.Valid(body) {
+		c.Body = string(body)
+		c.BodyBase64 = ""
+		return
+	}
+
+	c.Body = ""
+	c.BodyBase64 = base64.StdEncoding.EncodeToString(body)
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCapturedRequest_SetBody(t *testing.T) {
+	c := &CapturedRequest{}
+
+	c.SetBody([]byte(`{"id": "abc"}`))
+	assert.Equal(t, `{"id": "abc"}`, c.Body)
+	assert.Equal(t, "", c.BodyBase64)
+
+	c.SetBody([]byte{0x1f, 0x8b, 0xff})
+	assert.Equal(t, "", c.Body)
+	assert.Equal(t, "H4v/", c.BodyBase64)
+}
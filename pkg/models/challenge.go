@@ -0,0 +1,26 @@
+package models
+
+type ChallengeType string
+
+const (
+	// Slack Events API url_verification request
+	ChallengeTypeSlack ChallengeType = "slack"
+	// Meta (Facebook, Instagram, WhatsApp) hub.challenge verification request
+	ChallengeTypeMeta ChallengeType = "meta"
+)
+
+var ChallengeTypes = []ChallengeType{
+	ChallengeTypeSlack,
+	ChallengeTypeMeta,
+}
+
+type Challenge struct {
+	ChallengeType ChallengeType `yaml:"challengeType"`
+	// Name of the environment variable containing the verify token expected in Meta challenges
+	VerifyTokenEnvVar string `yaml:"verifyTokenEnvVar"`
+}
+
+type ChallengeResponse struct {
+	ContentType string
+	Body        []byte
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+	"time"
+
+	"github.com/didil/inhooks/pkg/lib"
+)
+
+// Schedule of cron sources, which emit a message to the flow sinks on each tick
+type Cron struct {
+	// Cron expression, e.g. 0 2 * * * for every day at 2am
+	Schedule string `yaml:"schedule"`
+	// IANA time zone of the schedule, e.g. Europe/Paris. Defaults to UTC.
+	Timezone string `yaml:"timezone"`
+	// Payload of the messages, as a Go template executed with the CronTick, e.g. {"date": "{{ .Time.Format "2006-01-02" }}"}
+	Payload string `yaml:"payload"`
+	// HTTP headers of the messages
+	Headers map[string]string `yaml:"headers"`
+
+	schedule        *lib.CronSchedule
+	location        *time.Location
+	payloadTemplate *template.Template
+}
+
+// Data the cron payload template is executed with
+type CronTick struct {
+	// Scheduled time of the tick, in the schedule time zone
+	Time     time.Time
+	FlowID   string
+	SourceID string
+}
+
+// Compile parses the cron schedule, time zone and payload template
+func (c *Cron) Compile() error {
+	schedule, err := lib.ParseCronSchedule(c.Schedule)
+	if err != nil {
+		return err
+	}
+	c.schedule = schedule
+
+	location, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return fmt.Errorf("invalid time zone %s: %w", c.Timezone, err)
+	}
+	c.location = location
+
+	payloadTemplate, err := template.New("payload").Option("missingkey=error").Parse(c.Payload)
+	if err != nil {
+		return fmt.Errorf("invalid payload template: %w", err)
+	}
+	c.payloadTemplate = payloadTemplate
+
+	return nil
+}
+
+// Next returns the first tick strictly after t, or the zero time if the schedule has no next tick
+func (c *Cron) Next(t time.Time) time.Time {
+	return c.schedule.Next(t.In(c.location))
+}
+
+// RenderPayload executes the payload template for a tick
+func (c *Cron) RenderPayload(tick *CronTick) ([]byte, error) {
+	if c.payloadTemplate == nil {
+		return nil, fmt.Errorf("cron payload template not compiled")
+	}
+
+	buf := &bytes.Buffer{}
+	err := c.payloadTemplate.Execute(buf, tick)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render cron payload: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
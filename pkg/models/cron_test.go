@@ -0,0 +1,35 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCron(t *testing.T) {
+	c := &Cron{
+		Schedule: "0 2 * * *",
+		Timezone: "Europe/Paris",
+		Payload:  `{"source": "{{ .SourceID }}", "tick": "{{ .Time.Format "2006-01-02T15:04:05Z07:00" }}"}`,
+	}
+	assert.NoError(t, c.Compile())
+
+	paris, err := time.LoadLocation("Europe/Paris")
+	assert.NoError(t, err)
+
+	// the schedule is in the cron time zone
+	tick := c.Next(time.Date(2023, 05, 5, 8, 9, 12, 0, time.UTC))
+	assert.Equal(t, time.Date(2023, 05, 6, 2, 0, 0, 0, paris), tick)
+
+	payload, err := c.RenderPayload(&CronTick{Time: tick, FlowID: "flow-1", SourceID: "source-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"source": "source-1", "tick": "2023-05-06T02:00:00+02:00"}`, string(payload))
+
+	// static payload
+	c = &Cron{Schedule: "@hourly", Payload: `{"task": "sync"}`}
+	assert.NoError(t, c.Compile())
+	payload, err = c.RenderPayload(&CronTick{Time: tick})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"task": "sync"}`, string(payload))
+}
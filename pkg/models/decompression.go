@@ -0,0 +1,23 @@
+package models
+
+type SignedPayload string
+
+const (
+	// The signature covers the body as sent, before decompression
+	SignedPayloadCompressed SignedPayload = "compressed"
+	// The signature covers the decompressed body
+	SignedPayloadDecompressed SignedPayload = "decompressed"
+)
+
+var SignedPayloads = []SignedPayload{
+	SignedPayloadCompressed,
+	SignedPayloadDecompressed,
+}
+
+// Decoding of compressed request bodies (Content-Encoding gzip, deflate or br)
+type Decompression struct {
+	// Bytes used for signature verification. Defaults to compressed.
+	SignedPayload SignedPayload `yaml:"signedPayload"`
+	// Max decompressed body size in bytes. Defaults to the INGEST_MAX_DECOMPRESSED_BYTES env var.
+	MaxDecompressedBytes *int64 `yaml:"maxDecompressedBytes"`
+}
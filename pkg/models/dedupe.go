@@ -0,0 +1,50 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/didil/inhooks/pkg/lib"
+)
+
+// Deduplication of ingest requests by idempotency key. Exactly one of Header and JSONPath must be set.
+type Dedupe struct {
+	// Header containing the idempotency key, e.g. X-GitHub-Delivery
+	Header string `yaml:"header"`
+	// JSON path of the idempotency key in the request body, e.g. $.id
+	JSONPath string `yaml:"jsonPath"`
+	// Duration during which requests with the same key are dropped. Defaults to the INGEST_DEDUPE_TTL env var.
+	TTL *time.Duration `yaml:"ttl"`
+
+	jsonPath *lib.JSONPath
+}
+
+// Compile parses the dedupe JSON path
+func (d *Dedupe) Compile() error {
+	d.jsonPath = nil
+	if d.JSONPath == "" {
+		return nil
+	}
+
+	jsonPath, err := lib.ParseJSONPath(d.JSONPath)
+	if err != nil {
+		return err
+	}
+	d.jsonPath = jsonPath
+
+	return nil
+}
+
+// PayloadKey returns the value found at the dedupe JSON path of the decoded payload, or false if there is none
+func (d *Dedupe) PayloadKey(doc interface{}) (interface{}, bool, error) {
+	if d.jsonPath == nil {
+		return nil, false, fmt.Errorf("dedupe json path not compiled")
+	}
+
+	value, ok := d.jsonPath.Lookup(doc)
+	if !ok || value == nil {
+		return nil, false, nil
+	}
+
+	return value, true, nil
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Message to store and add to a queue in a batch
+type EnqueueEntry struct {
+	MessageKey string
+	Value      []byte
+	QueueKey   string
+	MessageID  string
+	// Score of the message in a sorted set queue. The message is pushed to a list queue if nil.
+	Score *float64
+}
+
+// Key set in the same transaction as the enqueued messages, e.g. to record that a cron tick was emitted
+type EnqueueMarker struct {
+	Key   string
+	Value []byte
+	TTL   time.Duration
+}
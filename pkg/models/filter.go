@@ -0,0 +1,161 @@
+package models
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/didil/inhooks/pkg/lib"
+)
+
+// Condition on the ingested requests. A condition is either a header or JSON path value condition, or a combination of conditions with and, or, not.
+type Filter struct {
+	// Name of the header to match
+	Header string `yaml:"header"`
+	// JSON path of the payload value to match, e.g. $.action
+	JSONPath string `yaml:"jsonPath"`
+	// The value must be equal to Equals. Numbers and booleans are compared using their JSON representation.
+	Equals *string `yaml:"equals"`
+	// The value must match the regular expression. The value only needs to be present if neither Equals nor Regex is set.
+	Regex string `yaml:"regex"`
+
+	// All the conditions must match
+	And []*Filter `yaml:"and"`
+	// At least one of the conditions must match
+	Or []*Filter `yaml:"or"`
+	// The condition must not match
+	Not *Filter `yaml:"not"`
+
+	regex    *regexp.Regexp
+	jsonPath *lib.JSONPath
+}
+
+// Compile validates the filter and compiles its regular expressions and JSON paths
+func (f *Filter) Compile() error {
+	kinds := 0
+	for _, set := range []bool{f.Header != "", f.JSONPath != "", f.And != nil, f.Or != nil, f.Not != nil} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		return fmt.Errorf("filter requires exactly one of header, jsonPath, and, or, not")
+	}
+
+	if f.Header == "" && f.JSONPath == "" && (f.Equals != nil || f.Regex != "") {
+		return fmt.Errorf("filter equals and regex require a header or jsonPath")
+	}
+
+	if f.Equals != nil && f.Regex != "" {
+		return fmt.Errorf("filter cannot have both equals and regex")
+	}
+
+	if f.Regex != "" {
+		regex, err := regexp.Compile(f.Regex)
+		if err != nil {
+			return fmt.Errorf("invalid filter regex: %w", err)
+		}
+		f.regex = regex
+	}
+
+	if f.JSONPath != "" {
+		jsonPath, err := lib.ParseJSONPath(f.JSONPath)
+		if err != nil {
+			return fmt.Errorf("invalid filter json path: %w", err)
+		}
+		f.jsonPath = jsonPath
+	}
+
+	if (f.And != nil && len(f.And) == 0) || (f.Or != nil && len(f.Or) == 0) {
+		return fmt.Errorf("filter and, or cannot be empty")
+	}
+
+	conditions := append(append([]*Filter{}, f.And...), f.Or...)
+	if f.Not != nil {
+		conditions = append(conditions, f.Not)
+	}
+	for _, condition := range conditions {
+		if condition == nil {
+			return fmt.Errorf("filter condition cannot be empty")
+		}
+
+		err := condition.Compile()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Match returns true if the request headers and payload match the filter. doc is the decoded JSON payload, or nil if the payload is not JSON.
+func (f *Filter) Match(headers http.Header, doc interface{}) bool {
+	switch {
+	case f.Header != "":
+		for _, value := range headers.Values(f.Header) {
+			if f.matchValue(value) {
+				return true
+			}
+		}
+		return false
+	case f.JSONPath != "":
+		if doc == nil || f.jsonPath == nil {
+			return false
+		}
+
+		value, ok := f.jsonPath.Lookup(doc)
+		if !ok {
+			return false
+		}
+
+		str, err := lib.JSONValueString(value)
+		if err != nil {
+			return false
+		}
+		return f.matchValue(str)
+	case f.And != nil:
+		for _, condition := range f.And {
+			if !condition.Match(headers, doc) {
+				return false
+			}
+		}
+		return true
+	case f.Or != nil:
+		for _, condition := range f.Or {
+			if condition.Match(headers, doc) {
+				return true
+			}
+		}
+		return false
+	case f.Not != nil:
+		return !f.Not.Match(headers, doc)
+	default:
+		return false
+	}
+}
+
+// UsesJSONPath returns true if the filter has a JSON path condition
+func (f *Filter) UsesJSONPath() bool {
+	if f.JSONPath != "" {
+		return true
+	}
+
+	for _, condition := range append(append([]*Filter{}, f.And...), f.Or...) {
+		if condition.UsesJSONPath() {
+			return true
+		}
+	}
+
+	return f.Not != nil && f.Not.UsesJSONPath()
+}
+
+func (f *Filter) matchValue(value string) bool {
+	switch {
+	case f.Equals != nil:
+		return value == *f.Equals
+	case f.regex != nil:
+		return f.regex.MatchString(value)
+	default:
+		return true
+	}
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/didil/inhooks/pkg/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func compileFilter(t *testing.T, conf string) *Filter {
+	f := &Filter{}
+	require.NoError(t, yaml.Unmarshal([]byte(conf), f))
+	require.NoError(t, f.Compile())
+	return f
+}
+
+func TestFilter_Compile_Errors(t *testing.T) {
+	confs := map[string]string{
+		"empty":               `{}`,
+		"header and jsonPath": `{header: X-Event, jsonPath: $.action}`,
+		"equals without key":  `{equals: push}`,
+		"equals and regex":    `{header: X-Event, equals: push, regex: "^push"}`,
+		"invalid regex":       `{header: X-Event, regex: "("}`,
+		"invalid json path":   `{jsonPath: "$.items[0"}`,
+		"empty and":           `{and: []}`,
+		"invalid nested":      `{or: [{header: X-Event}, {equals: push}]}`,
+	}
+
+	for name, conf := range confs {
+		t.Run(name, func(t *testing.T) {
+			f := &Filter{}
+			require.NoError(t, yaml.Unmarshal([]byte(conf), f))
+			assert.Error(t, f.Compile())
+		})
+	}
+}
+
+func TestFilter_Match_Header(t *testing.T) {
+	headers := http.Header{
+		"X-Github-Event": []string{"push"},
+	}
+
+	assert.True(t, compileFilter(t, `{header: X-GitHub-Event, equals: push}`).Match(headers, nil))
+	assert.False(t, compileFilter(t, `{header: X-GitHub-Event, equals: issues}`).Match(headers, nil))
+	assert.True(t, compileFilter(t, `{header: X-GitHub-Event, regex: "^(push|release)$"}`).Match(headers, nil))
+	assert.True(t, compileFilter(t, `{header: X-GitHub-Event}`).Match(headers, nil))
+	assert.False(t, compileFilter(t, `{header: X-Other}`).Match(headers, nil))
+}
+
+func TestFilter_Match_JSONPath(t *testing.T) {
+	doc, err := lib.DecodeJSON([]byte(`{"action":"opened","issue":{"number":12,"locked":false}}`))
+	require.NoError(t, err)
+
+	assert.True(t, compileFilter(t, `{jsonPath: $.action, equals: opened}`).Match(nil, doc))
+	assert.False(t, compileFilter(t, `{jsonPath: $.action, equals: closed}`).Match(nil, doc))
+	assert.True(t, compileFilter(t, `{jsonPath: $.issue.number, equals: "12"}`).Match(nil, doc))
+	assert.True(t, compileFilter(t, `{jsonPath: $.issue.locked, equals: "false"}`).Match(nil, doc))
+	assert.True(t, compileFilter(t, `{jsonPath: $.action, regex: "^open"}`).Match(nil, doc))
+	assert.False(t, compileFilter(t, `{jsonPath: $.label}`).Match(nil, doc))
+
+	// non JSON payloads
+	assert.False(t, compileFilter(t, `{jsonPath: $.action}`).Match(nil, nil))
+}
+
+func TestFilter_Match_Combinations(t *testing.T) {
+	headers := http.Header{
+		"X-Github-Event": []string{"issues"},
+	}
+	doc, err := lib.DecodeJSON([]byte(`{"action":"opened"}`))
+	require.NoError(t, err)
+
+	f := compileFilter(t, `
+and:
+  - header: X-GitHub-Event
+    equals: issues
+  - or:
+      - jsonPath: $.action
+        equals: opened
+      - jsonPath: $.action
+        equals: reopened
+`)
+	assert.True(t, f.UsesJSONPath())
+	assert.True(t, f.Match(headers, doc))
+	assert.False(t, f.Match(http.Header{"X-Github-Event": []string{"push"}}, doc))
+
+	f = compileFilter(t, `{not: {header: X-GitHub-Event, equals: push}}`)
+	assert.False(t, f.UsesJSONPath())
+	assert.True(t, f.Match(headers, doc))
+	assert.False(t, f.Match(http.Header{"X-Github-Event": []string{"push"}}, doc))
+}
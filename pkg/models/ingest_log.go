@@ -0,0 +1,28 @@
+package models
+
+import (
+	"net/http"
+	"time"
+)
+
+// Log of the requests ingested by a source, used to backfill sinks
+type IngestLog struct {
+	// Duration during which the requests are kept. Defaults to the INGEST_LOG_RETENTION env var.
+	Retention *time.Duration `yaml:"retention"`
+}
+
+// Request recorded in the source ingest log, with its body as sent by the client
+type IngestLogEntry struct {
+	IngestedReqID string      `json:"ingestedReqID"`
+	ReceivedAt    time.Time   `json:"receivedAt"`
+	HttpMethod    string      `json:"httpMethod"`
+	HttpHeaders   http.Header `json:"httpHeaders"`
+	RawQuery      string      `json:"rawQuery"`
+	PathSuffix    string      `json:"pathSuffix"`
+	HttpPath      string      `json:"httpPath"`
+	ClientIP      string      `json:"clientIP"`
+	TLSVersion    string      `json:"tlsVersion,omitempty"`
+	Body          []byte      `json:"body"`
+	// Key of the body in the payload store when it is too large to be stored in redis
+	BodyRef string `json:"bodyRef,omitempty"`
+}
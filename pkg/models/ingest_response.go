@@ -0,0 +1,115 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"mime"
+	"strings"
+	"text/template"
+)
+
+// Response sent to the sender of an ingest request
+type IngestResponse struct {
+	// HTTP status code. Defaults to 200 for success responses and to the error status code for failure responses.
+	StatusCode int `yaml:"statusCode"`
+	// Content-Type header
+	ContentType string `yaml:"contentType"`
+	// Body template, using the go text/template syntax. Available fields: .ReqID, .SourceSlug, .Error
+	// Values are JSON string escaped for JSON content types, left as is for text/plain and HTML escaped otherwise.
+	Body string `yaml:"body"`
+	// Extra headers
+	Headers map[string]string `yaml:"headers"`
+
+	bodyTmpl *template.Template
+}
+
+type IngestResponseData struct {
+	ReqID      string
+	SourceSlug string
+	Error      string
+}
+
+// Compile parses the body template
+func (r *IngestResponse) Compile() error {
+	r.bodyTmpl = nil
+	if r.Body == "" {
+		return nil
+	}
+
+	tmpl, err := template.New("body").Option("missingkey=error").Parse(r.Body)
+	if err != nil {
+		return fmt.Errorf("failed to parse body template: %w", err)
+	}
+	r.bodyTmpl = tmpl
+
+	return nil
+}
+
+// RenderBody renders the body template, escaping the data values according to the content type
+func (r *IngestResponse) RenderBody(data *IngestResponseData) ([]byte, error) {
+	if r.Body == "" {
+		return nil, nil
+	}
+	if r.bodyTmpl == nil {
+		return nil, fmt.Errorf("body template not compiled")
+	}
+
+	escape := r.escaper()
+	escapedData := &IngestResponseData{
+		ReqID:      escape(data.ReqID),
+		SourceSlug: escape(data.SourceSlug),
+		Error:      escape(data.Error),
+	}
+
+	buf := &bytes.Buffer{}
+	err := r.bodyTmpl.Execute(buf, escapedData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute body template: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// escaper returns the function escaping template values for the response content type.
+// Bodies without a content type are sniffed by the http server, so they are HTML escaped.
+func (r *IngestResponse) escaper() func(string) string {
+	mediaType, _, err := mime.ParseMediaType(r.ContentType)
+	if err != nil {
+		return html.EscapeString
+	}
+
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return jsonEscapeString
+	case mediaType == "text/plain":
+		return func(s string) string { return s }
+	default:
+		return html.EscapeString
+	}
+}
+
+// jsonEscapeString escapes s to be written inside a JSON string
+func jsonEscapeString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b[1 : len(b)-1])
+}
+
+func validateIngestResponse(r *IngestResponse, field string) error {
+	if r.StatusCode != 0 && (r.StatusCode < 100 || r.StatusCode > 599) {
+		return fmt.Errorf("invalid %s status code: %d", field, r.StatusCode)
+	}
+
+	err := r.Compile()
+	if err != nil {
+		return fmt.Errorf("invalid %s body: %w", field, err)
+	}
+
+	_, err = r.RenderBody(&IngestResponseData{})
+	if err != nil {
+		return fmt.Errorf("invalid %s body: %w", field, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIngestResponse_RenderBody(t *testing.T) {
+	r := &IngestResponse{
+		ContentType: "application/json",
+		Body:        `{"ok":false,"reqID":"{{.ReqID}}","error":"{{.Error}}"}`,
+	}
+	assert.NoError(t, r.Compile())
+
+	body, err := r.RenderBody(&IngestResponseData{ReqID: "req-1", SourceSlug: "source-1", Error: "invalid signature"})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"ok":false,"reqID":"req-1","error":"invalid signature"}`, string(body))
+
+	r = &IngestResponse{}
+	assert.NoError(t, r.Compile())
+	body, err = r.RenderBody(&IngestResponseData{})
+	assert.NoError(t, err)
+	assert.Nil(t, body)
+
+	r = &IngestResponse{Body: "{{.ReqID}}"}
+	_, err = r.RenderBody(&IngestResponseData{})
+	assert.EqualError(t, err, "body template not compiled")
+}
+
+func TestIngestResponse_RenderBody_Escaping(t *testing.T) {
+	data := &IngestResponseData{ReqID: "req-1", SourceSlug: `a"/><script>x</script>`, Error: "bad \"value\"\n"}
+
+	tcs := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    string
+	}{
+		{
+			name:        "json",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"source":"{{.SourceSlug}}","error":"{{.Error}}"}`,
+			expected:    `{"source":"a\"/\u003e\u003cscript\u003ex\u003c/script\u003e","error":"bad \"value\"\n"}`,
+		},
+		{
+			name:        "json suffix",
+			contentType: "application/problem+json",
+			body:        `{"error":"{{.Error}}"}`,
+			expected:    `{"error":"bad \"value\"\n"}`,
+		},
+		{
+			name:        "xml",
+			contentType: "application/xml",
+			body:        `<ack source="{{.SourceSlug}}"/>`,
+			expected:    `<ack source="a&#34;/&gt;&lt;script&gt;x&lt;/script&gt;"/>`,
+		},
+		{
+			name:        "html",
+			contentType: "text/html",
+			body:        `<p>{{.SourceSlug}}</p>`,
+			expected:    `<p>a&#34;/&gt;&lt;script&gt;x&lt;/script&gt;</p>`,
+		},
+		{
+			name:        "no content type",
+			contentType: "",
+			body:        `{{.SourceSlug}}`,
+			expected:    `a&#34;/&gt;&lt;script&gt;x&lt;/script&gt;`,
+		},
+		{
+			name:        "plain text",
+			contentType: "text/plain",
+			body:        `{{.SourceSlug}}`,
+			expected:    `a"/><script>x</script>`,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &IngestResponse{ContentType: tc.contentType, Body: tc.body}
+			assert.NoError(t, r.Compile())
+
+			body, err := r.RenderBody(data)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, string(body))
+		})
+	}
+}
+
+func TestValidateIngestResponse(t *testing.T) {
+	err := validateIngestResponse(&IngestResponse{StatusCode: 204}, "response")
+	assert.NoError(t, err)
+
+	err = validateIngestResponse(&IngestResponse{StatusCode: 1000}, "response")
+	assert.EqualError(t, err, "invalid response status code: 1000")
+
+	err = validateIngestResponse(&IngestResponse{Body: "{{.Unknown}}"}, "response")
+	assert.ErrorContains(t, err, "invalid response body: failed to execute body template")
+
+	err = validateIngestResponse(&IngestResponse{Body: "{{.ReqID"}, "response")
+	assert.ErrorContains(t, err, "invalid response body: failed to parse body template")
+
+	r := &IngestResponse{Body: "{{.ReqID}}"}
+	err = validateIngestResponse(r, "response")
+	assert.NoError(t, err)
+	assert.NotNil(t, r.bodyTmpl)
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"net/http"
+	"time"
+)
+
+// Request data shared by all the messages of an ingest request, stored once and referenced by the messages IngestID
+type IngestedRequest struct {
+	IngestedReqID string      `json:"ingestedReqID"`
+	HttpMethod    string      `json:"httpMethod"`
+	HttpHeaders   http.Header `json:"httpHeaders"`
+	RawQuery      string      `json:"rawQuery"`
+	PathSuffix    string      `json:"pathSuffix"`
+	ReceivedAt    time.Time   `json:"receivedAt"`
+	HttpPath      string      `json:"httpPath"`
+	ClientIP      string      `json:"clientIP"`
+	UserAgent     string      `json:"userAgent"`
+	TLSVersion    string      `json:"tlsVersion,omitempty"`
+	Payload       []byte      `json:"payload"`
+}
+
+// Ingested request to store once in a batch, with the number of messages referencing it
+type IngestEntry struct {
+	IngestKey string
+	Value     []byte
+	Refs      int
+}
@@ -2,7 +2,9 @@ package models
 
 import (
 	"fmt"
+	"mime"
 	"net/url"
+	"os"
 	"regexp"
 
 	"github.com/didil/inhooks/pkg/lib"
@@ -12,6 +14,8 @@ import (
 type InhooksConfig struct {
 	Flows                []*Flow                `yaml:"flows"`
 	TransformDefinitions []*TransformDefinition `yaml:"transform_definitions"`
+	// Response sent for requests to unknown source slugs. Defaults to a JSON error with status 404.
+	UnknownSourceResponse *IngestResponse `yaml:"unknown_source_response"`
 }
 
 var idRegex = regexp.MustCompile(`^[a-zA-Z0-9\-]{1,255}$`)
@@ -26,6 +30,13 @@ func ValidateInhooksConfig(appConf *lib.AppConfig, c *InhooksConfig) error {
 		return fmt.Errorf("no flows defined")
 	}
 
+	if c.UnknownSourceResponse != nil {
+		err := validateIngestResponse(c.UnknownSourceResponse, "unknown source response")
+		if err != nil {
+			return err
+		}
+	}
+
 	flowIDs := map[string]bool{}
 	sourceSlugs := map[string]bool{}
 	transformIDs := map[string]bool{}
@@ -83,6 +94,209 @@ func ValidateInhooksConfig(appConf *lib.AppConfig, c *InhooksConfig) error {
 			return fmt.Errorf("invalid source type: %s. allowed: %v", source.Type, SourceTypes)
 		}
 
+		if source.Type == SourceTypeRedisStream {
+			err := validateRedisStreamSource(source)
+			if err != nil {
+				return err
+			}
+		} else if source.Stream != nil {
+			return fmt.Errorf("stream can only be used with redis-stream sources")
+		}
+
+		if source.Type == SourceTypeCron {
+			if source.Cron == nil {
+				return fmt.Errorf("cron sources require a cron schedule")
+			}
+
+			err := source.Cron.Compile()
+			if err != nil {
+				return fmt.Errorf("invalid cron: %w", err)
+			}
+		} else if source.Cron != nil {
+			return fmt.Errorf("cron can only be used with cron sources")
+		}
+
+		if source.Type == SourceTypePoll {
+			err := validatePollSource(appConf, source)
+			if err != nil {
+				return err
+			}
+		} else if source.Poll != nil {
+			return fmt.Errorf("poll can only be used with poll sources")
+		}
+
+		if !source.AcceptsHttpRequests() {
+			if source.Sync != nil {
+				return fmt.Errorf("%s sources cannot use sync delivery", source.Type)
+			}
+
+			if source.Capture {
+				return fmt.Errorf("%s sources cannot capture requests", source.Type)
+			}
+
+			if source.IngestLog != nil {
+				return fmt.Errorf("%s sources cannot use an ingest log", source.Type)
+			}
+
+			err := validateNoHttpOptions(source)
+			if err != nil {
+				return err
+			}
+		}
+
+		for j, cidr := range source.AllowedCIDRs {
+			_, err := lib.ParseIPPrefix(cidr)
+			if err != nil {
+				return fmt.Errorf("invalid cidr flows[%d].source.allowedCIDRs[%d]: %s", i, j, cidr)
+			}
+		}
+
+		if source.SuccessResponse != nil {
+			err := validateIngestResponse(source.SuccessResponse, fmt.Sprintf("flows[%d].source.successResponse", i))
+			if err != nil {
+				return err
+			}
+		}
+
+		if source.FailureResponse != nil {
+			err := validateIngestResponse(source.FailureResponse, fmt.Sprintf("flows[%d].source.failureResponse", i))
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, method := range source.AllowedMethods {
+			if !slices.Contains(SourceHttpMethods, method) {
+				return fmt.Errorf("invalid source allowed method: %s. allowed: %v", method, SourceHttpMethods)
+			}
+		}
+
+		if source.RateLimit != nil {
+			rateLimit := source.RateLimit
+			if rateLimit.Requests <= 0 {
+				return fmt.Errorf("rate limit requests must be positive")
+			}
+
+			if rateLimit.Interval <= 0 {
+				return fmt.Errorf("rate limit interval must be positive")
+			}
+
+			if rateLimit.Burst == nil {
+				rateLimit.Burst = &rateLimit.Requests
+			}
+
+			if *rateLimit.Burst <= 0 {
+				return fmt.Errorf("rate limit burst must be positive")
+			}
+		}
+
+		if source.MaxQueueDepth != nil && *source.MaxQueueDepth <= 0 {
+			return fmt.Errorf("max queue depth must be positive")
+		}
+
+		if source.MaxBodyBytes != nil && *source.MaxBodyBytes <= 0 {
+			return fmt.Errorf("max body bytes must be positive")
+		}
+
+		if source.Decompression != nil {
+			decompression := source.Decompression
+			if decompression.SignedPayload == "" {
+				decompression.SignedPayload = SignedPayloadCompressed
+			}
+
+			if !slices.Contains(SignedPayloads, decompression.SignedPayload) {
+				return fmt.Errorf("invalid decompression signed payload: %s. allowed: %v", decompression.SignedPayload, SignedPayloads)
+			}
+
+			if decompression.MaxDecompressedBytes == nil {
+				decompression.MaxDecompressedBytes = &appConf.Ingest.MaxDecompressedBytes
+			}
+
+			if *decompression.MaxDecompressedBytes <= 0 {
+				return fmt.Errorf("max decompressed bytes must be positive")
+			}
+		}
+
+		if source.Dedupe != nil {
+			dedupe := source.Dedupe
+			if (dedupe.Header == "") == (dedupe.JSONPath == "") {
+				return fmt.Errorf("dedupe requires exactly one of header or jsonPath")
+			}
+
+			err := dedupe.Compile()
+			if err != nil {
+				return fmt.Errorf("invalid dedupe json path: %w", err)
+			}
+
+			if dedupe.TTL == nil {
+				dedupe.TTL = &appConf.Ingest.DedupeTTL
+			}
+
+			if *dedupe.TTL <= 0 {
+				return fmt.Errorf("dedupe ttl must be positive")
+			}
+		}
+
+		if source.Split != nil {
+			err := source.Split.Compile()
+			if err != nil {
+				return fmt.Errorf("invalid split json path: %w", err)
+			}
+
+			if source.Sync != nil {
+				return fmt.Errorf("split cannot be used with sync delivery")
+			}
+		}
+
+		if source.IngestLog != nil {
+			ingestLog := source.IngestLog
+			if ingestLog.Retention == nil {
+				ingestLog.Retention = &appConf.Ingest.LogRetention
+			}
+
+			if *ingestLog.Retention <= 0 {
+				return fmt.Errorf("ingest log retention must be positive")
+			}
+		}
+
+		if source.Schema != nil {
+			schema := source.Schema
+			if (schema.Inline == nil) == (schema.File == "") {
+				return fmt.Errorf("schema requires exactly one of inline or file")
+			}
+
+			if schema.Mode == "" {
+				schema.Mode = SchemaModeReject
+			}
+
+			if !slices.Contains(SchemaModes, schema.Mode) {
+				return fmt.Errorf("invalid schema mode: %s. allowed: %v", schema.Mode, SchemaModes)
+			}
+
+			err := schema.Compile()
+			if err != nil {
+				return fmt.Errorf("invalid schema: %w", err)
+			}
+		}
+
+		for _, contentType := range source.AllowedContentTypes {
+			_, params, err := mime.ParseMediaType(contentType)
+			if err != nil || len(params) > 0 {
+				return fmt.Errorf("invalid source allowed content type: %s", contentType)
+			}
+		}
+
+		if source.Challenge != nil {
+			challenge := source.Challenge
+			if !slices.Contains(ChallengeTypes, challenge.ChallengeType) {
+				return fmt.Errorf("invalid challenge type: %s. allowed: %v", challenge.ChallengeType, ChallengeTypes)
+			}
+
+			if challenge.ChallengeType == ChallengeTypeMeta && challenge.VerifyTokenEnvVar == "" {
+				return fmt.Errorf("challenge verify token env var required")
+			}
+		}
+
 		if source.Verification != nil {
 			verification := source.Verification
 			if verification.VerificationType != "" && !slices.Contains(VerificationTypes, verification.VerificationType) {
@@ -99,8 +313,19 @@ func ValidateInhooksConfig(appConf *lib.AppConfig, c *InhooksConfig) error {
 				}
 			}
 
-			if verification.SignatureHeader == "" {
-				return fmt.Errorf("verification signature header required")
+			switch verification.VerificationType {
+			case VerificationTypeBasicAuth:
+				if verification.BasicAuthUsername == "" {
+					return fmt.Errorf("verification basic auth username required")
+				}
+			case VerificationTypeQueryToken:
+				if verification.TokenQueryParam == "" {
+					return fmt.Errorf("verification token query param required")
+				}
+			default:
+				if verification.SignatureHeader == "" {
+					return fmt.Errorf("verification signature header required")
+				}
 			}
 
 			if verification.CurrentSecretEnvVar == "" {
@@ -108,8 +333,8 @@ func ValidateInhooksConfig(appConf *lib.AppConfig, c *InhooksConfig) error {
 			}
 		}
 
-		if len(f.Sinks) == 0 {
-			return fmt.Errorf("flow sinks cannot be empty")
+		if len(f.Sinks) == 0 && !f.Source.Capture {
+			return fmt.Errorf("flow sinks cannot be empty unless the source captures requests")
 		}
 
 		for j, sink := range f.Sinks {
@@ -134,6 +359,14 @@ func ValidateInhooksConfig(appConf *lib.AppConfig, c *InhooksConfig) error {
 			}
 
 			if sink.Type == SinkTypeHttp {
+				if sink.Method != "" && sink.Method != SinkMethodPreserve && !slices.Contains(SourceHttpMethods, sink.Method) {
+					return fmt.Errorf("invalid sink method: %s. allowed: %v or %s", sink.Method, SourceHttpMethods, SinkMethodPreserve)
+				}
+
+				if sink.PathMode != "" && !slices.Contains(SinkPathModes, sink.PathMode) {
+					return fmt.Errorf("invalid sink path mode: %s. allowed: %v", sink.PathMode, SinkPathModes)
+				}
+
 				u, err := url.ParseRequestURI(sink.URL)
 				if err != nil {
 					return fmt.Errorf("invalid url: %s", sink.URL)
@@ -143,6 +376,13 @@ func ValidateInhooksConfig(appConf *lib.AppConfig, c *InhooksConfig) error {
 				}
 			}
 
+			if sink.Filter != nil {
+				err := sink.Filter.Compile()
+				if err != nil {
+					return fmt.Errorf("invalid sink filter: %w", err)
+				}
+			}
+
 			// validate transform
 			if sink.Transform != nil {
 				if !transformIDs[sink.Transform.ID] {
@@ -150,6 +390,109 @@ func ValidateInhooksConfig(appConf *lib.AppConfig, c *InhooksConfig) error {
 				}
 			}
 		}
+
+		if source.Sync != nil {
+			sync := source.Sync
+			if !slices.ContainsFunc(f.Sinks, func(sink *Sink) bool { return sink.ID == sync.SinkID }) {
+				return fmt.Errorf("sync sink id not found: %s", sync.SinkID)
+			}
+
+			if sync.Timeout == nil {
+				sync.Timeout = &appConf.Ingest.SyncTimeout
+			}
+
+			if *sync.Timeout <= 0 {
+				return fmt.Errorf("sync timeout must be positive")
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateRedisStreamSource(source *Source) error {
+	stream := source.Stream
+	if stream == nil || stream.Key == "" {
+		return fmt.Errorf("redis-stream sources require a stream key")
+	}
+
+	if stream.Group == "" {
+		stream.Group = RedisStreamDefaultGroup
+	}
+
+	if stream.PayloadField == "" {
+		stream.PayloadField = RedisStreamDefaultPayloadField
+	}
+
+	return nil
+}
+
+func validatePollSource(appConf *lib.AppConfig, source *Source) error {
+	poll := source.Poll
+	if poll == nil {
+		return fmt.Errorf("poll sources require a poll config")
+	}
+
+	u, err := url.Parse(poll.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid poll url: %s", poll.URL)
+	}
+
+	if poll.Interval <= 0 {
+		return fmt.Errorf("poll interval must be positive")
+	}
+
+	if poll.Cursor != nil && poll.Cursor.QueryParam == "" {
+		return fmt.Errorf("poll cursor query param required")
+	}
+
+	for name, envVar := range poll.HeaderEnvVars {
+		if os.Getenv(envVar) == "" {
+			return fmt.Errorf("env var %s of poll header %s not set", envVar, name)
+		}
+	}
+
+	err = poll.Compile()
+	if err != nil {
+		return fmt.Errorf("invalid poll: %w", err)
+	}
+
+	if poll.DedupeTTL == nil {
+		poll.DedupeTTL = &appConf.Ingest.DedupeTTL
+	}
+
+	if *poll.DedupeTTL <= 0 {
+		return fmt.Errorf("poll dedupe ttl must be positive")
+	}
+
+	return nil
+}
+
+// validateNoHttpOptions rejects the options that only apply to the requests sent to the ingest endpoint
+func validateNoHttpOptions(source *Source) error {
+	httpOptions := []struct {
+		name string
+		set  bool
+	}{
+		{"verification", source.Verification != nil},
+		{"allowedCIDRs", len(source.AllowedCIDRs) > 0},
+		{"allowedCIDRsFile", source.AllowedCIDRsFile != ""},
+		{"rateLimit", source.RateLimit != nil},
+		{"maxQueueDepth", source.MaxQueueDepth != nil},
+		{"challenge", source.Challenge != nil},
+		{"allowedMethods", len(source.AllowedMethods) > 0},
+		{"maxBodyBytes", source.MaxBodyBytes != nil},
+		{"allowedContentTypes", len(source.AllowedContentTypes) > 0},
+		{"dedupe", source.Dedupe != nil},
+		{"schema", source.Schema != nil},
+		{"successResponse", source.SuccessResponse != nil},
+		{"failureResponse", source.FailureResponse != nil},
+	}
+
+	for _, option := range httpOptions {
+		if option.set {
+			return fmt.Errorf("%s sources cannot use %s", source.Type, option.name)
+		}
 	}
 
 	return nil
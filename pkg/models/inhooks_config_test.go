@@ -2,6 +2,9 @@ package models
 
 import (
 	"context"
+	"net/http"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -246,7 +249,7 @@ func TestValidateInhooksConfig_InvalidSourceType(t *testing.T) {
 		},
 	}
 
-	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "invalid source type: abc. allowed: [http]")
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "invalid source type: abc. allowed: [http redis-stream cron poll]")
 }
 
 func TestValidateInhooksConfig_InvalidSinkType(t *testing.T) {
@@ -333,7 +336,7 @@ func TestValidateInhooksConfig_InvalidVerificationType(t *testing.T) {
 		},
 	}
 
-	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "invalid verification type: random. allowed: [hmac]")
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "invalid verification type: random. allowed: [hmac basic-auth token query-token]")
 }
 
 func TestValidateInhooksConfig_InvalidHMACAlgorithm(t *testing.T) {
@@ -482,3 +485,819 @@ func TestValidateInhooksConfig_EmptyTransformScript(t *testing.T) {
 
 	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "transform script cannot be empty")
 }
+
+func TestValidateInhooksConfig_VerificationMissingFields(t *testing.T) {
+	ctx := context.Background()
+	appConf, err := testsupport.InitAppConfig(ctx)
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		verification *Verification
+		expectedErr  string
+	}{
+		{
+			verification: &Verification{
+				VerificationType:    VerificationTypeBasicAuth,
+				CurrentSecretEnvVar: "SECRET",
+			},
+			expectedErr: "verification basic auth username required",
+		},
+		{
+			verification: &Verification{
+				VerificationType:    VerificationTypeToken,
+				CurrentSecretEnvVar: "SECRET",
+			},
+			expectedErr: "verification signature header required",
+		},
+		{
+			verification: &Verification{
+				VerificationType:    VerificationTypeQueryToken,
+				CurrentSecretEnvVar: "SECRET",
+			},
+			expectedErr: "verification token query param required",
+		},
+		{
+			verification: &Verification{
+				VerificationType: VerificationTypeQueryToken,
+				TokenQueryParam:  "token",
+			},
+			expectedErr: "verification current secret env var required",
+		},
+	}
+
+	for _, tc := range testCases {
+		c := &InhooksConfig{
+			Flows: []*Flow{
+				{
+					ID: "flow-1",
+					Source: &Source{
+						ID:           "source-1",
+						Slug:         "source-1-slug",
+						Type:         "http",
+						Verification: tc.verification,
+					},
+					Sinks: []*Sink{
+						{
+							ID:   "sink-1",
+							Type: "http",
+							URL:  "https://example.com/sink",
+						},
+					},
+				},
+			},
+		}
+
+		assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), tc.expectedErr)
+	}
+}
+
+func TestValidateInhooksConfig_InvalidAllowedCIDR(t *testing.T) {
+	ctx := context.Background()
+	appConf, err := testsupport.InitAppConfig(ctx)
+	assert.NoError(t, err)
+
+	c := &InhooksConfig{
+		Flows: []*Flow{
+			{
+				ID: "flow-1",
+				Source: &Source{
+					ID:           "source-1",
+					Slug:         "source-1-slug",
+					Type:         "http",
+					AllowedCIDRs: []string{"192.0.2.0/24", "192.0.2.0/33"},
+				},
+				Sinks: []*Sink{
+					{
+						ID:   "sink-1",
+						Type: "http",
+						URL:  "https://example.com/sink",
+					},
+				},
+			},
+		},
+	}
+
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "invalid cidr flows[0].source.allowedCIDRs[1]: 192.0.2.0/33")
+}
+
+func TestValidateInhooksConfig_RateLimit(t *testing.T) {
+	ctx := context.Background()
+	appConf, err := testsupport.InitAppConfig(ctx)
+	assert.NoError(t, err)
+
+	rateLimit := &RateLimit{
+		Requests: 100,
+		Interval: time.Minute,
+	}
+
+	c := &InhooksConfig{
+		Flows: []*Flow{
+			{
+				ID: "flow-1",
+				Source: &Source{
+					ID:        "source-1",
+					Slug:      "source-1-slug",
+					Type:      "http",
+					RateLimit: rateLimit,
+				},
+				Sinks: []*Sink{
+					{
+						ID:   "sink-1",
+						Type: "http",
+						URL:  "https://example.com/sink",
+					},
+				},
+			},
+		},
+	}
+
+	assert.NoError(t, ValidateInhooksConfig(appConf, c))
+	// burst defaults to requests
+	assert.Equal(t, 100, *rateLimit.Burst)
+
+	rateLimit.Interval = 0
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "rate limit interval must be positive")
+}
+
+func TestValidateInhooksConfig_BodyLimits(t *testing.T) {
+	ctx := context.Background()
+	appConf, err := testsupport.InitAppConfig(ctx)
+	assert.NoError(t, err)
+
+	maxBodyBytes := int64(1024)
+	source := &Source{
+		ID:                  "source-1",
+		Slug:                "source-1-slug",
+		Type:                "http",
+		MaxBodyBytes:        &maxBodyBytes,
+		AllowedContentTypes: []string{"application/json"},
+	}
+
+	c := &InhooksConfig{
+		Flows: []*Flow{
+			{
+				ID:     "flow-1",
+				Source: source,
+				Sinks: []*Sink{
+					{
+						ID:   "sink-1",
+						Type: "http",
+						URL:  "https://example.com/sink",
+					},
+				},
+			},
+		},
+	}
+
+	assert.NoError(t, ValidateInhooksConfig(appConf, c))
+
+	source.AllowedContentTypes = []string{"application/json; charset=utf-8"}
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "invalid source allowed content type: application/json; charset=utf-8")
+
+	source.AllowedContentTypes = nil
+	maxBodyBytes = 0
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "max body bytes must be positive")
+}
+
+func TestValidateInhooksConfig_Decompression(t *testing.T) {
+	ctx := context.Background()
+	appConf, err := testsupport.InitAppConfig(ctx)
+	assert.NoError(t, err)
+
+	decompression := &Decompression{}
+
+	c := &InhooksConfig{
+		Flows: []*Flow{
+			{
+				ID: "flow-1",
+				Source: &Source{
+					ID:            "source-1",
+					Slug:          "source-1-slug",
+					Type:          "http",
+					Decompression: decompression,
+				},
+				Sinks: []*Sink{
+					{
+						ID:   "sink-1",
+						Type: "http",
+						URL:  "https://example.com/sink",
+					},
+				},
+			},
+		},
+	}
+
+	assert.NoError(t, ValidateInhooksConfig(appConf, c))
+	// defaults
+	assert.Equal(t, SignedPayloadCompressed, decompression.SignedPayload)
+	assert.Equal(t, appConf.Ingest.MaxDecompressedBytes, *decompression.MaxDecompressedBytes)
+
+	decompression.SignedPayload = "other"
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "invalid decompression signed payload: other")
+}
+
+func TestValidateInhooksConfig_Dedupe(t *testing.T) {
+	ctx := context.Background()
+	appConf, err := testsupport.InitAppConfig(ctx)
+	assert.NoError(t, err)
+
+	dedupe := &Dedupe{
+		JSONPath: "$.id",
+	}
+
+	c := &InhooksConfig{
+		Flows: []*Flow{
+			{
+				ID: "flow-1",
+				Source: &Source{
+					ID:     "source-1",
+					Slug:   "source-1-slug",
+					Type:   "http",
+					Dedupe: dedupe,
+				},
+				Sinks: []*Sink{
+					{
+						ID:   "sink-1",
+						Type: "http",
+						URL:  "https://example.com/sink",
+					},
+				},
+			},
+		},
+	}
+
+	assert.NoError(t, ValidateInhooksConfig(appConf, c))
+	// ttl defaults to the env var
+	assert.Equal(t, appConf.Ingest.DedupeTTL, *dedupe.TTL)
+
+	dedupe.Header = "X-GitHub-Delivery"
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "dedupe requires exactly one of header or jsonPath")
+
+	dedupe.Header = ""
+	dedupe.JSONPath = "$.a[x]"
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "invalid dedupe json path")
+}
+
+func TestValidateInhooksConfig_Sync(t *testing.T) {
+	ctx := context.Background()
+	appConf, err := testsupport.InitAppConfig(ctx)
+	assert.NoError(t, err)
+
+	sync := &SyncDelivery{
+		SinkID: "sink-1",
+	}
+
+	c := &InhooksConfig{
+		Flows: []*Flow{
+			{
+				ID: "flow-1",
+				Source: &Source{
+					ID:   "source-1",
+					Slug: "source-1-slug",
+					Type: "http",
+					Sync: sync,
+				},
+				Sinks: []*Sink{
+					{
+						ID:   "sink-1",
+						Type: "http",
+						URL:  "https://example.com/sink",
+					},
+				},
+			},
+		},
+	}
+
+	assert.NoError(t, ValidateInhooksConfig(appConf, c))
+	// timeout defaults to the env var
+	assert.Equal(t, appConf.Ingest.SyncTimeout, *sync.Timeout)
+
+	timeout := time.Duration(0)
+	sync.Timeout = &timeout
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "sync timeout must be positive")
+
+	sync.SinkID = "sink-2"
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "sync sink id not found: sink-2")
+}
+
+func TestValidateInhooksConfig_Schema(t *testing.T) {
+	ctx := context.Background()
+	appConf, err := testsupport.InitAppConfig(ctx)
+	assert.NoError(t, err)
+
+	schema := &PayloadSchema{
+		Inline: map[string]interface{}{
+			"type":     "object",
+			"required": []interface{}{"id"},
+		},
+	}
+
+	c := &InhooksConfig{
+		Flows: []*Flow{
+			{
+				ID: "flow-1",
+				Source: &Source{
+					ID:     "source-1",
+					Slug:   "source-1-slug",
+					Type:   "http",
+					Schema: schema,
+				},
+				Sinks: []*Sink{
+					{
+						ID:   "sink-1",
+						Type: "http",
+						URL:  "https://example.com/sink",
+					},
+				},
+			},
+		},
+	}
+
+	assert.NoError(t, ValidateInhooksConfig(appConf, c))
+	// mode defaults to reject
+	assert.Equal(t, SchemaModeReject, schema.Mode)
+	assert.NoError(t, schema.Validate(map[string]interface{}{"id": "abc"}))
+	assert.Error(t, schema.Validate(map[string]interface{}{}))
+
+	schema.Mode = "ignore"
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "invalid schema mode: ignore")
+
+	schema.Mode = SchemaModeWarn
+	schema.Inline = map[string]interface{}{"type": "unknown"}
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "invalid schema")
+
+	schemaFile := filepath.Join(t.TempDir(), "schema.json")
+	err = os.WriteFile(schemaFile, []byte(`{"type": "array"}`), 0600)
+	assert.NoError(t, err)
+
+	schema.File = schemaFile
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "schema requires exactly one of inline or file")
+
+	schema.Inline = nil
+	assert.NoError(t, ValidateInhooksConfig(appConf, c))
+	assert.NoError(t, schema.Validate([]interface{}{}))
+
+	schema.File = filepath.Join(t.TempDir(), "missing.json")
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "failed to read schema file")
+}
+
+func TestValidateInhooksConfig_Challenge(t *testing.T) {
+	ctx := context.Background()
+	appConf, err := testsupport.InitAppConfig(ctx)
+	assert.NoError(t, err)
+
+	challenge := &Challenge{
+		ChallengeType: "other",
+	}
+
+	c := &InhooksConfig{
+		Flows: []*Flow{
+			{
+				ID: "flow-1",
+				Source: &Source{
+					ID:        "source-1",
+					Slug:      "source-1-slug",
+					Type:      "http",
+					Challenge: challenge,
+				},
+				Sinks: []*Sink{
+					{
+						ID:   "sink-1",
+						Type: "http",
+						URL:  "https://example.com/sink",
+					},
+				},
+			},
+		},
+	}
+
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "invalid challenge type: other. allowed: [slack meta]")
+
+	challenge.ChallengeType = ChallengeTypeMeta
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "challenge verify token env var required")
+
+	challenge.VerifyTokenEnvVar = "META_VERIFY_TOKEN"
+	assert.NoError(t, ValidateInhooksConfig(appConf, c))
+}
+
+func TestValidateInhooksConfig_Methods(t *testing.T) {
+	ctx := context.Background()
+	appConf, err := testsupport.InitAppConfig(ctx)
+	assert.NoError(t, err)
+
+	source := &Source{
+		ID:             "source-1",
+		Slug:           "source-1-slug",
+		Type:           "http",
+		AllowedMethods: []string{"PUT", "CONNECT"},
+	}
+	sink := &Sink{
+		ID:     "sink-1",
+		Type:   "http",
+		URL:    "https://example.com/sink",
+		Method: "preserve",
+	}
+
+	c := &InhooksConfig{
+		Flows: []*Flow{
+			{
+				ID:     "flow-1",
+				Source: source,
+				Sinks:  []*Sink{sink},
+			},
+		},
+	}
+
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "invalid source allowed method: CONNECT. allowed: [GET POST PUT PATCH DELETE]")
+
+	source.AllowedMethods = []string{"PUT", "POST"}
+	assert.NoError(t, ValidateInhooksConfig(appConf, c))
+
+	sink.Method = "keep"
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "invalid sink method: keep. allowed: [GET POST PUT PATCH DELETE] or preserve")
+}
+
+func TestValidateInhooksConfig_InvalidSinkPathMode(t *testing.T) {
+	ctx := context.Background()
+	appConf, err := testsupport.InitAppConfig(ctx)
+	assert.NoError(t, err)
+
+	c := &InhooksConfig{
+		Flows: []*Flow{
+			{
+				ID: "flow-1",
+				Source: &Source{
+					ID:   "source-1",
+					Slug: "source-1-slug",
+					Type: "http",
+				},
+				Sinks: []*Sink{
+					{
+						ID:       "sink-1",
+						Type:     "http",
+						URL:      "https://example.com/sink",
+						PathMode: "prepend",
+					},
+				},
+			},
+		},
+	}
+
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "invalid sink path mode: prepend. allowed: [ignore append]")
+}
+
+func TestValidateInhooksConfig_Filter(t *testing.T) {
+	ctx := context.Background()
+	appConf, err := testsupport.InitAppConfig(ctx)
+	assert.NoError(t, err)
+
+	push := "push"
+	deleted := "true"
+	filter := &Filter{
+		And: []*Filter{
+			{Header: "X-GitHub-Event", Equals: &push},
+			{Not: &Filter{JSONPath: "$.deleted", Equals: &deleted}},
+		},
+	}
+
+	c := &InhooksConfig{
+		Flows: []*Flow{
+			{
+				ID: "flow-1",
+				Source: &Source{
+					ID:   "source-1",
+					Slug: "source-1-slug",
+					Type: "http",
+				},
+				Sinks: []*Sink{
+					{
+						ID:     "sink-1",
+						Type:   "http",
+						URL:    "https://example.com/sink",
+						Filter: filter,
+					},
+				},
+			},
+		},
+	}
+
+	assert.NoError(t, ValidateInhooksConfig(appConf, c))
+	assert.True(t, filter.Match(http.Header{"X-Github-Event": []string{"push"}}, map[string]interface{}{"deleted": false}))
+
+	filter.And[0].Regex = "^push$"
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "invalid sink filter: filter cannot have both equals and regex")
+}
+
+func TestValidateInhooksConfig_Split(t *testing.T) {
+	ctx := context.Background()
+	appConf, err := testsupport.InitAppConfig(ctx)
+	assert.NoError(t, err)
+
+	source := &Source{
+		ID:    "source-1",
+		Slug:  "source-1-slug",
+		Type:  "http",
+		Split: &PayloadSplit{JSONPath: "$.events"},
+	}
+
+	c := &InhooksConfig{
+		Flows: []*Flow{
+			{
+				ID:     "flow-1",
+				Source: source,
+				Sinks: []*Sink{
+					{
+						ID:   "sink-1",
+						Type: "http",
+						URL:  "https://example.com/sink",
+					},
+				},
+			},
+		},
+	}
+
+	assert.NoError(t, ValidateInhooksConfig(appConf, c))
+
+	source.Sync = &SyncDelivery{SinkID: "sink-1"}
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "split cannot be used with sync delivery")
+
+	source.Sync = nil
+	source.Split.JSONPath = "$.events["
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "invalid split json path")
+}
+
+func TestValidateInhooksConfig_Capture(t *testing.T) {
+	ctx := context.Background()
+	appConf, err := testsupport.InitAppConfig(ctx)
+	assert.NoError(t, err)
+
+	source := &Source{
+		ID:   "source-1",
+		Slug: "source-1-slug",
+		Type: "http",
+	}
+
+	c := &InhooksConfig{
+		Flows: []*Flow{
+			{
+				ID:     "flow-1",
+				Source: source,
+			},
+		},
+	}
+
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "flow sinks cannot be empty unless the source captures requests")
+
+	// capture sources can have no sinks
+	source.Capture = true
+	assert.NoError(t, ValidateInhooksConfig(appConf, c))
+}
+
+func TestValidateInhooksConfig_IngestLog(t *testing.T) {
+	ctx := context.Background()
+	appConf, err := testsupport.InitAppConfig(ctx)
+	assert.NoError(t, err)
+
+	source := &Source{
+		ID:        "source-1",
+		Slug:      "source-1-slug",
+		Type:      "http",
+		IngestLog: &IngestLog{},
+	}
+
+	c := &InhooksConfig{
+		Flows: []*Flow{
+			{
+				ID:     "flow-1",
+				Source: source,
+				Sinks: []*Sink{
+					{
+						ID:   "sink-1",
+						Type: "http",
+						URL:  "https://example.com/sink",
+					},
+				},
+			},
+		},
+	}
+
+	// the retention defaults to the app config
+	assert.NoError(t, ValidateInhooksConfig(appConf, c))
+	assert.Equal(t, appConf.Ingest.LogRetention, *source.IngestLog.Retention)
+
+	retention := time.Duration(0)
+	source.IngestLog.Retention = &retention
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "ingest log retention must be positive")
+}
+
+func TestValidateInhooksConfig_RedisStream(t *testing.T) {
+	ctx := context.Background()
+	appConf, err := testsupport.InitAppConfig(ctx)
+	assert.NoError(t, err)
+
+	source := &Source{
+		ID:   "source-1",
+		Slug: "source-1-slug",
+		Type: "redis-stream",
+	}
+
+	c := &InhooksConfig{
+		Flows: []*Flow{
+			{
+				ID:     "flow-1",
+				Source: source,
+				Sinks: []*Sink{
+					{
+						ID:   "sink-1",
+						Type: "http",
+						URL:  "https://example.com/sink",
+					},
+				},
+			},
+		},
+	}
+
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "redis-stream sources require a stream key")
+
+	// the group and payload field have defaults
+	source.Stream = &RedisStream{Key: "events"}
+	assert.NoError(t, ValidateInhooksConfig(appConf, c))
+	assert.Equal(t, "inhooks", source.Stream.Group)
+	assert.Equal(t, "payload", source.Stream.PayloadField)
+
+	source.Capture = true
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "redis-stream sources cannot capture requests")
+
+	source.Capture = false
+	source.Type = "http"
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "stream can only be used with redis-stream sources")
+}
+
+func TestValidateInhooksConfig_Cron(t *testing.T) {
+	ctx := context.Background()
+	appConf, err := testsupport.InitAppConfig(ctx)
+	assert.NoError(t, err)
+
+	source := &Source{
+		ID:   "source-1",
+		Slug: "source-1-slug",
+		Type: "cron",
+	}
+
+	c := &InhooksConfig{
+		Flows: []*Flow{
+			{
+				ID:     "flow-1",
+				Source: source,
+				Sinks: []*Sink{
+					{
+						ID:   "sink-1",
+						Type: "http",
+						URL:  "https://example.com/sink",
+					},
+				},
+			},
+		},
+	}
+
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "cron sources require a cron schedule")
+
+	source.Cron = &Cron{Schedule: "0 2 * *"}
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "invalid cron: invalid cron expression 0 2 * *: expected 5 fields")
+
+	source.Cron = &Cron{Schedule: "0 2 * * *", Timezone: "Mars/Olympus"}
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "invalid cron: invalid time zone Mars/Olympus")
+
+	source.Cron = &Cron{Schedule: "0 2 * * *", Payload: `{"date": "{{ .Time.Format }"}`}
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "invalid cron: invalid payload template")
+
+	source.Cron = &Cron{Schedule: "0 2 * * *", Payload: `{"task": "sync"}`}
+	assert.NoError(t, ValidateInhooksConfig(appConf, c))
+
+	source.Capture = true
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "cron sources cannot capture requests")
+
+	source.Capture = false
+	source.Type = "http"
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "cron can only be used with cron sources")
+}
+
+func TestValidateInhooksConfig_Poll(t *testing.T) {
+	ctx := context.Background()
+	appConf, err := testsupport.InitAppConfig(ctx)
+	assert.NoError(t, err)
+
+	source := &Source{
+		ID:   "source-1",
+		Slug: "source-1-slug",
+		Type: "poll",
+	}
+
+	c := &InhooksConfig{
+		Flows: []*Flow{
+			{
+				ID:     "flow-1",
+				Source: source,
+				Sinks: []*Sink{
+					{
+						ID:   "sink-1",
+						Type: "http",
+						URL:  "https://example.com/sink",
+					},
+				},
+			},
+		},
+	}
+
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "poll sources require a poll config")
+
+	source.Poll = &Poll{URL: "example.com/events"}
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "invalid poll url: example.com/events")
+
+	source.Poll = &Poll{URL: "https://example.com/events"}
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "poll interval must be positive")
+
+	source.Poll = &Poll{URL: "https://example.com/events", Interval: time.Minute, ItemsJSONPath: "$.data", ItemKeyJSONPath: "$.id", Cursor: &PollCursor{}}
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "poll cursor query param required")
+
+	source.Poll = &Poll{URL: "https://example.com/events", Interval: time.Minute, ItemsJSONPath: "$.data[0", ItemKeyJSONPath: "$.id"}
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "invalid poll: invalid items json path")
+
+	source.Poll = &Poll{URL: "https://example.com/events", Interval: time.Minute, ItemsJSONPath: "$.data"}
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "invalid poll: invalid item key json path")
+
+	source.Poll = &Poll{URL: "https://example.com/events", Interval: time.Minute, ItemsJSONPath: "$.data", ItemKeyJSONPath: "$.id", HeaderEnvVars: map[string]string{"Authorization": "POLL_TEST_AUTHORIZATION"}}
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "env var POLL_TEST_AUTHORIZATION of poll header Authorization not set")
+
+	t.Setenv("POLL_TEST_AUTHORIZATION", "Bearer secret")
+	assert.NoError(t, ValidateInhooksConfig(appConf, c))
+
+	// the dedupe ttl defaults to the app config
+	source.Poll = &Poll{URL: "https://example.com/events", Interval: time.Minute, ItemsJSONPath: "$.data", ItemKeyJSONPath: "$.id", Cursor: &PollCursor{QueryParam: "after"}}
+	assert.NoError(t, ValidateInhooksConfig(appConf, c))
+	assert.Equal(t, appConf.Ingest.DedupeTTL, *source.Poll.DedupeTTL)
+
+	source.Type = "http"
+	assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), "poll can only be used with poll sources")
+}
+
+func TestValidateInhooksConfig_NonHttpSourceOptions(t *testing.T) {
+	ctx := context.Background()
+	appConf, err := testsupport.InitAppConfig(ctx)
+	assert.NoError(t, err)
+
+	maxQueueDepth := 100
+	maxBodyBytes := int64(1024)
+
+	testCases := []struct {
+		name   string
+		setOpt func(source *Source)
+		errMsg string
+	}{
+		{"verification", func(source *Source) { source.Verification = &Verification{} }, "redis-stream sources cannot use verification"},
+		{"allowedCIDRs", func(source *Source) { source.AllowedCIDRs = []string{"10.0.0.0/8"} }, "redis-stream sources cannot use allowedCIDRs"},
+		{"allowedCIDRsFile", func(source *Source) { source.AllowedCIDRsFile = "/etc/inhooks/cidrs.txt" }, "redis-stream sources cannot use allowedCIDRsFile"},
+		{"rateLimit", func(source *Source) { source.RateLimit = &RateLimit{} }, "redis-stream sources cannot use rateLimit"},
+		{"maxQueueDepth", func(source *Source) { source.MaxQueueDepth = &maxQueueDepth }, "redis-stream sources cannot use maxQueueDepth"},
+		{"challenge", func(source *Source) { source.Challenge = &Challenge{} }, "redis-stream sources cannot use challenge"},
+		{"allowedMethods", func(source *Source) { source.AllowedMethods = []string{http.MethodPut} }, "redis-stream sources cannot use allowedMethods"},
+		{"maxBodyBytes", func(source *Source) { source.MaxBodyBytes = &maxBodyBytes }, "redis-stream sources cannot use maxBodyBytes"},
+		{"allowedContentTypes", func(source *Source) { source.AllowedContentTypes = []string{"application/json"} }, "redis-stream sources cannot use allowedContentTypes"},
+		{"dedupe", func(source *Source) { source.Dedupe = &Dedupe{} }, "redis-stream sources cannot use dedupe"},
+		{"schema", func(source *Source) { source.Schema = &PayloadSchema{} }, "redis-stream sources cannot use schema"},
+		{"successResponse", func(source *Source) { source.SuccessResponse = &IngestResponse{} }, "redis-stream sources cannot use successResponse"},
+		{"failureResponse", func(source *Source) { source.FailureResponse = &IngestResponse{} }, "redis-stream sources cannot use failureResponse"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			source := &Source{
+				ID:     "source-1",
+				Slug:   "source-1-slug",
+				Type:   "redis-stream",
+				Stream: &RedisStream{Key: "events"},
+			}
+
+			c := &InhooksConfig{
+				Flows: []*Flow{
+					{
+						ID:     "flow-1",
+						Source: source,
+						Sinks: []*Sink{
+							{
+								ID:   "sink-1",
+								Type: "http",
+								URL:  "https://example.com/sink",
+							},
+						},
+					},
+				},
+			}
+
+			assert.NoError(t, ValidateInhooksConfig(appConf, c))
+
+			tc.setOpt(source)
+			assert.ErrorContains(t, ValidateInhooksConfig(appConf, c), tc.errMsg)
+		})
+	}
+}
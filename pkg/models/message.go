@@ -10,15 +10,37 @@ type Message struct {
 	FlowID   string `json:"flowID"`
 	SourceID string `json:"sourceID"`
 	// Ingested Request ID
-	IngestedReqID string      `json:"ingestedReqID"`
-	SinkID        string      `json:"sinkID"`
-	HttpHeaders   http.Header `json:"httpHeaders"`
-	RawQuery      string      `json:"rawQuery"`
-	Payload       []byte      `json:"payload"`
+	IngestedReqID string `json:"ingestedReqID"`
+	// ID of the stored ingested request containing the request data. Empty if the request data is stored in the message.
+	IngestID string `json:"ingestID,omitempty"`
+	// Index of the payload element when the request payload is split, shared by the messages of the element
+	SplitIndex  *int        `json:"splitIndex,omitempty"`
+	SinkID      string      `json:"sinkID"`
+	HttpMethod  string      `json:"httpMethod"`
+	HttpHeaders http.Header `json:"httpHeaders"`
+	RawQuery    string      `json:"rawQuery"`
+	// Escaped path following the source slug in the ingest url
+	PathSuffix string `json:"pathSuffix"`
+	// Time at which the request was received
+	ReceivedAt time.Time `json:"receivedAt"`
+	// Escaped path of the ingest url
+	HttpPath string `json:"httpPath"`
+	// IP address of the client, resolved through the trusted proxies
+	ClientIP  string `json:"clientIP"`
+	UserAgent string `json:"userAgent"`
+	// TLS version of the connection, empty if the request was not received over TLS
+	TLSVersion string `json:"tlsVersion,omitempty"`
+	Payload    []byte `json:"payload"`
+	// Key of the payload in the payload store when it is too large to be stored in redis
+	PayloadRef string `json:"payloadRef,omitempty"`
+	// Bytes covered by the request signature when they differ from the payload, e.g. compressed bodies. Not stored.
+	SignedPayload []byte `json:"-"`
+	// Enqueued in the processing queue as it is delivered inline, e.g. to a sync sink. Not stored.
+	DeliverInline bool `json:"-"`
 
 	// Processing Info
 	DeliveryAttempts []*DeliveryAttempt `json:"deliveryAttempts"`
-	DeliverAfter     time.Time
+	DeliverAfter     time.Time          `json:"deliverAfter"`
 }
 
 type DeliveryAttempt struct {
@@ -35,3 +57,56 @@ const (
 )
 
 type MessageStatus string
+
+// IngestedRequest returns the request data of the message
+func (m *Message) IngestedRequest() *IngestedRequest {
+	return &IngestedRequest{
+		IngestedReqID: m.IngestedReqID,
+		HttpMethod:    m.HttpMethod,
+		HttpHeaders:   m.HttpHeaders,
+		RawQuery:      m.RawQuery,
+		PathSuffix:    m.PathSuffix,
+		ReceivedAt:    m.ReceivedAt,
+		HttpPath:      m.HttpPath,
+		ClientIP:      m.ClientIP,
+		UserAgent:     m.UserAgent,
+		TLSVersion:    m.TLSVersion,
+		Payload:       m.Payload,
+	}
+}
+
+// SetIngestedRequest sets the request data of the message
+func (m *Message) SetIngestedRequest(r *IngestedRequest) {
+	m.HttpMethod = r.HttpMethod
+	m.HttpHeaders = r.HttpHeaders
+	m.RawQuery = r.RawQuery
+	m.PathSuffix = r.PathSuffix
+	m.ReceivedAt = r.ReceivedAt
+	m.HttpPath = r.HttpPath
+	m.ClientIP = r.ClientIP
+	m.UserAgent = r.UserAgent
+	m.TLSVersion = r.TLSVersion
+	m.Payload = r.Payload
+}
+
+// RequestMetadata returns the metadata of the ingested request
+func (m *Message) RequestMetadata() *RequestMetadata {
+	return &RequestMetadata{
+		ReceivedAt: m.ReceivedAt,
+		Method:     m.HttpMethod,
+		Path:       m.HttpPath,
+		ClientIP:   m.ClientIP,
+		UserAgent:  m.UserAgent,
+		TLSVersion: m.TLSVersion,
+	}
+}
+
+// SetRequestMetadata sets the metadata of the ingested request
+func (m *Message) SetRequestMetadata(md *RequestMetadata) {
+	m.ReceivedAt = md.ReceivedAt
+	m.HttpMethod = md.Method
+	m.HttpPath = md.Path
+	m.ClientIP = md.ClientIP
+	m.UserAgent = md.UserAgent
+	m.TLSVersion = md.TLSVersion
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessage_DeliverAfterJSON(t *testing.T) {
+	deliverAfter := time.Date(2023, 05, 5, 8, 9, 12, 0, time.UTC)
+
+	b, err := json.Marshal(&Message{DeliverAfter: deliverAfter})
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), `"deliverAfter":"2023-05-05T08:09:12Z"`)
+
+	// messages stored before the field had a json tag
+	m := &Message{}
+	err = json.Unmarshal([]byte(`{"id":"abc","DeliverAfter":"2023-05-05T08:09:12Z"}`), m)
+	assert.NoError(t, err)
+	assert.Equal(t, deliverAfter, m.DeliverAfter)
+}
+
+func TestRequestMetadata_Headers(t *testing.T) {
+	m := &Message{
+		HttpMethod: "POST",
+		ReceivedAt: time.Date(2023, 05, 5, 8, 9, 12, 500, time.FixedZone("CET", 3600)),
+		HttpPath:   "/api/v1/ingest/github",
+		ClientIP:   "140.82.115.10",
+		UserAgent:  "GitHub-Hookshot/abc",
+	}
+
+	headers := m.RequestMetadata().Headers()
+	assert.Equal(t, "2023-05-05T07:09:12.0000005Z", headers.Get("X-Inhooks-Received-At"))
+	assert.Equal(t, "POST", headers.Get("X-Inhooks-Method"))
+	assert.Equal(t, "/api/v1/ingest/github", headers.Get("X-Inhooks-Path"))
+	assert.Equal(t, "140.82.115.10", headers.Get("X-Inhooks-Client-IP"))
+	assert.Equal(t, "GitHub-Hookshot/abc", headers.Get("X-Inhooks-User-Agent"))
+	assert.NotContains(t, headers, "X-Inhooks-Tls-Version")
+
+	assert.Empty(t, (&Message{}).RequestMetadata().Headers())
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+type SchemaMode string
+
+const (
+	// requests with payloads not matching the schema are rejected
+	SchemaModeReject SchemaMode = "reject"
+	// schema violations are only logged and counted
+	SchemaModeWarn SchemaMode = "warn"
+)
+
+var SchemaModes = []SchemaMode{
+	SchemaModeReject,
+	SchemaModeWarn,
+}
+
+// JSON Schema the request payloads are validated against
+type PayloadSchema struct {
+	// Inline JSON Schema, written in YAML or JSON
+	Inline interface{} `yaml:"inline"`
+	// Path to a JSON Schema file
+	File string `yaml:"file"`
+	// Handling of payloads not matching the schema. Defaults to reject.
+	Mode SchemaMode `yaml:"mode"`
+
+	compiled *jsonschema.Schema
+}
+
+// Compile loads and compiles the schema
+func (s *PayloadSchema) Compile() error {
+	var schemaBytes []byte
+	var err error
+	if s.File != "" {
+		schemaBytes, err = os.ReadFile(s.File)
+		if err != nil {
+			return fmt.Errorf("failed to read schema file: %w", err)
+		}
+	} else {
+		schemaBytes, err = json.Marshal(s.Inline)
+		if err != nil {
+			return fmt.Errorf("failed to encode inline schema: %w", err)
+		}
+	}
+
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(schemaBytes))
+	if err != nil {
+		return fmt.Errorf("failed to decode schema: %w", err)
+	}
+
+	c := jsonschema.NewCompiler()
+	err = c.AddResource("schema.json", doc)
+	if err != nil {
+		return err
+	}
+
+	s.compiled, err = c.Compile("schema.json")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Validate validates a JSON document decoded with json.Number numbers against the compiled schema
+func (s *PayloadSchema) Validate(doc interface{}) error {
+	if s.compiled == nil {
+		return fmt.Errorf("schema not compiled")
+	}
+
+	return s.compiled.Validate(doc)
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/didil/inhooks/pkg/lib"
+)
+
+// Split of batched JSON array payloads into one message per element for each sink
+type PayloadSplit struct {
+	// JSON path of the array to split, e.g. $ for a top level array or $.events
+	JSONPath string `yaml:"jsonPath"`
+
+	jsonPath *lib.JSONPath
+}
+
+// Compile parses the split JSON path
+func (s *PayloadSplit) Compile() error {
+	jsonPath, err := lib.ParseJSONPath(s.JSONPath)
+	if err != nil {
+		return err
+	}
+	s.jsonPath = jsonPath
+
+	return nil
+}
+
+// Elements returns the elements of the array found at the split JSON path, as they are encoded in the payload
+func (s *PayloadSplit) Elements(payload []byte) ([][]byte, error) {
+	if s.jsonPath == nil {
+		return nil, fmt.Errorf("split json path not compiled")
+	}
+
+	value, ok, err := s.jsonPath.LookupRaw(payload)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("no value found at %s", s.JSONPath)
+	}
+
+	var elements []json.RawMessage
+	err = json.Unmarshal(value, &elements)
+	if err != nil || elements == nil {
+		return nil, fmt.Errorf("value at %s is not an array", s.JSONPath)
+	}
+
+	result := make([][]byte, 0, len(elements))
+	for _, element := range elements {
+		result = append(result, element)
+	}
+
+	return result, nil
+}
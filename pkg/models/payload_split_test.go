@@ -0,0 +1,39 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPayloadSplit_Elements(t *testing.T) {
+	split := &PayloadSplit{JSONPath: "$"}
+	assert.NoError(t, split.Compile())
+
+	elements, err := split.Elements([]byte(`[{"event": "open", "id": 1}, {"event":"click"}, 3]`))
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte(`{"event": "open", "id": 1}`), []byte(`{"event":"click"}`), []byte(`3`)}, elements)
+
+	elements, err = split.Elements([]byte(`[]`))
+	assert.NoError(t, err)
+	assert.Empty(t, elements)
+
+	_, err = split.Elements([]byte(`{"event": "open"}`))
+	assert.EqualError(t, err, "value at $ is not an array")
+
+	_, err = split.Elements([]byte(`not json`))
+	assert.EqualError(t, err, "invalid json")
+
+	split = &PayloadSplit{JSONPath: "$.batch.events"}
+	assert.NoError(t, split.Compile())
+
+	elements, err = split.Elements([]byte(`{"batch": {"events": [{"id": "a"}, {"id": "b"}]}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte(`{"id": "a"}`), []byte(`{"id": "b"}`)}, elements)
+
+	_, err = split.Elements([]byte(`{"batch": {}}`))
+	assert.EqualError(t, err, "no value found at $.batch.events")
+
+	_, err = split.Elements([]byte(`{"batch": {"events": null}}`))
+	assert.EqualError(t, err, "value at $.batch.events is not an array")
+}
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/didil/inhooks/pkg/lib"
+)
+
+// HTTP API listed periodically by poll sources, each new item is sent to the flow sinks
+type Poll struct {
+	// URL listing the items, called with GET
+	URL string `yaml:"url"`
+	// Interval between calls
+	Interval time.Duration `yaml:"interval"`
+	// HTTP headers of the calls
+	Headers map[string]string `yaml:"headers"`
+	// HTTP headers of the calls read from env vars, by header name, e.g. Authorization: VENDOR_API_AUTHORIZATION
+	HeaderEnvVars map[string]string `yaml:"headerEnvVars"`
+	// JSON path of the items array in the response, e.g. $.data
+	ItemsJSONPath string `yaml:"itemsJsonPath"`
+	// JSON path of the key of an item, e.g. $.id. Items with a key that was already seen are dropped.
+	ItemKeyJSONPath string `yaml:"itemKeyJsonPath"`
+	// Duration during which the item keys are remembered. Defaults to the INGEST_DEDUPE_TTL env var.
+	DedupeTTL *time.Duration `yaml:"dedupeTTL"`
+	// Cursor sent with each call to list the items after the previous call
+	Cursor *PollCursor `yaml:"cursor"`
+
+	items   *PayloadSplit
+	itemKey *lib.JSONPath
+	cursor  *lib.JSONPath
+}
+
+// Cursor of a poll source, stored in redis between calls
+type PollCursor struct {
+	// Query parameter the cursor is sent in, e.g. starting_after
+	QueryParam string `yaml:"queryParam"`
+	// JSON path of the next cursor in the response, e.g. $.next_cursor. Defaults to the key of the last item of the response, for APIs listing items after a last seen id.
+	JSONPath string `yaml:"jsonPath"`
+}
+
+// Compile parses the poll JSON paths
+func (p *Poll) Compile() error {
+	p.items = &PayloadSplit{JSONPath: p.ItemsJSONPath}
+	err := p.items.Compile()
+	if err != nil {
+		return fmt.Errorf("invalid items json path: %w", err)
+	}
+
+	p.itemKey, err = lib.ParseJSONPath(p.ItemKeyJSONPath)
+	if err != nil {
+		return fmt.Errorf("invalid item key json path: %w", err)
+	}
+
+	p.cursor = nil
+	if p.Cursor != nil && p.Cursor.JSONPath != "" {
+		p.cursor, err = lib.ParseJSONPath(p.Cursor.JSONPath)
+		if err != nil {
+			return fmt.Errorf("invalid cursor json path: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Items returns the items of a response, as they are encoded in the response
+func (p *Poll) Items(body []byte) ([][]byte, error) {
+	if p.items == nil {
+		return nil, fmt.Errorf("poll json paths not compiled")
+	}
+
+	return p.items.Elements(body)
+}
+
+// ItemKey returns the key of an item
+func (p *Poll) ItemKey(item []byte) (string, error) {
+	if p.itemKey == nil {
+		return "", fmt.Errorf("poll json paths not compiled")
+	}
+
+	value, ok, err := p.itemKey.LookupRaw(item)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("no item key found at %s", p.ItemKeyJSONPath)
+	}
+
+	return jsonScalarString(value, p.ItemKeyJSONPath)
+}
+
+// NextCursor returns the cursor to send with the next call, or false if the response does not move the cursor
+func (p *Poll) NextCursor(body []byte, lastItemKey string) (string, bool, error) {
+	if p.Cursor == nil {
+		return "", false, nil
+	}
+
+	if p.cursor == nil {
+		return lastItemKey, lastItemKey != "", nil
+	}
+
+	value, ok, err := p.cursor.LookupRaw(body)
+	if err != nil || !ok || string(value) == "null" {
+		return "", false, err
+	}
+
+	cursor, err := jsonScalarString(value, p.Cursor.JSONPath)
+	if err != nil {
+		return "", false, err
+	}
+
+	return cursor, cursor != "", nil
+}
+
+// jsonScalarString returns the string or number at a JSON path as a string
+func jsonScalarString(value json.RawMessage, path string) (string, error) {
+	v, err := lib.DecodeJSON(value)
+	if err != nil {
+		return "", err
+	}
+
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	default:
+		return "", fmt.Errorf("value at %s is not a string or a number", path)
+	}
+}
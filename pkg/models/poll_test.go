@@ -0,0 +1,55 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoll(t *testing.T) {
+	p := &Poll{
+		ItemsJSONPath:   "$.data",
+		ItemKeyJSONPath: "$.id",
+		Cursor:          &PollCursor{QueryParam: "after", JSONPath: "$.meta.next"},
+	}
+	assert.NoError(t, p.Compile())
+
+	body := []byte(`{"data": [{"id": "evt_1"}, {"id": 12345678901234567890}], "meta": {"next": "c1"}}`)
+
+	items, err := p.Items(body)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte(`{"id": "evt_1"}`), []byte(`{"id": 12345678901234567890}`)}, items)
+
+	key, err := p.ItemKey(items[0])
+	assert.NoError(t, err)
+	assert.Equal(t, "evt_1", key)
+
+	// large numeric ids are kept as is
+	key, err = p.ItemKey(items[1])
+	assert.NoError(t, err)
+	assert.Equal(t, "12345678901234567890", key)
+
+	_, err = p.ItemKey([]byte(`{"id": {"value": 1}}`))
+	assert.ErrorContains(t, err, "value at $.id is not a string or a number")
+
+	_, err = p.ItemKey([]byte(`{"name": "a"}`))
+	assert.ErrorContains(t, err, "no item key found at $.id")
+
+	cursor, ok, err := p.NextCursor(body, "12345678901234567890")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "c1", cursor)
+
+	// the cursor does not move at the end of the list
+	_, ok, err = p.NextCursor([]byte(`{"data": [], "meta": {"next": null}}`), "")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// last seen id
+	p.Cursor.JSONPath = ""
+	assert.NoError(t, p.Compile())
+	cursor, ok, err = p.NextCursor(body, "12345678901234567890")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "12345678901234567890", cursor)
+}
@@ -0,0 +1,22 @@
+package models
+
+// Redis stream read by redis-stream sources through a consumer group
+type RedisStream struct {
+	// Key of the stream the producers add entries to. The key is used as is, without the inhooks prefix.
+	Key string `yaml:"key"`
+	// Consumer group shared by the inhooks instances. Defaults to inhooks.
+	Group string `yaml:"group"`
+	// Entry field holding the message payload. Defaults to payload. The other fields are forwarded as http headers.
+	PayloadField string `yaml:"payloadField"`
+}
+
+const (
+	RedisStreamDefaultGroup        = "inhooks"
+	RedisStreamDefaultPayloadField = "payload"
+)
+
+// Entry read from a redis stream
+type StreamEntry struct {
+	ID     string
+	Values map[string]string
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"net/http"
+	"time"
+)
+
+// Metadata of the ingested request, exposed to transforms and optionally forwarded to the sinks
+type RequestMetadata struct {
+	ReceivedAt time.Time `json:"receivedAt"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	ClientIP   string    `json:"clientIP"`
+	UserAgent  string    `json:"userAgent"`
+	// Empty if the request was not received over TLS by inhooks
+	TLSVersion string `json:"tlsVersion"`
+}
+
+// Headers used to forward the request metadata to the sinks
+var RequestMetadataHeaders = []string{
+	"X-Inhooks-Received-At",
+	"X-Inhooks-Method",
+	"X-Inhooks-Path",
+	"X-Inhooks-Client-Ip",
+	"X-Inhooks-User-Agent",
+	"X-Inhooks-Tls-Version",
+}
+
+// Headers returns the metadata as X-Inhooks-* headers. Unknown values, e.g. for messages ingested before the metadata was recorded, are skipped.
+func (md *RequestMetadata) Headers() http.Header {
+	var receivedAt string
+	if !md.ReceivedAt.IsZero() {
+		receivedAt = md.ReceivedAt.UTC().Format(time.RFC3339Nano)
+	}
+
+	values := []string{receivedAt, md.Method, md.Path, md.ClientIP, md.UserAgent, md.TLSVersion}
+
+	headers := http.Header{}
+	for i, k := range RequestMetadataHeaders {
+		if values[i] != "" {
+			headers.Set(k, values[i])
+		}
+	}
+
+	return headers
+}
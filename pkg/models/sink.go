@@ -2,6 +2,23 @@ package models
 
 import "time"
 
+type SinkPathMode string
+
+const (
+	// the ingest url path suffix is not forwarded
+	SinkPathModeIgnore SinkPathMode = "ignore"
+	// the ingest url path suffix is appended to the sink url
+	SinkPathModeAppend SinkPathMode = "append"
+)
+
+var SinkPathModes = []SinkPathMode{
+	SinkPathModeIgnore,
+	SinkPathModeAppend,
+}
+
+// Sink http method mode that sends the messages with the http method of the ingested request
+const SinkMethodPreserve = "preserve"
+
 type SinkType string
 
 const (
@@ -19,6 +36,10 @@ type Sink struct {
 	Type SinkType `yaml:"type"`
 	// Sink Url for HTTP sinks
 	URL string `yaml:"url"`
+	// HTTP method for HTTP sinks. Defaults to POST. Set to "preserve" to use the method of the ingested request.
+	Method string `yaml:"method"`
+	// Forwarding of the ingest url path suffix for HTTP sinks. Defaults to ignore.
+	PathMode SinkPathMode `yaml:"pathMode"`
 	// Process after delay
 	Delay *time.Duration `yaml:"delay"`
 	// Retry every x time
@@ -29,4 +50,8 @@ type Sink struct {
 	MaxAttempts *int `yaml:"maxAttempts"`
 	// Transform to apply to the data
 	Transform *Transform `yaml:"transform"`
+	// Send the ingested request metadata to HTTP sinks as X-Inhooks-* headers
+	ForwardMetadata bool `yaml:"forwardMetadata"`
+	// Condition on the ingested requests. The sink only receives the requests matching the filter.
+	Filter *Filter `yaml:"filter"`
 }
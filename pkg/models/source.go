@@ -1,23 +1,44 @@
 package models
 
+import (
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
 type SourceType string
 
 const (
-	SourceTypeHttp = "http"
+	SourceTypeHttp        = "http"
+	SourceTypeRedisStream = "redis-stream"
+	SourceTypeCron        = "cron"
+	SourceTypePoll        = "poll"
 )
 
 var SourceTypes = []SourceType{
 	SourceTypeHttp,
+	SourceTypeRedisStream,
+	SourceTypeCron,
+	SourceTypePoll,
 }
 
 type VerificationType string
 
 const (
-	VerificationTypeHMAC VerificationType = "hmac"
+	VerificationTypeHMAC       VerificationType = "hmac"
+	VerificationTypeBasicAuth  VerificationType = "basic-auth"
+	VerificationTypeToken      VerificationType = "token"
+	VerificationTypeQueryToken VerificationType = "query-token"
 )
 
 var VerificationTypes = []VerificationType{
 	VerificationTypeHMAC,
+	VerificationTypeBasicAuth,
+	VerificationTypeToken,
+	VerificationTypeQueryToken,
 }
 
 type HMACAlgorithm string
@@ -30,9 +51,101 @@ var HMACAlgorithms = []HMACAlgorithm{
 	HMACAlgorithmSHA256,
 }
 
+// HTTP methods that can be accepted by sources
+var SourceHttpMethods = []string{
+	http.MethodGet,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
 type Source struct {
 	ID           string        `yaml:"id"`
 	Slug         string        `yaml:"slug"`
 	Type         SourceType    `yaml:"type"`
 	Verification *Verification `yaml:"verification"`
+	// Redis stream read by redis-stream sources
+	Stream *RedisStream `yaml:"stream"`
+	// Schedule of cron sources
+	Cron *Cron `yaml:"cron"`
+	// HTTP API listed by poll sources
+	Poll *Poll `yaml:"poll"`
+	// CIDR ranges allowed to send requests to the source
+	AllowedCIDRs []string `yaml:"allowedCIDRs"`
+	// Path to a file containing CIDR ranges allowed to send requests to the source, one per line. The file is reloaded periodically.
+	AllowedCIDRsFile string `yaml:"allowedCIDRsFile"`
+	// Rate limit applied to the source ingest requests, shared across inhooks instances
+	RateLimit *RateLimit `yaml:"rateLimit"`
+	// Ingest requests are rejected when the ready and scheduled queues of one of the flow sinks contain more messages than this threshold
+	MaxQueueDepth *int `yaml:"maxQueueDepth"`
+	// Endpoint verification handshake answered directly without enqueueing messages
+	Challenge *Challenge `yaml:"challenge"`
+	// HTTP methods accepted by the source. Defaults to POST.
+	AllowedMethods []string `yaml:"allowedMethods"`
+	// Max request body size in bytes. Defaults to the INGEST_MAX_BODY_BYTES env var.
+	MaxBodyBytes *int64 `yaml:"maxBodyBytes"`
+	// Content types accepted by the source, without parameters (e.g. application/json). Defaults to all content types.
+	AllowedContentTypes []string `yaml:"allowedContentTypes"`
+	// Decode compressed request bodies before verification and storage
+	Decompression *Decompression `yaml:"decompression"`
+	// Drop requests with an idempotency key that was already ingested
+	Dedupe *Dedupe `yaml:"dedupe"`
+	// Split JSON array payloads into one message per element
+	Split *PayloadSplit `yaml:"split"`
+	// JSON Schema the request payloads are validated against after verification
+	Schema *PayloadSchema `yaml:"schema"`
+	// Deliver the messages of one sink inline and relay its response to the sender
+	Sync *SyncDelivery `yaml:"sync"`
+	// Keep the ingested requests to backfill sinks
+	IngestLog *IngestLog `yaml:"ingestLog"`
+	// Record the ingested requests for inspection. Capture sources can have no sinks.
+	Capture bool `yaml:"capture"`
+	// Response sent when the request is ingested successfully. Defaults to an empty JSON object with status 200.
+	SuccessResponse *IngestResponse `yaml:"successResponse"`
+	// Response sent when the request fails. Defaults to a JSON error.
+	FailureResponse *IngestResponse `yaml:"failureResponse"`
+}
+
+// AcceptsHttpRequests returns true if the source ingests requests sent to the ingest endpoint
+func (s *Source) AcceptsHttpRequests() bool {
+	return s.Type != SourceTypeRedisStream && s.Type != SourceTypeCron && s.Type != SourceTypePoll
+}
+
+// IsMethodAllowed returns true if the source accepts requests with the http method
+func (s *Source) IsMethodAllowed(method string) bool {
+	if len(s.AllowedMethods) == 0 {
+		return method == http.MethodPost
+	}
+
+	return slices.Contains(s.AllowedMethods, method)
+}
+
+// IsContentTypeAllowed returns true if the source accepts requests with the Content-Type header value
+func (s *Source) IsContentTypeAllowed(contentType string) bool {
+	if len(s.AllowedContentTypes) == 0 {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return slices.ContainsFunc(s.AllowedContentTypes, func(allowed string) bool {
+		return strings.EqualFold(allowed, mediaType)
+	})
+}
+
+// Token bucket rate limit. Requests are allowed at a rate of Requests per Interval, with bursts of up to Burst requests.
+type RateLimit struct {
+	Requests int           `yaml:"requests"`
+	Interval time.Duration `yaml:"interval"`
+	// Bucket capacity. Defaults to Requests.
+	Burst *int `yaml:"burst"`
+}
+
+// HasIPAllowlist returns true if requests to the source are restricted to a list of CIDR ranges
+func (s *Source) HasIPAllowlist() bool {
+	return len(s.AllowedCIDRs) > 0 || s.AllowedCIDRsFile != ""
 }
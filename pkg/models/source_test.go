@@ -0,0 +1,35 @@
+package models
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSource_IsMethodAllowed(t *testing.T) {
+	source := &Source{}
+
+	assert.True(t, source.IsMethodAllowed(http.MethodPost))
+	assert.False(t, source.IsMethodAllowed(http.MethodPut))
+
+	source.AllowedMethods = []string{http.MethodPut, http.MethodGet}
+
+	assert.False(t, source.IsMethodAllowed(http.MethodPost))
+	assert.True(t, source.IsMethodAllowed(http.MethodPut))
+	assert.True(t, source.IsMethodAllowed(http.MethodGet))
+}
+
+func TestSource_IsContentTypeAllowed(t *testing.T) {
+	source := &Source{}
+
+	assert.True(t, source.IsContentTypeAllowed("text/plain"))
+	assert.True(t, source.IsContentTypeAllowed(""))
+
+	source.AllowedContentTypes = []string{"application/json"}
+
+	assert.True(t, source.IsContentTypeAllowed("application/json"))
+	assert.True(t, source.IsContentTypeAllowed("Application/JSON; charset=utf-8"))
+	assert.False(t, source.IsContentTypeAllowed("text/plain"))
+	assert.False(t, source.IsContentTypeAllowed(""))
+}
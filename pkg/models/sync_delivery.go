@@ -0,0 +1,21 @@
+package models
+
+import (
+	"net/http"
+	"time"
+)
+
+// Inline delivery of the source messages to one sink, relaying the sink response to the sender
+type SyncDelivery struct {
+	// ID of the sink the messages are delivered to inline
+	SinkID string `yaml:"sinkID"`
+	// Max duration of the inline delivery. Defaults to the INGEST_SYNC_TIMEOUT env var.
+	Timeout *time.Duration `yaml:"timeout"`
+}
+
+// Response returned by a sink
+type SinkResponse struct {
+	StatusCode int
+	Headers    http.Header
+	Body       []byte
+}
@@ -7,4 +7,8 @@ type Verification struct {
 	SignaturePrefix      string           `yaml:"signaturePrefix"`
 	CurrentSecretEnvVar  string           `yaml:"currentSecretEnvVar"`
 	PreviousSecretEnvVar string           `yaml:"previousSecretEnvVar"`
+	// Expected username for basic auth verification. The password is read from the secret env vars.
+	BasicAuthUsername string `yaml:"basicAuthUsername"`
+	// Name of the query string parameter containing the token for query token verification
+	TokenQueryParam string `yaml:"tokenQueryParam"`
 }
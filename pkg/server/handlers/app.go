@@ -2,19 +2,30 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
+	"github.com/didil/inhooks/pkg/lib"
 	"github.com/didil/inhooks/pkg/services"
 	"go.uber.org/zap"
 )
 
 type App struct {
 	logger             *zap.Logger
+	appConf            *lib.AppConfig
 	inhooksConfigSvc   services.InhooksConfigService
 	messageBuilder     services.MessageBuilder
 	messageEnqueuer    services.MessageEnqueuer
 	messageVerifier    services.MessageVerifier
 	messageTransformer services.MessageTransformer
+	ipAllowlistSvc     services.IPAllowlistService
+	ingestLimiter      services.IngestLimiter
+	challengeResponder services.ChallengeResponder
+	deduplicator       services.MessageDeduplicator
+	syncDeliverySvc    services.SyncDeliveryService
+	payloadValidator   services.PayloadValidator
+	captureSvc         services.CaptureService
+	ingestLogSvc       services.IngestLogService
 }
 
 type AppOpt func(app *App)
@@ -35,6 +46,12 @@ func WithLogger(logger *zap.Logger) AppOpt {
 	}
 }
 
+func WithAppConfig(appConf *lib.AppConfig) AppOpt {
+	return func(app *App) {
+		app.appConf = appConf
+	}
+}
+
 func WithInhooksConfigService(inhooksConfigSvc services.InhooksConfigService) AppOpt {
 	return func(app *App) {
 		app.inhooksConfigSvc = inhooksConfigSvc
@@ -65,9 +82,59 @@ func WithMessageTransformer(messageTransformer services.MessageTransformer) AppO
 	}
 }
 
+func WithIPAllowlistService(ipAllowlistSvc services.IPAllowlistService) AppOpt {
+	return func(app *App) {
+		app.ipAllowlistSvc = ipAllowlistSvc
+	}
+}
+
+func WithIngestLimiter(ingestLimiter services.IngestLimiter) AppOpt {
+	return func(app *App) {
+		app.ingestLimiter = ingestLimiter
+	}
+}
+
+func WithChallengeResponder(challengeResponder services.ChallengeResponder) AppOpt {
+	return func(app *App) {
+		app.challengeResponder = challengeResponder
+	}
+}
+
+func WithMessageDeduplicator(deduplicator services.MessageDeduplicator) AppOpt {
+	return func(app *App) {
+		app.deduplicator = deduplicator
+	}
+}
+
+func WithSyncDeliveryService(syncDeliverySvc services.SyncDeliveryService) AppOpt {
+	return func(app *App) {
+		app.syncDeliverySvc = syncDeliverySvc
+	}
+}
+
+func WithPayloadValidator(payloadValidator services.PayloadValidator) AppOpt {
+	return func(app *App) {
+		app.payloadValidator = payloadValidator
+	}
+}
+
+func WithCaptureService(captureSvc services.CaptureService) AppOpt {
+	return func(app *App) {
+		app.captureSvc = captureSvc
+	}
+}
+
+func WithIngestLogService(ingestLogSvc services.IngestLogService) AppOpt {
+	return func(app *App) {
+		app.ingestLogSvc = ingestLogSvc
+	}
+}
+
 type JSONErr struct {
 	Error string `json:"error"`
 	ReqID string `json:"reqID,omitempty"`
+	// payload schema violations
+	ValidationErrors []string `json:"validationErrors,omitempty"`
 }
 
 type JSONOK struct {
@@ -78,6 +145,11 @@ func (app *App) WriteJSONErr(w http.ResponseWriter, statusCode int, reqID string
 		Error: err.Error(),
 		ReqID: reqID,
 	}
+
+	var validationErr *services.SchemaValidationError
+	if errors.As(err, &validationErr) {
+		jsonErr.ValidationErrors = validationErr.Violations
+	}
 	app.WriteJSONResponse(w, statusCode, jsonErr)
 }
 
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"net/netip"
+
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+)
+
+type ListCapturesResponse struct {
+	Captures []*models.CapturedRequest `json:"captures"`
+}
+
+// HandleListCaptures lists the requests recorded by a capture source, most recent first
+func (app *App) HandleListCaptures(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	reqID := middleware.GetReqID(ctx)
+	sourceSlug := chi.URLParam(r, "sourceSlug")
+	logger := app.logger.With(zap.String("reqID", reqID), zap.String("sourceSlug", sourceSlug))
+
+	// the endpoint is disabled unless a token is configured
+	if app.appConf == nil || app.appConf.Ingest.CaptureAPIToken == "" {
+		logger.Error("list captures request failed: captures endpoint disabled")
+		app.WriteJSONErr(w, http.StatusNotFound, reqID, fmt.Errorf("captures endpoint disabled"))
+		return
+	}
+
+	expectedAuthorization := "Bearer " + app.appConf.Ingest.CaptureAPIToken
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(expectedAuthorization)) != 1 {
+		logger.Error("list captures request failed: invalid token")
+		app.WriteJSONErr(w, http.StatusUnauthorized, reqID, fmt.Errorf("invalid token"))
+		return
+	}
+
+	flow := app.inhooksConfigSvc.FindFlowForSource(sourceSlug)
+	if flow == nil {
+		logger.Error("list captures request failed: unknown source slug")
+		app.WriteJSONErr(w, http.StatusNotFound, reqID, fmt.Errorf("unknown source slug %s", sourceSlug))
+		return
+	}
+
+	if !flow.Source.Capture {
+		logger.Error("list captures request failed: capture not enabled")
+		app.WriteJSONErr(w, http.StatusNotFound, reqID, fmt.Errorf("capture not enabled for source slug %s", sourceSlug))
+		return
+	}
+
+	captures, err := app.captureSvc.List(ctx, flow)
+	if err != nil {
+		logger.Error("list captures request failed: unable to list captured requests", zap.Error(err))
+		app.WriteJSONErr(w, http.StatusInternalServerError, reqID, fmt.Errorf("unable to list captured requests"))
+		return
+	}
+
+	app.WriteJSONResponse(w, http.StatusOK, &ListCapturesResponse{Captures: captures})
+}
+
+// captureRequest records the request as sent by the client, without its credentials, with the result of its verification
+func (app *App) captureRequest(ctx context.Context, logger *zap.Logger, flow *models.Flow, r *http.Request, reqID string, clientIP netip.Addr, body []byte, verifyErr error) {
+	headers, rawQuery := app.redactCredentials(flow, r)
+
+	c := &models.CapturedRequest{
+		ReqID:    reqID,
+		Method:   r.Method,
+		Path:     r.URL.EscapedPath(),
+		RawQuery: rawQuery,
+		Headers:  headers,
+	}
+	if clientIP.IsValid() {
+		c.ClientIP = clientIP.String()
+	}
+	c.SetBody(bytes.Clone(body))
+
+	verification := flow.Source.Verification
+	switch {
+	case verification == nil || verification.VerificationType == "":
+		c.VerificationStatus = models.VerificationStatusNone
+	case verifyErr != nil:
+		c.VerificationStatus = models.VerificationStatusFailed
+		c.VerificationError = verifyErr.Error()
+	default:
+		c.VerificationStatus = models.VerificationStatusVerified
+	}
+
+	err := app.captureSvc.Capture(ctx, flow, c)
+	if err != nil {
+		// fail open, the request is still processed
+		logger.Error("unable to capture request", zap.Error(err))
+	}
+}
+
+// redactCredentials returns the request headers and query without the source verification credentials and signature
+func (app *App) redactCredentials(flow *models.Flow, r *http.Request) (http.Header, string) {
+	m := &models.Message{HttpHeaders: r.Header, RawQuery: r.URL.RawQuery}
+	app.messageVerifier.StripCredentials(flow, m)
+
+	verification := flow.Source.Verification
+	if verification != nil && verification.VerificationType == models.VerificationTypeHMAC {
+		m.HttpHeaders = m.HttpHeaders.Clone()
+		m.HttpHeaders.Del(verification.SignatureHeader)
+	}
+
+	return m.HttpHeaders, m.RawQuery
+}
@@ -0,0 +1,212 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/didil/inhooks/pkg/lib"
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/didil/inhooks/pkg/server"
+	"github.com/didil/inhooks/pkg/server/handlers"
+	"github.com/didil/inhooks/pkg/testsupport/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestIngest_Capture(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inhooksConfigSvc := mocks.NewMockInhooksConfigService(ctrl)
+	messageBuilder := mocks.NewMockMessageBuilder(ctrl)
+	messageVerifier := mocks.NewMockMessageVerifier(ctrl)
+	captureSvc := mocks.NewMockCaptureService(ctrl)
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	app := handlers.NewApp(
+		handlers.WithLogger(logger),
+		handlers.WithInhooksConfigService(inhooksConfigSvc),
+		handlers.WithMessageBuilder(messageBuilder),
+		handlers.WithMessageVerifier(messageVerifier),
+		handlers.WithCaptureService(captureSvc),
+	)
+	r := server.NewRouter(app)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	// capture only source, without sinks
+	flow := &models.Flow{
+		ID: "flow-1",
+		Source: &models.Source{
+			ID:      "source-1",
+			Capture: true,
+			Verification: &models.Verification{
+				VerificationType: models.VerificationTypeHMAC,
+				SignatureHeader:  "X-Signature",
+			},
+		},
+	}
+
+	sendRequest := func() *http.Response {
+		req, err := http.NewRequest(http.MethodPost, s.URL+"/api/v1/ingest/my-source/events?x=1", bytes.NewBufferString(`{"id": "abc"}`))
+		assert.NoError(t, err)
+		req.Header.Set("X-Signature", "sha256=abc")
+		req.Header.Set("Authorization", "Bearer secret-token")
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		return resp
+	}
+
+	var captured *models.CapturedRequest
+	expectCapture := func() {
+		inhooksConfigSvc.EXPECT().FindFlowForSource("my-source").Return(flow)
+		messageBuilder.EXPECT().FromHttp(flow, gomock.Any(), gomock.Any()).DoAndReturn(func(flow *models.Flow, r *http.Request, reqID string) ([]*models.Message, error) {
+			// the body is read by the message builder
+			_, err := io.ReadAll(r.Body)
+			assert.NoError(t, err)
+			return []*models.Message{}, nil
+		})
+		messageVerifier.EXPECT().StripCredentials(flow, gomock.Any()).Do(func(flow *models.Flow, m *models.Message) {
+			m.HttpHeaders = m.HttpHeaders.Clone()
+			m.HttpHeaders.Del("Authorization")
+		})
+		captureSvc.EXPECT().Capture(gomock.Any(), flow, gomock.Any()).DoAndReturn(func(ctx context.Context, flow *models.Flow, c *models.CapturedRequest) error {
+			captured = c
+			return nil
+		})
+	}
+
+	// the request is verified and captured
+	expectCapture()
+	messageVerifier.EXPECT().Verify(flow, gomock.Any()).DoAndReturn(func(flow *models.Flow, m *models.Message) error {
+		assert.Equal(t, []byte(`{"id": "abc"}`), m.Payload)
+		assert.Equal(t, "sha256=abc", m.HttpHeaders.Get("X-Signature"))
+		return nil
+	})
+
+	resp := sendRequest()
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, http.MethodPost, captured.Method)
+	assert.Equal(t, "/api/v1/ingest/my-source/events", captured.Path)
+	assert.Equal(t, "x=1", captured.RawQuery)
+	// the credentials and signature are not captured
+	assert.Equal(t, "", captured.Headers.Get("Authorization"))
+	assert.Equal(t, "", captured.Headers.Get("X-Signature"))
+	assert.Equal(t, "application/json", captured.Headers.Get("Content-Type"))
+	assert.Equal(t, `{"id": "abc"}`, captured.Body)
+	assert.Equal(t, models.VerificationStatusVerified, captured.VerificationStatus)
+
+	// requests failing verification are captured and rejected
+	expectCapture()
+	messageVerifier.EXPECT().Verify(flow, gomock.Any()).Return(fmt.Errorf("invalid signature"))
+
+	resp = sendRequest()
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, models.VerificationStatusFailed, captured.VerificationStatus)
+	assert.Equal(t, "invalid signature", captured.VerificationError)
+}
+
+func TestListCaptures(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inhooksConfigSvc := mocks.NewMockInhooksConfigService(ctrl)
+	captureSvc := mocks.NewMockCaptureService(ctrl)
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	appConf := &lib.AppConfig{
+		Ingest: lib.IngestConfig{
+			CaptureAPIToken: "captures-token",
+		},
+	}
+
+	app := handlers.NewApp(
+		handlers.WithLogger(logger),
+		handlers.WithAppConfig(appConf),
+		handlers.WithInhooksConfigService(inhooksConfigSvc),
+		handlers.WithCaptureService(captureSvc),
+	)
+	r := server.NewRouter(app)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	flow := &models.Flow{
+		ID:     "flow-1",
+		Source: &models.Source{ID: "source-1", Capture: true},
+	}
+
+	captures := []*models.CapturedRequest{
+		{
+			ReqID:              "req-2",
+			ReceivedAt:         time.Date(2023, 05, 5, 8, 9, 12, 0, time.UTC),
+			Method:             http.MethodPost,
+			Headers:            http.Header{"Content-Type": []string{"application/json"}},
+			Body:               `{"id": "abc"}`,
+			VerificationStatus: models.VerificationStatusNone,
+		},
+	}
+
+	listCaptures := func(sourceSlug string, token string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, s.URL+"/api/v1/sources/"+sourceSlug+"/captures", nil)
+		assert.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		return resp
+	}
+
+	// the token is required
+	for _, token := range []string{"", "other-token"} {
+		resp := listCaptures("my-source", token)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	}
+
+	inhooksConfigSvc.EXPECT().FindFlowForSource("my-source").Return(flow)
+	captureSvc.EXPECT().List(gomock.Any(), flow).Return(captures, nil)
+
+	resp := listCaptures("my-source", "captures-token")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	listResp := &handlers.ListCapturesResponse{}
+	err = json.NewDecoder(resp.Body).Decode(listResp)
+	assert.NoError(t, err)
+	assert.Equal(t, captures, listResp.Captures)
+
+	// sources without capture
+	inhooksConfigSvc.EXPECT().FindFlowForSource("other-source").Return(&models.Flow{ID: "flow-2", Source: &models.Source{ID: "source-2"}})
+
+	resp = listCaptures("other-source", "captures-token")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// the endpoint is disabled without token
+	appConf.Ingest.CaptureAPIToken = ""
+
+	resp = listCaptures("my-source", "")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -1,16 +1,26 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
+	"net/netip"
+	"strconv"
+	"time"
 
 	"github.com/didil/inhooks/pkg/models"
+	"github.com/didil/inhooks/pkg/services"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"golang.org/x/exp/slices"
 )
 
 var ingestRequestsCounter = promauto.NewCounter(prometheus.CounterOpts{
@@ -23,6 +33,41 @@ var enqueuedMessagesCounter = promauto.NewCounter(prometheus.CounterOpts{
 	Help: "Number of enqueued messages",
 })
 
+var droppedDuplicatesCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "ingest_dropped_duplicates_total",
+	Help: "Number of ingest requests dropped because their idempotency key was already ingested",
+}, []string{"sourceID"})
+
+var syncDeliveriesCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "ingest_sync_deliveries_total",
+	Help: "Number of inline deliveries to sync sinks",
+}, []string{"sourceID", "status"})
+
+var schemaViolationsCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "ingest_schema_violations_total",
+	Help: "Number of ingest requests with a payload not matching the source schema",
+}, []string{"sourceID", "mode"})
+
+var filteredSinksCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "ingest_filtered_sinks_total",
+	Help: "Number of sinks skipped because their filter did not match the ingest request",
+}, []string{"sourceID", "sinkID"})
+
+var rejectedIngestRequestsCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "ingest_rejected_requests_total",
+	Help: "Number of rejected ingest requests",
+}, []string{"sourceID", "reason"})
+
+const (
+	rejectionReasonIPNotAllowed = "ip_not_allowed"
+	rejectionReasonRateLimit    = "rate_limit"
+	rejectionReasonQueueDepth   = "queue_depth"
+	rejectionReasonBodyTooLarge = "body_too_large"
+	rejectionReasonContentType  = "unsupported_content_type"
+	rejectionReasonEncoding     = "unsupported_content_encoding"
+	rejectionReasonSchema       = "schema_violation"
+)
+
 func (app *App) HandleIngest(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	reqID := middleware.GetReqID(ctx)
@@ -32,37 +77,274 @@ func (app *App) HandleIngest(w http.ResponseWriter, r *http.Request) {
 	logger.Info("new ingest request")
 	ingestRequestsCounter.Inc()
 
+	respData := &models.IngestResponseData{ReqID: reqID, SourceSlug: sourceSlug}
+
 	// find the flow
 	flow := app.inhooksConfigSvc.FindFlowForSource(sourceSlug)
-	if flow == nil {
+	if flow == nil || !flow.Source.AcceptsHttpRequests() {
 		logger.Error("ingest request failed: unknown source slug", zap.String("sourceSlug", sourceSlug))
-		app.WriteJSONErr(w, http.StatusNotFound, reqID, fmt.Errorf("unknown source slug %s", sourceSlug))
+		app.writeIngestErr(w, app.inhooksConfigSvc.GetUnknownSourceResponse(), http.StatusNotFound, respData, fmt.Errorf("unknown source slug %s", sourceSlug))
 		return
 	}
 
 	logger = logger.With(zap.String("flowID", flow.ID), zap.String("sourceID", flow.Source.ID))
 
+	// check the client ip
+	var clientIP netip.Addr
+	if flow.Source.HasIPAllowlist() {
+		var err error
+		clientIP, err = app.ipAllowlistSvc.ClientIP(r)
+		if err != nil {
+			logger.Error("ingest request failed: unable to get client ip", zap.Error(err))
+			rejectedIngestRequestsCounter.WithLabelValues(flow.Source.ID, rejectionReasonIPNotAllowed).Inc()
+			app.writeIngestErr(w, flow.Source.FailureResponse, http.StatusForbidden, respData, fmt.Errorf("ip not allowed"))
+			return
+		}
+
+		if !app.ipAllowlistSvc.IsAllowed(flow.Source, clientIP) {
+			logger.Error("ingest request failed: ip not allowed", zap.String("clientIP", clientIP.String()))
+			rejectedIngestRequestsCounter.WithLabelValues(flow.Source.ID, rejectionReasonIPNotAllowed).Inc()
+			app.writeIngestErr(w, flow.Source.FailureResponse, http.StatusForbidden, respData, fmt.Errorf("ip not allowed"))
+			return
+		}
+	}
+
+	// check the source rate limit
+	if flow.Source.RateLimit != nil {
+		allowed, retryAfter, err := app.ingestLimiter.CheckRateLimit(ctx, flow.Source)
+		if err != nil {
+			// fail open, the request is still processed
+			logger.Error("unable to check rate limit", zap.Error(err))
+		} else if !allowed {
+			logger.Error("ingest request failed: rate limit exceeded", zap.Duration("retryAfter", retryAfter))
+			rejectedIngestRequestsCounter.WithLabelValues(flow.Source.ID, rejectionReasonRateLimit).Inc()
+			setRetryAfter(w, retryAfter)
+			app.writeIngestErr(w, flow.Source.FailureResponse, http.StatusTooManyRequests, respData, fmt.Errorf("rate limit exceeded"))
+			return
+		}
+	}
+
+	// limit the body size
+	if maxBodyBytes := app.maxBodyBytes(flow.Source); maxBodyBytes > 0 {
+		if r.ContentLength > maxBodyBytes {
+			logger.Error("ingest request failed: body too large", zap.Int64("contentLength", r.ContentLength))
+			rejectedIngestRequestsCounter.WithLabelValues(flow.Source.ID, rejectionReasonBodyTooLarge).Inc()
+			app.writeIngestErr(w, flow.Source.FailureResponse, http.StatusRequestEntityTooLarge, respData, fmt.Errorf("request body too large"))
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	}
+
+	// answer endpoint verification challenges
+	if flow.Source.Challenge != nil {
+		challengeResp, err := app.challengeResponder.Respond(flow, r)
+		if isBodyTooLarge(err) {
+			logger.Error("ingest request failed: body too large", zap.Error(err))
+			rejectedIngestRequestsCounter.WithLabelValues(flow.Source.ID, rejectionReasonBodyTooLarge).Inc()
+			app.writeIngestErr(w, flow.Source.FailureResponse, http.StatusRequestEntityTooLarge, respData, fmt.Errorf("request body too large"))
+			return
+		}
+		if err != nil {
+			logger.Error("ingest request failed: unable to respond to challenge", zap.Error(err))
+			app.writeIngestErr(w, flow.Source.FailureResponse, http.StatusForbidden, respData, fmt.Errorf("unable to respond to challenge"))
+			return
+		}
+
+		if challengeResp != nil {
+			w.Header().Set("Content-Type", challengeResp.ContentType)
+			w.WriteHeader(http.StatusOK)
+			_, err = w.Write(challengeResp.Body)
+			if err != nil {
+				logger.Error("challenge response write err", zap.Error(err))
+			}
+			logger.Info("challenge request succeeded")
+			return
+		}
+	}
+
+	if !flow.Source.IsMethodAllowed(r.Method) {
+		logger.Error("ingest request failed: method not allowed", zap.String("method", r.Method))
+		app.writeIngestErr(w, flow.Source.FailureResponse, http.StatusMethodNotAllowed, respData, fmt.Errorf("method not allowed"))
+		return
+	}
+
+	if !flow.Source.IsContentTypeAllowed(r.Header.Get("Content-Type")) {
+		logger.Error("ingest request failed: unsupported content type", zap.String("contentType", r.Header.Get("Content-Type")))
+		rejectedIngestRequestsCounter.WithLabelValues(flow.Source.ID, rejectionReasonContentType).Inc()
+		app.writeIngestErr(w, flow.Source.FailureResponse, http.StatusUnsupportedMediaType, respData, fmt.Errorf("unsupported content type"))
+		return
+	}
+
+	// check the sinks queues depth
+	if flow.Source.MaxQueueDepth != nil {
+		allowed, err := app.ingestLimiter.CheckQueueDepth(ctx, flow)
+		if err != nil {
+			// fail open, the request is still processed
+			logger.Error("unable to check queue depth", zap.Error(err))
+		} else if !allowed {
+			logger.Error("ingest request failed: max queue depth reached")
+			rejectedIngestRequestsCounter.WithLabelValues(flow.Source.ID, rejectionReasonQueueDepth).Inc()
+			setRetryAfter(w, app.appConf.Ingest.BackpressureRetryAfter)
+			app.writeIngestErr(w, flow.Source.FailureResponse, http.StatusServiceUnavailable, respData, fmt.Errorf("service overloaded"))
+			return
+		}
+	}
+
+	// keep the raw body to verify and validate the whole request, and to capture or log it
+	rawBody := &bytes.Buffer{}
+	r.Body = io.NopCloser(io.TeeReader(r.Body, rawBody))
+
 	// build messages
 	messages, err := app.messageBuilder.FromHttp(flow, r, reqID)
+	if errors.Is(err, services.ErrUnsupportedContentEncoding) {
+		logger.Error("ingest request failed: unsupported content encoding", zap.Error(err))
+		rejectedIngestRequestsCounter.WithLabelValues(flow.Source.ID, rejectionReasonEncoding).Inc()
+		app.writeIngestErr(w, flow.Source.FailureResponse, http.StatusUnsupportedMediaType, respData, fmt.Errorf("unsupported content encoding"))
+		return
+	}
+	if isBodyTooLarge(err) {
+		logger.Error("ingest request failed: body too large", zap.Error(err))
+		rejectedIngestRequestsCounter.WithLabelValues(flow.Source.ID, rejectionReasonBodyTooLarge).Inc()
+		app.writeIngestErr(w, flow.Source.FailureResponse, http.StatusRequestEntityTooLarge, respData, fmt.Errorf("request body too large"))
+		return
+	}
+	if errors.Is(err, services.ErrInvalidSplitPayload) {
+		logger.Error("ingest request failed: unable to split payload", zap.Error(err))
+		app.writeIngestErr(w, flow.Source.FailureResponse, http.StatusBadRequest, respData, fmt.Errorf("unable to split payload"))
+		return
+	}
 	if err != nil {
 		logger.Error("ingest request failed: unable to build messages", zap.Error(err))
-		app.WriteJSONErr(w, http.StatusBadRequest, reqID, fmt.Errorf("unable to read data"))
+		app.writeIngestErr(w, flow.Source.FailureResponse, http.StatusBadRequest, respData, fmt.Errorf("unable to read data"))
 		return
 	}
 
-	// verify messages (first message is enough as payloads and signatures are the same)
-	err = app.messageVerifier.Verify(flow, messages[0])
+	// record the sinks skipped by their filter
+	for _, sink := range flow.Sinks {
+		if sink.Filter != nil && !slices.ContainsFunc(messages, func(m *models.Message) bool { return m.SinkID == sink.ID }) {
+			logger.Info("sink skipped by filter", zap.String("sinkID", sink.ID))
+			filteredSinksCounter.WithLabelValues(flow.Source.ID, sink.ID).Inc()
+		}
+	}
+
+	// record the client ip, resolved through the trusted proxies
+	if !clientIP.IsValid() && app.ipAllowlistSvc != nil {
+		clientIP, err = app.ipAllowlistSvc.ClientIP(r)
+		if err != nil {
+			logger.Warn("unable to get client ip", zap.Error(err))
+		}
+	}
+	if clientIP.IsValid() {
+		for _, m := range messages {
+			m.ClientIP = clientIP.String()
+		}
+	}
+
+	// verify the request before the sinks filters apply, so that requests producing no message are verified too
+	requestMessage, err := app.requestMessage(flow, r, rawBody.Bytes(), messages)
+	if err == nil {
+		err = app.messageVerifier.Verify(flow, requestMessage)
+	}
+
+	if flow.Source.Capture {
+		app.captureRequest(ctx, logger, flow, r, reqID, clientIP, rawBody.Bytes(), err)
+	}
+
 	if err != nil {
 		logger.Error("ingest request failed: unable to verify messages signature", zap.Error(err))
-		app.WriteJSONErr(w, http.StatusForbidden, reqID, fmt.Errorf("unable to verify signature"))
+		app.writeIngestErr(w, flow.Source.FailureResponse, http.StatusForbidden, respData, fmt.Errorf("unable to verify signature"))
+		return
+	}
+
+	// validate the payload against the source schema, once per payload element when the payload is split
+	if flow.Source.Schema != nil {
+		err = app.validateRequest(flow.Source, requestMessage)
+		var validationErr *services.SchemaValidationError
+		if errors.As(err, &validationErr) {
+			schemaViolationsCounter.WithLabelValues(flow.Source.ID, string(flow.Source.Schema.Mode)).Inc()
+
+			if flow.Source.Schema.Mode == models.SchemaModeWarn {
+				logger.Warn("payload does not match schema", zap.Strings("violations", validationErr.Violations))
+			} else {
+				logger.Error("ingest request failed: payload does not match schema", zap.Strings("violations", validationErr.Violations))
+				rejectedIngestRequestsCounter.WithLabelValues(flow.Source.ID, rejectionReasonSchema).Inc()
+				app.writeIngestErr(w, flow.Source.FailureResponse, http.StatusBadRequest, respData, err)
+				return
+			}
+		} else if err != nil {
+			// fail open, the request is still processed
+			logger.Error("unable to validate payload", zap.Error(err))
+		}
+	}
+
+	if len(messages) == 0 {
+		if flow.Source.IngestLog != nil {
+			app.logRequest(ctx, logger, flow, r, reqID, clientIP, requestMessage, rawBody.Bytes())
+		}
+
+		app.writeIngestOK(w, flow.Source.SuccessResponse, respData)
+		logger.Info("ingest request succeeded: no message to enqueue")
 		return
 	}
 
+	// remove verification credentials so that they are not forwarded to the sinks
+	for _, m := range messages {
+		app.messageVerifier.StripCredentials(flow, m)
+	}
+
+	// drop duplicate requests
+	idempotencyKey := ""
+	if flow.Source.Dedupe != nil {
+		idempotencyKey, err = app.deduplicator.IdempotencyKey(flow, requestMessage)
+		if err != nil {
+			logger.Error("unable to get idempotency key", zap.Error(err))
+		}
+
+		if idempotencyKey != "" {
+			claimed, err := app.deduplicator.Claim(ctx, flow, idempotencyKey, reqID)
+			if err != nil {
+				// fail open, the request is still processed
+				logger.Error("unable to claim idempotency key", zap.Error(err))
+				idempotencyKey = ""
+			} else if !claimed {
+				logger.Info("duplicate ingest request dropped", zap.String("idempotencyKey", idempotencyKey))
+				droppedDuplicatesCounter.WithLabelValues(flow.Source.ID).Inc()
+				app.writeIngestOK(w, flow.Source.SuccessResponse, respData)
+				return
+			}
+		}
+	}
+
+	// keep the request to backfill sinks
+	if flow.Source.IngestLog != nil {
+		app.logRequest(ctx, logger, flow, r, reqID, clientIP, requestMessage, rawBody.Bytes())
+	}
+
+	// the sync sink message is enqueued in the processing queue and delivered inline once enqueued
+	var syncSink *models.Sink
+	var syncMessage *models.Message
+	if flow.Source.Sync != nil {
+		syncSink, syncMessage = syncTarget(flow, messages)
+		if syncMessage == nil {
+			logger.Error("sync sink not found", zap.String("sinkID", flow.Source.Sync.SinkID))
+		} else {
+			syncMessage.DeliverInline = true
+		}
+	}
+
 	// enqueue messages
 	queuedInfos, err := app.messageEnqueuer.Enqueue(ctx, messages)
 	if err != nil {
 		logger.Error("ingest request failed: unable to enqueue messages", zap.Error(err))
-		app.WriteJSONErr(w, http.StatusBadRequest, reqID, fmt.Errorf("unable to enqueue data"))
+		if idempotencyKey != "" {
+			// allow the sender to retry
+			releaseErr := app.deduplicator.Release(ctx, flow, idempotencyKey)
+			if releaseErr != nil {
+				logger.Error("unable to release idempotency key", zap.Error(releaseErr))
+			}
+		}
+		app.writeIngestErr(w, flow.Source.FailureResponse, http.StatusBadRequest, respData, fmt.Errorf("unable to enqueue data"))
 		return
 	}
 	enqueuedMessagesCounter.Add(float64(len(queuedInfos)))
@@ -75,6 +357,133 @@ func (app *App) HandleIngest(w http.ResponseWriter, r *http.Request) {
 		logger.Info("message queued", fields...)
 	}
 
-	app.WriteJSONResponse(w, http.StatusOK, JSONOK{})
+	// deliver inline to the sync sink, the message is retried asynchronously on failure
+	var syncResp *models.SinkResponse
+	if syncMessage != nil {
+		syncResp = app.deliverSync(ctx, logger, flow, syncSink, syncMessage)
+	}
+
+	if syncResp != nil {
+		app.writeSinkResponse(w, syncResp)
+		logger.Info("ingest request succeeded", zap.Int("syncStatusCode", syncResp.StatusCode))
+		return
+	}
+
+	app.writeIngestOK(w, flow.Source.SuccessResponse, respData)
 	logger.Info("ingest request succeeded")
 }
+
+// requestMessage returns a message holding the whole request, before the sinks filters and the payload split apply
+func (app *App) requestMessage(flow *models.Flow, r *http.Request, body []byte, messages []*models.Message) (*models.Message, error) {
+	if len(messages) > 0 && flow.Source.Split == nil {
+		// the messages payloads and signatures are the request ones
+		return messages[0], nil
+	}
+
+	m, err := services.RequestMessage(flow.Source, r, body)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) > 0 {
+		m.ReceivedAt = messages[0].ReceivedAt
+		m.TLSVersion = messages[0].TLSVersion
+	}
+
+	return m, nil
+}
+
+// validateRequest validates the request payload, or each element of the payload when it is split, stopping at the first schema violation
+func (app *App) validateRequest(source *models.Source, requestMessage *models.Message) error {
+	if source.Split == nil {
+		return app.payloadValidator.Validate(source, requestMessage)
+	}
+
+	elements, err := source.Split.Elements(requestMessage.Payload)
+	if err != nil {
+		return err
+	}
+
+	for i, element := range elements {
+		m := *requestMessage
+		m.Payload = element
+		m.SplitIndex = &i
+
+		err := app.payloadValidator.Validate(source, &m)
+		var validationErr *services.SchemaValidationError
+		if errors.As(err, &validationErr) {
+			// report the invalid element
+			violations := make([]string, 0, len(validationErr.Violations))
+			for _, violation := range validationErr.Violations {
+				violations = append(violations, fmt.Sprintf("element %d: %s", i, violation))
+			}
+			return &services.SchemaValidationError{Violations: violations}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// syncTarget returns the sync sink and its message, or nil if the sync sink has no message
+func syncTarget(flow *models.Flow, messages []*models.Message) (*models.Sink, *models.Message) {
+	sinkIdx := slices.IndexFunc(flow.Sinks, func(sink *models.Sink) bool { return sink.ID == flow.Source.Sync.SinkID })
+	mIdx := slices.IndexFunc(messages, func(m *models.Message) bool { return m.SinkID == flow.Source.Sync.SinkID })
+	if sinkIdx == -1 || mIdx == -1 {
+		return nil, nil
+	}
+
+	return flow.Sinks[sinkIdx], messages[mIdx]
+}
+
+// deliverSync delivers the enqueued message of the sync sink inline and returns the sink response, or nil if the delivery failed
+func (app *App) deliverSync(ctx context.Context, logger *zap.Logger, flow *models.Flow, sink *models.Sink, m *models.Message) *models.SinkResponse {
+	if flow.Source.Sync.Timeout != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *flow.Source.Sync.Timeout)
+		defer cancel()
+	}
+
+	resp, err := app.syncDeliverySvc.Deliver(ctx, sink, m)
+	if err != nil && resp == nil {
+		logger.Error("sync delivery failed, falling back to async delivery", zap.String("sinkID", sink.ID), zap.String("messageID", m.ID), zap.Error(err))
+		syncDeliveriesCounter.WithLabelValues(flow.Source.ID, "failed").Inc()
+		return nil
+	}
+	if err != nil {
+		// the message is redelivered once recovered from the processing queue
+		logger.Error("sync delivery succeeded but the message could not be moved to done", zap.String("sinkID", sink.ID), zap.String("messageID", m.ID), zap.Error(err))
+	}
+
+	logger.Info("sync delivery succeeded", zap.String("sinkID", sink.ID), zap.String("messageID", m.ID), zap.Int("statusCode", resp.StatusCode))
+	syncDeliveriesCounter.WithLabelValues(flow.Source.ID, "ok").Inc()
+	return resp
+}
+
+// setRetryAfter sets the Retry-After header in seconds, rounded up
+func setRetryAfter(w http.ResponseWriter, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+}
+
+// maxBodyBytes returns the max request body size of the source, 0 meaning no limit
+func (app *App) maxBodyBytes(source *models.Source) int64 {
+	if source.MaxBodyBytes != nil {
+		return *source.MaxBodyBytes
+	}
+
+	if app.appConf == nil {
+		return 0
+	}
+
+	return app.appConf.Ingest.MaxBodyBytes
+}
+
+func isBodyTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr) || errors.Is(err, services.ErrDecompressedBodyTooLarge)
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/netip"
+
+	"github.com/didil/inhooks/pkg/models"
+	"go.uber.org/zap"
+)
+
+// logRequest records the request in the source ingest log, with its body as sent by the client and without the verification credentials and signature
+func (app *App) logRequest(ctx context.Context, logger *zap.Logger, flow *models.Flow, r *http.Request, reqID string, clientIP netip.Addr, requestMessage *models.Message, body []byte) {
+	headers, rawQuery := app.redactCredentials(flow, r)
+
+	entry := &models.IngestLogEntry{
+		IngestedReqID: reqID,
+		ReceivedAt:    requestMessage.ReceivedAt,
+		HttpMethod:    r.Method,
+		HttpHeaders:   headers,
+		RawQuery:      rawQuery,
+		PathSuffix:    requestMessage.PathSuffix,
+		HttpPath:      r.URL.EscapedPath(),
+		TLSVersion:    requestMessage.TLSVersion,
+		Body:          bytes.Clone(body),
+	}
+	if clientIP.IsValid() {
+		entry.ClientIP = clientIP.String()
+	}
+
+	err := app.ingestLogSvc.Append(ctx, flow, entry)
+	if err != nil {
+		// fail open, the request is still processed
+		logger.Error("unable to log ingest request", zap.Error(err))
+	}
+}
@@ -0,0 +1,218 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/didil/inhooks/pkg/server"
+	"github.com/didil/inhooks/pkg/server/handlers"
+	"github.com/didil/inhooks/pkg/services"
+	"github.com/didil/inhooks/pkg/testsupport/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestIngest_IngestLog(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inhooksConfigSvc := mocks.NewMockInhooksConfigService(ctrl)
+	messageBuilder := mocks.NewMockMessageBuilder(ctrl)
+	messageEnqueuer := mocks.NewMockMessageEnqueuer(ctrl)
+	messageVerifier := mocks.NewMockMessageVerifier(ctrl)
+	ingestLogSvc := mocks.NewMockIngestLogService(ctrl)
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	app := handlers.NewApp(
+		handlers.WithLogger(logger),
+		handlers.WithInhooksConfigService(inhooksConfigSvc),
+		handlers.WithMessageBuilder(messageBuilder),
+		handlers.WithMessageEnqueuer(messageEnqueuer),
+		handlers.WithMessageVerifier(messageVerifier),
+		handlers.WithIngestLogService(ingestLogSvc),
+	)
+	r := server.NewRouter(app)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	flow := &models.Flow{
+		ID:     "flow-1",
+		Source: &models.Source{ID: "source-1", IngestLog: &models.IngestLog{}},
+	}
+
+	receivedAt := time.Date(2023, 05, 5, 8, 9, 12, 0, time.UTC)
+	messages := []*models.Message{{ID: "m-1", ReceivedAt: receivedAt, PathSuffix: "events"}}
+
+	inhooksConfigSvc.EXPECT().FindFlowForSource("my-source").Return(flow)
+	messageBuilder.EXPECT().FromHttp(flow, gomock.Any(), gomock.Any()).DoAndReturn(func(flow *models.Flow, r *http.Request, reqID string) ([]*models.Message, error) {
+		// the body is read by the message builder
+		_, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		return messages, nil
+	})
+	messageVerifier.EXPECT().Verify(flow, messages[0]).Return(nil)
+	messageVerifier.EXPECT().StripCredentials(flow, gomock.Any()).Times(2)
+	messageEnqueuer.EXPECT().Enqueue(gomock.Any(), messages).Return([]*models.QueuedInfo{}, nil)
+
+	var entry *models.IngestLogEntry
+	ingestLogSvc.EXPECT().Append(gomock.Any(), flow, gomock.Any()).DoAndReturn(func(ctx context.Context, flow *models.Flow, e *models.IngestLogEntry) error {
+		entry = e
+		return nil
+	})
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/api/v1/ingest/my-source/events?x=1", bytes.NewBufferString(`{"id": "abc"}`))
+	assert.NoError(t, err)
+	req.Header.Set("X-Signature", "abc")
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// the raw request is logged
+	assert.NotEmpty(t, entry.IngestedReqID)
+	assert.Equal(t, receivedAt, entry.ReceivedAt)
+	assert.Equal(t, http.MethodPost, entry.HttpMethod)
+	assert.Equal(t, "abc", entry.HttpHeaders.Get("X-Signature"))
+	assert.Equal(t, "x=1", entry.RawQuery)
+	assert.Equal(t, "events", entry.PathSuffix)
+	assert.Equal(t, "/api/v1/ingest/my-source/events", entry.HttpPath)
+	assert.Equal(t, []byte(`{"id": "abc"}`), entry.Body)
+}
+
+func TestIngest_IngestLogRedactsCredentials(t *testing.T) {
+	t.Setenv("INGEST_LOG_TEST_SECRET", "s3cret-value")
+	hmacAlgorithm := models.HMACAlgorithmSHA256
+
+	tcs := []struct {
+		name         string
+		verification *models.Verification
+		prepare      func(req *http.Request)
+	}{
+		{
+			name: "basic auth",
+			verification: &models.Verification{
+				VerificationType:    models.VerificationTypeBasicAuth,
+				BasicAuthUsername:   "user",
+				CurrentSecretEnvVar: "INGEST_LOG_TEST_SECRET",
+			},
+			prepare: func(req *http.Request) {
+				req.SetBasicAuth("user", "s3cret-value")
+			},
+		},
+		{
+			name: "token",
+			verification: &models.Verification{
+				VerificationType:    models.VerificationTypeToken,
+				SignatureHeader:     "Authorization",
+				SignaturePrefix:     "Bearer ",
+				CurrentSecretEnvVar: "INGEST_LOG_TEST_SECRET",
+			},
+			prepare: func(req *http.Request) {
+				req.Header.Set("Authorization", "Bearer s3cret-value")
+			},
+		},
+		{
+			name: "query token",
+			verification: &models.Verification{
+				VerificationType:    models.VerificationTypeQueryToken,
+				TokenQueryParam:     "token",
+				CurrentSecretEnvVar: "INGEST_LOG_TEST_SECRET",
+			},
+			prepare: func(req *http.Request) {
+				req.URL.RawQuery = "x=1&token=s3cret-value"
+			},
+		},
+		{
+			name: "hmac",
+			verification: &models.Verification{
+				VerificationType:    models.VerificationTypeHMAC,
+				HMACAlgorithm:       &hmacAlgorithm,
+				SignatureHeader:     "X-Signature",
+				SignaturePrefix:     "sha256=",
+				CurrentSecretEnvVar: "INGEST_LOG_TEST_SECRET",
+			},
+			prepare: func(req *http.Request) {
+				mac := hmac.New(sha256.New, []byte("s3cret-value"))
+				mac.Write([]byte(`{"id": "abc"}`))
+				req.Header.Set("X-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			inhooksConfigSvc := mocks.NewMockInhooksConfigService(ctrl)
+			messageEnqueuer := mocks.NewMockMessageEnqueuer(ctrl)
+			ingestLogSvc := mocks.NewMockIngestLogService(ctrl)
+			logger, err := zap.NewDevelopment()
+			assert.NoError(t, err)
+
+			app := handlers.NewApp(
+				handlers.WithLogger(logger),
+				handlers.WithInhooksConfigService(inhooksConfigSvc),
+				handlers.WithMessageBuilder(services.NewMessageBuilder(services.NewTimeService())),
+				handlers.WithMessageEnqueuer(messageEnqueuer),
+				handlers.WithMessageVerifier(services.NewMessageVerifier()),
+				handlers.WithIngestLogService(ingestLogSvc),
+			)
+			r := server.NewRouter(app)
+			s := httptest.NewServer(r)
+			defer s.Close()
+
+			flow := &models.Flow{
+				ID: "flow-1",
+				Source: &models.Source{
+					ID:           "source-1",
+					IngestLog:    &models.IngestLog{},
+					Verification: tc.verification,
+				},
+				Sinks: []*models.Sink{{ID: "sink-1"}},
+			}
+
+			inhooksConfigSvc.EXPECT().FindFlowForSource("my-source").Return(flow)
+			messageEnqueuer.EXPECT().Enqueue(gomock.Any(), gomock.Any()).Return([]*models.QueuedInfo{}, nil)
+
+			var entry *models.IngestLogEntry
+			ingestLogSvc.EXPECT().Append(gomock.Any(), flow, gomock.Any()).DoAndReturn(func(ctx context.Context, flow *models.Flow, e *models.IngestLogEntry) error {
+				entry = e
+				return nil
+			})
+
+			req, err := http.NewRequest(http.MethodPost, s.URL+"/api/v1/ingest/my-source", bytes.NewBufferString(`{"id": "abc"}`))
+			assert.NoError(t, err)
+			tc.prepare(req)
+
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+			// neither the secret nor the signature reach the stored entry
+			b, err := json.Marshal(entry)
+			assert.NoError(t, err)
+			assert.NotContains(t, string(b), "s3cret-value")
+			assert.NotContains(t, string(b), "czNjcmV0LXZhbHVl")
+			assert.Empty(t, entry.HttpHeaders.Get("Authorization"))
+			assert.Empty(t, entry.HttpHeaders.Get("X-Signature"))
+			assert.Equal(t, []byte(`{"id": "abc"}`), entry.Body)
+		})
+	}
+}
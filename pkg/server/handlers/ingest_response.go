@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/didil/inhooks/pkg/models"
+	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
+)
+
+// writeIngestOK writes the source success response, or an empty JSON object if not configured
+func (app *App) writeIngestOK(w http.ResponseWriter, customResp *models.IngestResponse, data *models.IngestResponseData) {
+	if customResp == nil {
+		app.WriteJSONResponse(w, http.StatusOK, JSONOK{})
+		return
+	}
+
+	app.writeCustomIngestResponse(w, customResp, http.StatusOK, data)
+}
+
+// writeIngestErr writes the source failure response, or a JSON error if not configured
+func (app *App) writeIngestErr(w http.ResponseWriter, customResp *models.IngestResponse, statusCode int, data *models.IngestResponseData, err error) {
+	if customResp == nil {
+		app.WriteJSONErr(w, statusCode, data.ReqID, err)
+		return
+	}
+
+	errData := *data
+	errData.Error = err.Error()
+	app.writeCustomIngestResponse(w, customResp, statusCode, &errData)
+}
+
+func (app *App) writeCustomIngestResponse(w http.ResponseWriter, customResp *models.IngestResponse, defaultStatusCode int, data *models.IngestResponseData) {
+	body, err := customResp.RenderBody(data)
+	if err != nil {
+		app.logger.Error("failed to render ingest response body", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	for k, v := range customResp.Headers {
+		w.Header().Set(k, v)
+	}
+	if customResp.ContentType != "" {
+		w.Header().Set("Content-Type", customResp.ContentType)
+	}
+
+	statusCode := defaultStatusCode
+	if customResp.StatusCode != 0 {
+		statusCode = customResp.StatusCode
+	}
+	w.WriteHeader(statusCode)
+
+	_, err = w.Write(body)
+	if err != nil {
+		app.logger.Error("ingest response write err", zap.Error(err))
+	}
+}
+
+// hop-by-hop and length headers of sink responses that are not relayed to the sender
+var sinkResponseSkippedHeaders = []string{
+	"Connection",
+	"Content-Length",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Connection",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// writeSinkResponse relays the response of a sync sink to the sender
+func (app *App) writeSinkResponse(w http.ResponseWriter, resp *models.SinkResponse) {
+	for k, v := range resp.Headers {
+		if slices.Contains(sinkResponseSkippedHeaders, http.CanonicalHeaderKey(k)) {
+			continue
+		}
+		w.Header()[k] = v
+	}
+	w.WriteHeader(resp.StatusCode)
+
+	_, err := w.Write(resp.Body)
+	if err != nil {
+		app.logger.Error("sink response write err", zap.Error(err))
+	}
+}
@@ -2,16 +2,23 @@ package handlers_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
 	"testing"
+	"time"
 
+	"github.com/didil/inhooks/pkg/lib"
 	"github.com/didil/inhooks/pkg/models"
 	"github.com/didil/inhooks/pkg/server"
 	"github.com/didil/inhooks/pkg/server/handlers"
+	"github.com/didil/inhooks/pkg/services"
 	"github.com/didil/inhooks/pkg/testsupport/mocks"
+	"github.com/go-chi/chi/v5"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
@@ -58,6 +65,8 @@ func TestIngest_OK(t *testing.T) {
 	messageBuilder.EXPECT().FromHttp(flow, gomock.AssignableToTypeOf(&http.Request{}), gomock.AssignableToTypeOf("")).Return(messages, nil)
 
 	messageVerifier.EXPECT().Verify(flow, messages[0]).Return(nil)
+	messageVerifier.EXPECT().StripCredentials(flow, messages[0])
+	messageVerifier.EXPECT().StripCredentials(flow, messages[1])
 
 	queuedInfos := []*models.QueuedInfo{
 		{MessageID: messages[0].ID, QueueStatus: models.QueueStatusReady},
@@ -82,6 +91,47 @@ func TestIngest_OK(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestIngest_PathSuffix(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inhooksConfigSvc := mocks.NewMockInhooksConfigService(ctrl)
+	messageBuilder := mocks.NewMockMessageBuilder(ctrl)
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	app := handlers.NewApp(
+		handlers.WithLogger(logger),
+		handlers.WithInhooksConfigService(inhooksConfigSvc),
+		handlers.WithMessageBuilder(messageBuilder),
+	)
+	r := server.NewRouter(app)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	flow := &models.Flow{
+		ID:     "flow-1",
+		Source: &models.Source{},
+	}
+
+	inhooksConfigSvc.EXPECT().FindFlowForSource("my-source").Return(flow)
+
+	messageBuilder.EXPECT().FromHttp(flow, gomock.AssignableToTypeOf(&http.Request{}), gomock.AssignableToTypeOf("")).
+		DoAndReturn(func(flow *models.Flow, r *http.Request, reqID string) ([]*models.Message, error) {
+			assert.Equal(t, "orders/created", chi.URLParam(r, "*"))
+			return nil, fmt.Errorf("stop")
+		})
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/api/v1/ingest/my-source/orders/created", bytes.NewBufferString(`{"id": "abc"}`))
+	assert.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
 func TestIngest_FlowNotFound(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -103,6 +153,7 @@ func TestIngest_FlowNotFound(t *testing.T) {
 	defer s.Close()
 
 	inhooksConfigSvc.EXPECT().FindFlowForSource("my-source").Return(nil)
+	inhooksConfigSvc.EXPECT().GetUnknownSourceResponse().Return(nil)
 
 	buf := bytes.NewBufferString(`{"id": "abc"}`)
 
@@ -123,6 +174,41 @@ func TestIngest_FlowNotFound(t *testing.T) {
 	assert.Equal(t, "unknown source slug my-source", jsonErr.Error)
 }
 
+func TestIngest_NonHttpSource(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inhooksConfigSvc := mocks.NewMockInhooksConfigService(ctrl)
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	app := handlers.NewApp(
+		handlers.WithLogger(logger),
+		handlers.WithInhooksConfigService(inhooksConfigSvc),
+	)
+	r := server.NewRouter(app)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	flow := &models.Flow{
+		ID:     "flow-1",
+		Source: &models.Source{ID: "source-1", Type: models.SourceTypeRedisStream},
+	}
+
+	// redis-stream sources do not accept http requests
+	inhooksConfigSvc.EXPECT().FindFlowForSource("my-source").Return(flow)
+	inhooksConfigSvc.EXPECT().GetUnknownSourceResponse().Return(nil)
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/api/v1/ingest/my-source", bytes.NewBufferString(`{"id": "abc"}`))
+	assert.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
 func TestIngest_MessageBuildFailed(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -171,3 +257,1070 @@ func TestIngest_MessageBuildFailed(t *testing.T) {
 
 	assert.Equal(t, "unable to read data", jsonErr.Error)
 }
+
+func TestIngest_IPNotAllowed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inhooksConfigSvc := mocks.NewMockInhooksConfigService(ctrl)
+	messageBuilder := mocks.NewMockMessageBuilder(ctrl)
+	ipAllowlistSvc := mocks.NewMockIPAllowlistService(ctrl)
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	app := handlers.NewApp(
+		handlers.WithLogger(logger),
+		handlers.WithInhooksConfigService(inhooksConfigSvc),
+		handlers.WithMessageBuilder(messageBuilder),
+		handlers.WithIPAllowlistService(ipAllowlistSvc),
+	)
+	r := server.NewRouter(app)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	flow := &models.Flow{
+		ID: "flow-id",
+		Source: &models.Source{
+			ID:           "source-id",
+			AllowedCIDRs: []string{"192.0.2.0/24"},
+		},
+	}
+	inhooksConfigSvc.EXPECT().FindFlowForSource("my-source").Return(flow)
+
+	clientIP := netip.MustParseAddr("127.0.0.1")
+	ipAllowlistSvc.EXPECT().ClientIP(gomock.AssignableToTypeOf(&http.Request{})).Return(clientIP, nil)
+	ipAllowlistSvc.EXPECT().IsAllowed(flow.Source, clientIP).Return(false)
+
+	buf := bytes.NewBufferString(`{"id": "abc"}`)
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/api/v1/ingest/my-source", buf)
+	assert.NoError(t, err)
+
+	cl := &http.Client{}
+	resp, err := cl.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	jsonErr := &handlers.JSONErr{}
+	err = json.NewDecoder(resp.Body).Decode(jsonErr)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "ip not allowed", jsonErr.Error)
+}
+
+func TestIngest_RateLimitExceeded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inhooksConfigSvc := mocks.NewMockInhooksConfigService(ctrl)
+	ingestLimiter := mocks.NewMockIngestLimiter(ctrl)
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	app := handlers.NewApp(
+		handlers.WithLogger(logger),
+		handlers.WithInhooksConfigService(inhooksConfigSvc),
+		handlers.WithIngestLimiter(ingestLimiter),
+	)
+	r := server.NewRouter(app)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	flow := &models.Flow{
+		ID: "flow-id",
+		Source: &models.Source{
+			ID: "source-id",
+			RateLimit: &models.RateLimit{
+				Requests: 10,
+				Interval: time.Second,
+			},
+		},
+	}
+	inhooksConfigSvc.EXPECT().FindFlowForSource("my-source").Return(flow)
+
+	ingestLimiter.EXPECT().CheckRateLimit(gomock.Any(), flow.Source).Return(false, 1500*time.Millisecond, nil)
+
+	buf := bytes.NewBufferString(`{"id": "abc"}`)
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/api/v1/ingest/my-source", buf)
+	assert.NoError(t, err)
+
+	cl := &http.Client{}
+	resp, err := cl.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "2", resp.Header.Get("Retry-After"))
+
+	jsonErr := &handlers.JSONErr{}
+	err = json.NewDecoder(resp.Body).Decode(jsonErr)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "rate limit exceeded", jsonErr.Error)
+}
+
+func TestIngest_MaxQueueDepthReached(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inhooksConfigSvc := mocks.NewMockInhooksConfigService(ctrl)
+	ingestLimiter := mocks.NewMockIngestLimiter(ctrl)
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	appConf := &lib.AppConfig{
+		Ingest: lib.IngestConfig{
+			BackpressureRetryAfter: 30 * time.Second,
+		},
+	}
+
+	app := handlers.NewApp(
+		handlers.WithLogger(logger),
+		handlers.WithAppConfig(appConf),
+		handlers.WithInhooksConfigService(inhooksConfigSvc),
+		handlers.WithIngestLimiter(ingestLimiter),
+	)
+	r := server.NewRouter(app)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	maxQueueDepth := 1000
+	flow := &models.Flow{
+		ID: "flow-id",
+		Source: &models.Source{
+			ID:            "source-id",
+			MaxQueueDepth: &maxQueueDepth,
+		},
+	}
+	inhooksConfigSvc.EXPECT().FindFlowForSource("my-source").Return(flow)
+
+	ingestLimiter.EXPECT().CheckQueueDepth(gomock.Any(), flow).Return(false, nil)
+
+	buf := bytes.NewBufferString(`{"id": "abc"}`)
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/api/v1/ingest/my-source", buf)
+	assert.NoError(t, err)
+
+	cl := &http.Client{}
+	resp, err := cl.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, "30", resp.Header.Get("Retry-After"))
+
+	jsonErr := &handlers.JSONErr{}
+	err = json.NewDecoder(resp.Body).Decode(jsonErr)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "service overloaded", jsonErr.Error)
+}
+
+func TestIngest_Challenge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inhooksConfigSvc := mocks.NewMockInhooksConfigService(ctrl)
+	challengeResponder := mocks.NewMockChallengeResponder(ctrl)
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	app := handlers.NewApp(
+		handlers.WithLogger(logger),
+		handlers.WithInhooksConfigService(inhooksConfigSvc),
+		handlers.WithChallengeResponder(challengeResponder),
+	)
+	r := server.NewRouter(app)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	flow := &models.Flow{
+		ID: "flow-id",
+		Source: &models.Source{
+			ID: "source-id",
+			Challenge: &models.Challenge{
+				ChallengeType: models.ChallengeTypeMeta,
+			},
+		},
+	}
+	inhooksConfigSvc.EXPECT().FindFlowForSource("my-source").Return(flow)
+
+	challengeResponder.EXPECT().Respond(flow, gomock.AssignableToTypeOf(&http.Request{})).Return(&models.ChallengeResponse{
+		ContentType: "text/plain",
+		Body:        []byte("1158201444"),
+	}, nil)
+
+	resp, err := http.Get(s.URL + "/api/v1/ingest/my-source?hub.mode=subscribe&hub.challenge=1158201444")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/plain", resp.Header.Get("Content-Type"))
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "1158201444", string(body))
+}
+
+func TestIngest_GetWithoutChallenge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inhooksConfigSvc := mocks.NewMockInhooksConfigService(ctrl)
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	app := handlers.NewApp(
+		handlers.WithLogger(logger),
+		handlers.WithInhooksConfigService(inhooksConfigSvc),
+	)
+	r := server.NewRouter(app)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	flow := &models.Flow{
+		ID: "flow-id",
+		Source: &models.Source{
+			ID: "source-id",
+		},
+	}
+	inhooksConfigSvc.EXPECT().FindFlowForSource("my-source").Return(flow)
+
+	resp, err := http.Get(s.URL + "/api/v1/ingest/my-source")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+	jsonErr := &handlers.JSONErr{}
+	err = json.NewDecoder(resp.Body).Decode(jsonErr)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "method not allowed", jsonErr.Error)
+}
+
+func TestIngest_CustomResponses(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inhooksConfigSvc := mocks.NewMockInhooksConfigService(ctrl)
+	messageBuilder := mocks.NewMockMessageBuilder(ctrl)
+	messageEnqueuer := mocks.NewMockMessageEnqueuer(ctrl)
+	messageVerifier := mocks.NewMockMessageVerifier(ctrl)
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	app := handlers.NewApp(
+		handlers.WithLogger(logger),
+		handlers.WithInhooksConfigService(inhooksConfigSvc),
+		handlers.WithMessageBuilder(messageBuilder),
+		handlers.WithMessageEnqueuer(messageEnqueuer),
+		handlers.WithMessageVerifier(messageVerifier),
+	)
+	r := server.NewRouter(app)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	ackResponse := &models.IngestResponse{
+		StatusCode:  http.StatusAccepted,
+		ContentType: "application/xml",
+		Body:        `<ack id="{{.ReqID}}" source="{{.SourceSlug}}"/>`,
+		Headers:     map[string]string{"X-Ack": "1"},
+	}
+	assert.NoError(t, ackResponse.Compile())
+
+	flow := &models.Flow{
+		ID: "flow-1",
+		Source: &models.Source{
+			SuccessResponse: ackResponse,
+			FailureResponse: ackResponse,
+		},
+	}
+
+	// success
+	inhooksConfigSvc.EXPECT().FindFlowForSource("my-source").Return(flow)
+
+	messages := []*models.Message{{ID: "107f942d-f693-45f4-83e6-9a67197bdfe9"}}
+	messageBuilder.EXPECT().FromHttp(flow, gomock.Any(), gomock.Any()).Return(messages, nil)
+	messageVerifier.EXPECT().Verify(flow, messages[0]).Return(nil)
+	messageVerifier.EXPECT().StripCredentials(flow, messages[0])
+	messageEnqueuer.EXPECT().Enqueue(gomock.Any(), messages).Return([]*models.QueuedInfo{{MessageID: messages[0].ID, QueueStatus: models.QueueStatusReady}}, nil)
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/api/v1/ingest/my-source", bytes.NewBufferString(`{"id": "abc"}`))
+	assert.NoError(t, err)
+	req.Header.Set("X-Request-Id", "req-1")
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, "application/xml", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "1", resp.Header.Get("X-Ack"))
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, `<ack id="req-1" source="my-source"/>`, string(body))
+
+	// failure, same response
+	inhooksConfigSvc.EXPECT().FindFlowForSource("my-source").Return(flow)
+	messageBuilder.EXPECT().FromHttp(flow, gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("failed to build message"))
+
+	req, err = http.NewRequest(http.MethodPost, s.URL+"/api/v1/ingest/my-source", bytes.NewBufferString(`{"id": "abc"}`))
+	assert.NoError(t, err)
+	req.Header.Set("X-Request-Id", "req-1")
+
+	resp2, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp2.Body.Close()
+
+	assert.Equal(t, http.StatusAccepted, resp2.StatusCode)
+	body2, err := io.ReadAll(resp2.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, body, body2)
+
+	// unknown source, same response
+	inhooksConfigSvc.EXPECT().FindFlowForSource("my-source").Return(nil)
+	inhooksConfigSvc.EXPECT().GetUnknownSourceResponse().Return(ackResponse)
+
+	req, err = http.NewRequest(http.MethodPost, s.URL+"/api/v1/ingest/my-source", bytes.NewBufferString(`{"id": "abc"}`))
+	assert.NoError(t, err)
+	req.Header.Set("X-Request-Id", "req-1")
+
+	resp3, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp3.Body.Close()
+
+	assert.Equal(t, http.StatusAccepted, resp3.StatusCode)
+	body3, err := io.ReadAll(resp3.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, body, body3)
+}
+
+func TestIngest_BodyTooLarge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inhooksConfigSvc := mocks.NewMockInhooksConfigService(ctrl)
+	messageBuilder := mocks.NewMockMessageBuilder(ctrl)
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	appConf := &lib.AppConfig{
+		Ingest: lib.IngestConfig{
+			MaxBodyBytes: 1024,
+		},
+	}
+
+	app := handlers.NewApp(
+		handlers.WithLogger(logger),
+		handlers.WithAppConfig(appConf),
+		handlers.WithInhooksConfigService(inhooksConfigSvc),
+		handlers.WithMessageBuilder(messageBuilder),
+	)
+	r := server.NewRouter(app)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	maxBodyBytes := int64(10)
+	flow := &models.Flow{
+		ID: "flow-id",
+		Source: &models.Source{
+			ID:           "source-id",
+			MaxBodyBytes: &maxBodyBytes,
+		},
+	}
+
+	// content length above the limit
+	inhooksConfigSvc.EXPECT().FindFlowForSource("my-source").Return(flow)
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/api/v1/ingest/my-source", bytes.NewBufferString(`{"id": "abc"}`))
+	assert.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+
+	jsonErr := &handlers.JSONErr{}
+	err = json.NewDecoder(resp.Body).Decode(jsonErr)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "request body too large", jsonErr.Error)
+
+	// chunked body above the limit
+	inhooksConfigSvc.EXPECT().FindFlowForSource("my-source").Return(flow)
+	messageBuilder.EXPECT().FromHttp(flow, gomock.Any(), gomock.Any()).DoAndReturn(func(flow *models.Flow, r *http.Request, reqID string) ([]*models.Message, error) {
+		_, err := io.ReadAll(r.Body)
+		return nil, err
+	})
+
+	req, err = http.NewRequest(http.MethodPost, s.URL+"/api/v1/ingest/my-source", io.MultiReader(bytes.NewBufferString(`{"id": "abc"}`)))
+	assert.NoError(t, err)
+
+	resp2, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp2.Body.Close()
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp2.StatusCode)
+}
+
+func TestIngest_UnsupportedContentType(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inhooksConfigSvc := mocks.NewMockInhooksConfigService(ctrl)
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	app := handlers.NewApp(
+		handlers.WithLogger(logger),
+		handlers.WithInhooksConfigService(inhooksConfigSvc),
+	)
+	r := server.NewRouter(app)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	flow := &models.Flow{
+		ID: "flow-id",
+		Source: &models.Source{
+			ID:                  "source-id",
+			AllowedContentTypes: []string{"application/json"},
+		},
+	}
+	inhooksConfigSvc.EXPECT().FindFlowForSource("my-source").Return(flow)
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/api/v1/ingest/my-source", bytes.NewBufferString(`id=abc`))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+
+	jsonErr := &handlers.JSONErr{}
+	err = json.NewDecoder(resp.Body).Decode(jsonErr)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "unsupported content type", jsonErr.Error)
+}
+
+func TestIngest_UnsupportedContentEncoding(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inhooksConfigSvc := mocks.NewMockInhooksConfigService(ctrl)
+	messageBuilder := mocks.NewMockMessageBuilder(ctrl)
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	app := handlers.NewApp(
+		handlers.WithLogger(logger),
+		handlers.WithInhooksConfigService(inhooksConfigSvc),
+		handlers.WithMessageBuilder(messageBuilder),
+	)
+	r := server.NewRouter(app)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	flow := &models.Flow{
+		ID: "flow-id",
+		Source: &models.Source{
+			ID:            "source-id",
+			Decompression: &models.Decompression{},
+		},
+	}
+	inhooksConfigSvc.EXPECT().FindFlowForSource("my-source").Return(flow)
+	messageBuilder.EXPECT().FromHttp(flow, gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("content encoding br: %w", services.ErrUnsupportedContentEncoding))
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/api/v1/ingest/my-source", bytes.NewBufferString(`compressed`))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Encoding", "br")
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+
+	jsonErr := &handlers.JSONErr{}
+	err = json.NewDecoder(resp.Body).Decode(jsonErr)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "unsupported content encoding", jsonErr.Error)
+}
+
+func TestIngest_DuplicateDropped(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inhooksConfigSvc := mocks.NewMockInhooksConfigService(ctrl)
+	messageBuilder := mocks.NewMockMessageBuilder(ctrl)
+	messageEnqueuer := mocks.NewMockMessageEnqueuer(ctrl)
+	messageVerifier := mocks.NewMockMessageVerifier(ctrl)
+	deduplicator := mocks.NewMockMessageDeduplicator(ctrl)
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	app := handlers.NewApp(
+		handlers.WithLogger(logger),
+		handlers.WithInhooksConfigService(inhooksConfigSvc),
+		handlers.WithMessageBuilder(messageBuilder),
+		handlers.WithMessageEnqueuer(messageEnqueuer),
+		handlers.WithMessageVerifier(messageVerifier),
+		handlers.WithMessageDeduplicator(deduplicator),
+	)
+	r := server.NewRouter(app)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	flow := &models.Flow{
+		ID: "flow-id",
+		Source: &models.Source{
+			ID: "source-id",
+			Dedupe: &models.Dedupe{
+				Header: "X-GitHub-Delivery",
+			},
+		},
+	}
+	inhooksConfigSvc.EXPECT().FindFlowForSource("my-source").Return(flow)
+
+	messages := []*models.Message{{ID: "107f942d-f693-45f4-83e6-9a67197bdfe9"}}
+	messageBuilder.EXPECT().FromHttp(flow, gomock.Any(), gomock.Any()).Return(messages, nil)
+	messageVerifier.EXPECT().Verify(flow, messages[0]).Return(nil)
+	messageVerifier.EXPECT().StripCredentials(flow, messages[0])
+	deduplicator.EXPECT().IdempotencyKey(flow, messages[0]).Return("delivery-1", nil)
+	deduplicator.EXPECT().Claim(gomock.Any(), flow, "delivery-1", gomock.Any()).Return(false, nil)
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/api/v1/ingest/my-source", bytes.NewBufferString(`{"id": "abc"}`))
+	assert.NoError(t, err)
+	req.Header.Set("X-GitHub-Delivery", "delivery-1")
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestIngest_DedupeReleasedOnEnqueueFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inhooksConfigSvc := mocks.NewMockInhooksConfigService(ctrl)
+	messageBuilder := mocks.NewMockMessageBuilder(ctrl)
+	messageEnqueuer := mocks.NewMockMessageEnqueuer(ctrl)
+	messageVerifier := mocks.NewMockMessageVerifier(ctrl)
+	deduplicator := mocks.NewMockMessageDeduplicator(ctrl)
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	app := handlers.NewApp(
+		handlers.WithLogger(logger),
+		handlers.WithInhooksConfigService(inhooksConfigSvc),
+		handlers.WithMessageBuilder(messageBuilder),
+		handlers.WithMessageEnqueuer(messageEnqueuer),
+		handlers.WithMessageVerifier(messageVerifier),
+		handlers.WithMessageDeduplicator(deduplicator),
+	)
+	r := server.NewRouter(app)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	flow := &models.Flow{
+		ID: "flow-id",
+		Source: &models.Source{
+			ID: "source-id",
+			Dedupe: &models.Dedupe{
+				Header: "X-GitHub-Delivery",
+			},
+		},
+	}
+	inhooksConfigSvc.EXPECT().FindFlowForSource("my-source").Return(flow)
+
+	messages := []*models.Message{{ID: "107f942d-f693-45f4-83e6-9a67197bdfe9"}}
+	messageBuilder.EXPECT().FromHttp(flow, gomock.Any(), gomock.Any()).Return(messages, nil)
+	messageVerifier.EXPECT().Verify(flow, messages[0]).Return(nil)
+	messageVerifier.EXPECT().StripCredentials(flow, messages[0])
+	deduplicator.EXPECT().IdempotencyKey(flow, messages[0]).Return("delivery-1", nil)
+	deduplicator.EXPECT().Claim(gomock.Any(), flow, "delivery-1", gomock.Any()).Return(true, nil)
+	messageEnqueuer.EXPECT().Enqueue(gomock.Any(), messages).Return(nil, fmt.Errorf("redis down"))
+	deduplicator.EXPECT().Release(gomock.Any(), flow, "delivery-1").Return(nil)
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/api/v1/ingest/my-source", bytes.NewBufferString(`{"id": "abc"}`))
+	assert.NoError(t, err)
+	req.Header.Set("X-GitHub-Delivery", "delivery-1")
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestIngest_DedupeSplitPayload(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inhooksConfigSvc := mocks.NewMockInhooksConfigService(ctrl)
+	messageBuilder := mocks.NewMockMessageBuilder(ctrl)
+	messageEnqueuer := mocks.NewMockMessageEnqueuer(ctrl)
+	messageVerifier := mocks.NewMockMessageVerifier(ctrl)
+	deduplicator := mocks.NewMockMessageDeduplicator(ctrl)
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	app := handlers.NewApp(
+		handlers.WithLogger(logger),
+		handlers.WithInhooksConfigService(inhooksConfigSvc),
+		handlers.WithMessageBuilder(messageBuilder),
+		handlers.WithMessageEnqueuer(messageEnqueuer),
+		handlers.WithMessageVerifier(messageVerifier),
+		handlers.WithMessageDeduplicator(deduplicator),
+	)
+	r := server.NewRouter(app)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	flow := &models.Flow{
+		ID: "flow-id",
+		Source: &models.Source{
+			ID:     "source-id",
+			Split:  &models.PayloadSplit{JSONPath: "$.events"},
+			Dedupe: &models.Dedupe{JSONPath: "$.batchId"},
+		},
+	}
+	assert.NoError(t, flow.Source.Split.Compile())
+
+	body := `{"batchId": "batch-1", "events": [{"id": "a"}, {"id": "b"}]}`
+	index0, index1 := 0, 1
+	messages := []*models.Message{
+		{ID: "m-1", IngestID: "i-1", SplitIndex: &index0, Payload: []byte(`{"id": "a"}`)},
+		{ID: "m-2", IngestID: "i-2", SplitIndex: &index1, Payload: []byte(`{"id": "b"}`)},
+	}
+
+	inhooksConfigSvc.EXPECT().FindFlowForSource("my-source").Return(flow)
+	messageBuilder.EXPECT().FromHttp(flow, gomock.Any(), gomock.Any()).DoAndReturn(func(flow *models.Flow, r *http.Request, reqID string) ([]*models.Message, error) {
+		// the body is read by the message builder
+		_, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		return messages, nil
+	})
+	messageVerifier.EXPECT().Verify(flow, gomock.Any()).Return(nil)
+	messageVerifier.EXPECT().StripCredentials(flow, gomock.Any()).Times(2)
+	// the idempotency key is read from the original request body
+	deduplicator.EXPECT().IdempotencyKey(flow, gomock.Any()).DoAndReturn(func(flow *models.Flow, m *models.Message) (string, error) {
+		assert.Equal(t, []byte(body), m.Payload)
+		return "batch-1", nil
+	})
+	deduplicator.EXPECT().Claim(gomock.Any(), flow, "batch-1", gomock.Any()).Return(false, nil)
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/api/v1/ingest/my-source", bytes.NewBufferString(body))
+	assert.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestIngest_Sync(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inhooksConfigSvc := mocks.NewMockInhooksConfigService(ctrl)
+	messageBuilder := mocks.NewMockMessageBuilder(ctrl)
+	messageEnqueuer := mocks.NewMockMessageEnqueuer(ctrl)
+	messageVerifier := mocks.NewMockMessageVerifier(ctrl)
+	syncDeliverySvc := mocks.NewMockSyncDeliveryService(ctrl)
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	app := handlers.NewApp(
+		handlers.WithLogger(logger),
+		handlers.WithInhooksConfigService(inhooksConfigSvc),
+		handlers.WithMessageBuilder(messageBuilder),
+		handlers.WithMessageEnqueuer(messageEnqueuer),
+		handlers.WithMessageVerifier(messageVerifier),
+		handlers.WithSyncDeliveryService(syncDeliverySvc),
+	)
+	r := server.NewRouter(app)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	timeout := 2 * time.Second
+	flow := &models.Flow{
+		ID: "flow-id",
+		Source: &models.Source{
+			ID:   "source-id",
+			Sync: &models.SyncDelivery{SinkID: "sink-2", Timeout: &timeout},
+		},
+		Sinks: []*models.Sink{{ID: "sink-1"}, {ID: "sink-2"}},
+	}
+
+	messages := []*models.Message{
+		{ID: "107f942d-f693-45f4-83e6-9a67197bdfe9", SinkID: "sink-1"},
+		{ID: "cdd3b72a-97b2-447e-b88d-4ae9e43f80a2", SinkID: "sink-2"},
+	}
+
+	sendRequest := func(deliver *gomock.Call) *http.Response {
+		inhooksConfigSvc.EXPECT().FindFlowForSource("my-source").Return(flow)
+		messageBuilder.EXPECT().FromHttp(flow, gomock.Any(), gomock.Any()).Return(messages, nil)
+		messageVerifier.EXPECT().Verify(flow, messages[0]).Return(nil)
+		messageVerifier.EXPECT().StripCredentials(flow, gomock.Any()).Times(2)
+		enqueue := messageEnqueuer.EXPECT().Enqueue(gomock.Any(), messages).DoAndReturn(func(ctx context.Context, messages []*models.Message) ([]*models.QueuedInfo, error) {
+			// the sync sink message is kept out of the ready queue
+			assert.False(t, messages[0].DeliverInline)
+			assert.True(t, messages[1].DeliverInline)
+			return []*models.QueuedInfo{
+				{MessageID: messages[0].ID, QueueStatus: models.QueueStatusReady},
+				{MessageID: messages[1].ID, QueueStatus: models.QueueStatusProcessing},
+			}, nil
+		})
+		// the message is delivered once enqueued
+		deliver.After(enqueue)
+
+		req, err := http.NewRequest(http.MethodPost, s.URL+"/api/v1/ingest/my-source", bytes.NewBufferString(`{"id": "abc"}`))
+		assert.NoError(t, err)
+
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		return resp
+	}
+
+	// the sync sink response is relayed to the sender
+	deliver := syncDeliverySvc.EXPECT().Deliver(gomock.Any(), flow.Sinks[1], messages[1]).DoAndReturn(func(ctx context.Context, sink *models.Sink, m *models.Message) (*models.SinkResponse, error) {
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(timeout), deadline, time.Second)
+
+		return &models.SinkResponse{
+			StatusCode: http.StatusAccepted,
+			Headers:    http.Header{"Content-Type": []string{"application/json"}, "X-Sink": []string{"sink-2"}, "Content-Length": []string{"100"}},
+			Body:       []byte(`{"text":"done"}`),
+		}, nil
+	})
+
+	resp := sendRequest(deliver)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "sink-2", resp.Header.Get("X-Sink"))
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"text":"done"}`, string(body))
+
+	// the source success response is sent when the sync delivery fails
+	deliver = syncDeliverySvc.EXPECT().Deliver(gomock.Any(), flow.Sinks[1], messages[1]).Return(nil, fmt.Errorf("failed to send http request: connection refused"))
+
+	resp = sendRequest(deliver)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	jsonOK := &handlers.JSONOK{}
+	err = json.NewDecoder(resp.Body).Decode(jsonOK)
+	assert.NoError(t, err)
+
+	// the message is not delivered when the enqueue fails, so that the sender retry does not deliver it twice
+	inhooksConfigSvc.EXPECT().FindFlowForSource("my-source").Return(flow)
+	messageBuilder.EXPECT().FromHttp(flow, gomock.Any(), gomock.Any()).Return(messages, nil)
+	messageVerifier.EXPECT().Verify(flow, messages[0]).Return(nil)
+	messageVerifier.EXPECT().StripCredentials(flow, gomock.Any()).Times(2)
+	messageEnqueuer.EXPECT().Enqueue(gomock.Any(), messages).Return(nil, fmt.Errorf("redis down"))
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/api/v1/ingest/my-source", bytes.NewBufferString(`{"id": "abc"}`))
+	assert.NoError(t, err)
+
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestIngest_SchemaViolation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inhooksConfigSvc := mocks.NewMockInhooksConfigService(ctrl)
+	messageBuilder := mocks.NewMockMessageBuilder(ctrl)
+	messageEnqueuer := mocks.NewMockMessageEnqueuer(ctrl)
+	messageVerifier := mocks.NewMockMessageVerifier(ctrl)
+	payloadValidator := mocks.NewMockPayloadValidator(ctrl)
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	app := handlers.NewApp(
+		handlers.WithLogger(logger),
+		handlers.WithInhooksConfigService(inhooksConfigSvc),
+		handlers.WithMessageBuilder(messageBuilder),
+		handlers.WithMessageEnqueuer(messageEnqueuer),
+		handlers.WithMessageVerifier(messageVerifier),
+		handlers.WithPayloadValidator(payloadValidator),
+	)
+	r := server.NewRouter(app)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	flow := &models.Flow{
+		ID: "flow-id",
+		Source: &models.Source{
+			ID:     "source-id",
+			Schema: &models.PayloadSchema{Mode: models.SchemaModeReject},
+		},
+	}
+
+	messages := []*models.Message{{ID: "107f942d-f693-45f4-83e6-9a67197bdfe9"}}
+	validationErr := &services.SchemaValidationError{Violations: []string{"/id: got number, want string"}}
+
+	sendRequest := func() *http.Response {
+		inhooksConfigSvc.EXPECT().FindFlowForSource("my-source").Return(flow)
+		messageBuilder.EXPECT().FromHttp(flow, gomock.Any(), gomock.Any()).Return(messages, nil)
+		messageVerifier.EXPECT().Verify(flow, messages[0]).Return(nil)
+		payloadValidator.EXPECT().Validate(flow.Source, messages[0]).Return(validationErr)
+
+		req, err := http.NewRequest(http.MethodPost, s.URL+"/api/v1/ingest/my-source", bytes.NewBufferString(`{"id": 123}`))
+		assert.NoError(t, err)
+
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		return resp
+	}
+
+	// the request is rejected with the validation errors
+	resp := sendRequest()
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	jsonErr := &handlers.JSONErr{}
+	err = json.NewDecoder(resp.Body).Decode(jsonErr)
+	assert.NoError(t, err)
+	assert.Equal(t, "payload does not match schema", jsonErr.Error)
+	assert.Equal(t, []string{"/id: got number, want string"}, jsonErr.ValidationErrors)
+
+	// the request is processed in warn mode
+	flow.Source.Schema.Mode = models.SchemaModeWarn
+	messageVerifier.EXPECT().StripCredentials(flow, messages[0])
+	messageEnqueuer.EXPECT().Enqueue(gomock.Any(), messages).Return([]*models.QueuedInfo{{MessageID: messages[0].ID, QueueStatus: models.QueueStatusReady}}, nil)
+
+	resp = sendRequest()
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestIngest_NoSinkMatched(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inhooksConfigSvc := mocks.NewMockInhooksConfigService(ctrl)
+	messageBuilder := mocks.NewMockMessageBuilder(ctrl)
+	messageEnqueuer := mocks.NewMockMessageEnqueuer(ctrl)
+	messageVerifier := mocks.NewMockMessageVerifier(ctrl)
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	app := handlers.NewApp(
+		handlers.WithLogger(logger),
+		handlers.WithInhooksConfigService(inhooksConfigSvc),
+		handlers.WithMessageBuilder(messageBuilder),
+		handlers.WithMessageEnqueuer(messageEnqueuer),
+		handlers.WithMessageVerifier(messageVerifier),
+	)
+	r := server.NewRouter(app)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	flow := &models.Flow{
+		ID:     "flow-1",
+		Source: &models.Source{ID: "source-1"},
+		Sinks:  []*models.Sink{{ID: "sink-1"}},
+	}
+
+	sendRequest := func() *http.Response {
+		inhooksConfigSvc.EXPECT().FindFlowForSource("my-source").Return(flow)
+		messageBuilder.EXPECT().FromHttp(flow, gomock.Any(), gomock.Any()).DoAndReturn(func(flow *models.Flow, r *http.Request, reqID string) ([]*models.Message, error) {
+			// the body is read by the message builder
+			_, err := io.ReadAll(r.Body)
+			assert.NoError(t, err)
+			return []*models.Message{}, nil
+		})
+
+		req, err := http.NewRequest(http.MethodPost, s.URL+"/api/v1/ingest/my-source", bytes.NewBufferString(`{"action": "closed"}`))
+		assert.NoError(t, err)
+
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		return resp
+	}
+
+	// the request is verified, nothing is enqueued
+	messageVerifier.EXPECT().Verify(flow, gomock.Any()).DoAndReturn(func(flow *models.Flow, m *models.Message) error {
+		assert.Equal(t, []byte(`{"action": "closed"}`), m.Payload)
+		return nil
+	})
+
+	resp := sendRequest()
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// requests failing verification are rejected even if no sink matched
+	messageVerifier.EXPECT().Verify(flow, gomock.Any()).Return(fmt.Errorf("invalid signature"))
+
+	resp = sendRequest()
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestIngest_SplitPayload(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inhooksConfigSvc := mocks.NewMockInhooksConfigService(ctrl)
+	messageBuilder := mocks.NewMockMessageBuilder(ctrl)
+	messageEnqueuer := mocks.NewMockMessageEnqueuer(ctrl)
+	messageVerifier := mocks.NewMockMessageVerifier(ctrl)
+	payloadValidator := mocks.NewMockPayloadValidator(ctrl)
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	app := handlers.NewApp(
+		handlers.WithLogger(logger),
+		handlers.WithInhooksConfigService(inhooksConfigSvc),
+		handlers.WithMessageBuilder(messageBuilder),
+		handlers.WithMessageEnqueuer(messageEnqueuer),
+		handlers.WithMessageVerifier(messageVerifier),
+		handlers.WithPayloadValidator(payloadValidator),
+	)
+	r := server.NewRouter(app)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	flow := &models.Flow{
+		ID: "flow-id",
+		Source: &models.Source{
+			ID:     "source-id",
+			Split:  &models.PayloadSplit{JSONPath: "$"},
+			Schema: &models.PayloadSchema{Mode: models.SchemaModeReject},
+		},
+	}
+	assert.NoError(t, flow.Source.Split.Compile())
+
+	sendRequest := func() *http.Response {
+		req, err := http.NewRequest(http.MethodPost, s.URL+"/api/v1/ingest/my-source", bytes.NewBufferString(`[{"id": "a"}, {"id": 2}]`))
+		assert.NoError(t, err)
+
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		return resp
+	}
+
+	// invalid split payloads are rejected
+	inhooksConfigSvc.EXPECT().FindFlowForSource("my-source").Return(flow)
+	messageBuilder.EXPECT().FromHttp(flow, gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("value at $ is not an array: %w", services.ErrInvalidSplitPayload))
+
+	resp := sendRequest()
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	jsonErr := &handlers.JSONErr{}
+	err = json.NewDecoder(resp.Body).Decode(jsonErr)
+	assert.NoError(t, err)
+	assert.Equal(t, "unable to split payload", jsonErr.Error)
+
+	// the whole request is verified, each element is validated and the violations reference the invalid element
+	index0, index1 := 0, 1
+	messages := []*models.Message{
+		{ID: "m-1", IngestID: "i-1", SplitIndex: &index0, Payload: []byte(`{"id": "a"}`)},
+		{ID: "m-2", IngestID: "i-2", SplitIndex: &index1, Payload: []byte(`{"id": 2}`)},
+	}
+
+	inhooksConfigSvc.EXPECT().FindFlowForSource("my-source").Return(flow)
+	messageBuilder.EXPECT().FromHttp(flow, gomock.Any(), gomock.Any()).DoAndReturn(func(flow *models.Flow, r *http.Request, reqID string) ([]*models.Message, error) {
+		// the body is read by the message builder
+		_, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		return messages, nil
+	})
+	messageVerifier.EXPECT().Verify(flow, gomock.Any()).DoAndReturn(func(flow *models.Flow, m *models.Message) error {
+		assert.Equal(t, []byte(`[{"id": "a"}, {"id": 2}]`), m.Payload)
+		return nil
+	})
+	payloadValidator.EXPECT().Validate(flow.Source, gomock.Any()).DoAndReturn(func(source *models.Source, m *models.Message) error {
+		assert.Equal(t, []byte(`{"id": "a"}`), m.Payload)
+		return nil
+	})
+	payloadValidator.EXPECT().Validate(flow.Source, gomock.Any()).DoAndReturn(func(source *models.Source, m *models.Message) error {
+		assert.Equal(t, []byte(`{"id": 2}`), m.Payload)
+		return &services.SchemaValidationError{Violations: []string{"/id: got number, want string"}}
+	})
+
+	resp = sendRequest()
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	jsonErr = &handlers.JSONErr{}
+	err = json.NewDecoder(resp.Body).Decode(jsonErr)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"element 1: /id: got number, want string"}, jsonErr.ValidationErrors)
+}
+
+func TestIngest_ClientIP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inhooksConfigSvc := mocks.NewMockInhooksConfigService(ctrl)
+	messageBuilder := mocks.NewMockMessageBuilder(ctrl)
+	messageEnqueuer := mocks.NewMockMessageEnqueuer(ctrl)
+	messageVerifier := mocks.NewMockMessageVerifier(ctrl)
+	ipAllowlistSvc := mocks.NewMockIPAllowlistService(ctrl)
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	app := handlers.NewApp(
+		handlers.WithLogger(logger),
+		handlers.WithInhooksConfigService(inhooksConfigSvc),
+		handlers.WithMessageBuilder(messageBuilder),
+		handlers.WithMessageEnqueuer(messageEnqueuer),
+		handlers.WithMessageVerifier(messageVerifier),
+		handlers.WithIPAllowlistService(ipAllowlistSvc),
+	)
+	r := server.NewRouter(app)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	flow := &models.Flow{
+		ID:     "flow-1",
+		Source: &models.Source{},
+	}
+
+	messages := []*models.Message{{ID: "m-1"}, {ID: "m-2"}}
+
+	inhooksConfigSvc.EXPECT().FindFlowForSource("my-source").Return(flow)
+	messageBuilder.EXPECT().FromHttp(flow, gomock.Any(), gomock.Any()).Return(messages, nil)
+	ipAllowlistSvc.EXPECT().ClientIP(gomock.Any()).Return(netip.MustParseAddr("140.82.115.10"), nil)
+	messageVerifier.EXPECT().Verify(flow, messages[0]).Return(nil)
+	messageVerifier.EXPECT().StripCredentials(flow, gomock.Any()).Times(2)
+	messageEnqueuer.EXPECT().Enqueue(gomock.Any(), messages).Return([]*models.QueuedInfo{}, nil)
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/api/v1/ingest/my-source", bytes.NewBufferString(`{"id": "abc"}`))
+	assert.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	// the client ip is recorded on the messages
+	assert.Equal(t, "140.82.115.10", messages[0].ClientIP)
+	assert.Equal(t, "140.82.115.10", messages[1].ClientIP)
+}
@@ -13,6 +13,7 @@ import (
 type TransformRequest struct {
 	Body                string                      `json:"body"`
 	Headers             map[string][]string         `json:"headers"`
+	Metadata            *models.RequestMetadata     `json:"metadata"`
 	TransformDefinition *models.TransformDefinition `json:"transformDefinition"`
 }
 
@@ -46,6 +47,9 @@ func (app *App) HandleTransform(w http.ResponseWriter, r *http.Request) {
 		Payload:     []byte(transformRequest.Body),
 		HttpHeaders: transformRequest.Headers,
 	}
+	if transformRequest.Metadata != nil {
+		m.SetRequestMetadata(transformRequest.Metadata)
+	}
 
 	err = app.messageTransformer.Transform(ctx, transformRequest.TransformDefinition, m)
 	if err != nil {
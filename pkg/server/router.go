@@ -11,7 +11,11 @@ func NewRouter(app *handlers.App) *chi.Mux {
 	r.Use(middleware.RequestID)
 	r.Use(middleware.Recoverer)
 	r.Route("/api/v1", func(r chi.Router) {
-		r.Post("/ingest/{sourceSlug}", app.HandleIngest)
+		// the accepted methods are checked per source
+		r.HandleFunc("/ingest/{sourceSlug}", app.HandleIngest)
+		r.HandleFunc("/ingest/{sourceSlug}/*", app.HandleIngest)
+
+		r.Get("/sources/{sourceSlug}/captures", app.HandleListCaptures)
 
 		r.Post("/transform", app.HandleTransform)
 		r.Get("/metrics", app.HandleMetrics)
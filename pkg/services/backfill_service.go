@@ -0,0 +1,106 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/pkg/errors"
+	"golang.org/x/exp/slices"
+)
+
+type BackfillService interface {
+	// Backfill enqueues a fresh message in one sink of the flow for each request of the source ingest log received between from and to.
+	// Returns the number of enqueued messages.
+	Backfill(ctx context.Context, flow *models.Flow, sinkID string, from time.Time, to time.Time) (int, error)
+}
+
+type backfillService struct {
+	ingestLogSvc    IngestLogService
+	messageBuilder  MessageBuilder
+	messageVerifier MessageVerifier
+	messageEnqueuer MessageEnqueuer
+}
+
+func NewBackfillService(ingestLogSvc IngestLogService, messageBuilder MessageBuilder, messageVerifier MessageVerifier, messageEnqueuer MessageEnqueuer) BackfillService {
+	return &backfillService{
+		ingestLogSvc:    ingestLogSvc,
+		messageBuilder:  messageBuilder,
+		messageVerifier: messageVerifier,
+		messageEnqueuer: messageEnqueuer,
+	}
+}
+
+func (s *backfillService) Backfill(ctx context.Context, flow *models.Flow, sinkID string, from time.Time, to time.Time) (int, error) {
+	if flow.Source.IngestLog == nil {
+		return 0, fmt.Errorf("ingest log not enabled for source %s", flow.Source.ID)
+	}
+
+	sinkIdx := slices.IndexFunc(flow.Sinks, func(sink *models.Sink) bool { return sink.ID == sinkID })
+	if sinkIdx == -1 {
+		return 0, fmt.Errorf("sink not found: %s", sinkID)
+	}
+
+	// the messages are only built for the backfilled sink
+	sinkFlow := &models.Flow{
+		ID:     flow.ID,
+		Source: flow.Source,
+		Sinks:  []*models.Sink{flow.Sinks[sinkIdx]},
+	}
+
+	count := 0
+	err := s.ingestLogSvc.Scan(ctx, flow, from, to, func(entries []*models.IngestLogEntry) error {
+		messages := []*models.Message{}
+		for _, entry := range entries {
+			entryMessages, err := s.buildMessages(ctx, sinkFlow, entry)
+			if err != nil {
+				return errors.Wrapf(err, "failed to build messages for ingested request %s", entry.IngestedReqID)
+			}
+			messages = append(messages, entryMessages...)
+		}
+
+		if len(messages) == 0 {
+			return nil
+		}
+
+		_, err := s.messageEnqueuer.Enqueue(ctx, messages)
+		if err != nil {
+			return errors.Wrapf(err, "failed to enqueue messages")
+		}
+		count += len(messages)
+
+		return nil
+	})
+
+	return count, err
+}
+
+// buildMessages builds the messages of a logged request, as they were built when the request was ingested
+func (s *backfillService) buildMessages(ctx context.Context, flow *models.Flow, entry *models.IngestLogEntry) ([]*models.Message, error) {
+	r, err := http.NewRequestWithContext(ctx, entry.HttpMethod, "/", bytes.NewReader(entry.Body))
+	if err != nil {
+		return nil, err
+	}
+	r.Header = entry.HttpHeaders
+	r.URL.RawQuery = entry.RawQuery
+
+	messages, err := s.messageBuilder.FromHttp(flow, r, entry.IngestedReqID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range messages {
+		s.messageVerifier.StripCredentials(flow, m)
+
+		m.ReceivedAt = entry.ReceivedAt
+		m.HttpPath = entry.HttpPath
+		m.PathSuffix = entry.PathSuffix
+		m.ClientIP = entry.ClientIP
+		m.TLSVersion = entry.TLSVersion
+	}
+
+	return messages, nil
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/didil/inhooks/pkg/testsupport/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackfillService(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ingestLogSvc := mocks.NewMockIngestLogService(ctrl)
+	messageBuilder := mocks.NewMockMessageBuilder(ctrl)
+	messageVerifier := mocks.NewMockMessageVerifier(ctrl)
+	messageEnqueuer := mocks.NewMockMessageEnqueuer(ctrl)
+
+	s := NewBackfillService(ingestLogSvc, messageBuilder, messageVerifier, messageEnqueuer)
+
+	sink1 := &models.Sink{ID: "sink-1"}
+	sink2 := &models.Sink{ID: "sink-2"}
+	flow := &models.Flow{
+		ID:     "flow-1",
+		Source: &models.Source{ID: "source-1", IngestLog: &models.IngestLog{}},
+		Sinks:  []*models.Sink{sink1, sink2},
+	}
+
+	from := time.Date(2023, 05, 5, 8, 0, 0, 0, time.UTC)
+	to := time.Date(2023, 05, 5, 9, 0, 0, 0, time.UTC)
+	receivedAt := time.Date(2023, 05, 5, 8, 9, 12, 0, time.UTC)
+
+	entry := &models.IngestLogEntry{
+		IngestedReqID: "req-1",
+		ReceivedAt:    receivedAt,
+		HttpMethod:    http.MethodPost,
+		HttpHeaders:   http.Header{"X-Signature": []string{"abc"}},
+		RawQuery:      "x=1",
+		PathSuffix:    "events",
+		HttpPath:      "/api/v1/ingest/my-source/events",
+		ClientIP:      "10.0.0.1",
+		Body:          []byte(`{"id": "abc"}`),
+	}
+
+	ingestLogSvc.EXPECT().Scan(ctx, flow, from, to, gomock.Any()).DoAndReturn(func(ctx context.Context, flow *models.Flow, from time.Time, to time.Time, fn func(entries []*models.IngestLogEntry) error) error {
+		return fn([]*models.IngestLogEntry{entry})
+	})
+
+	m := &models.Message{ID: "message-1", FlowID: "flow-1", SinkID: "sink-2", IngestedReqID: "req-1"}
+	messageBuilder.EXPECT().FromHttp(gomock.Any(), gomock.Any(), "req-1").DoAndReturn(func(flow *models.Flow, r *http.Request, reqID string) ([]*models.Message, error) {
+		// the messages are only built for the backfilled sink
+		assert.Equal(t, []*models.Sink{sink2}, flow.Sinks)
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "abc", r.Header.Get("X-Signature"))
+		assert.Equal(t, "x=1", r.URL.RawQuery)
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"id": "abc"}`, string(body))
+
+		return []*models.Message{m}, nil
+	})
+	messageVerifier.EXPECT().StripCredentials(gomock.Any(), m)
+	messageEnqueuer.EXPECT().Enqueue(ctx, []*models.Message{m}).Return(nil, nil)
+
+	count, err := s.Backfill(ctx, flow, "sink-2", from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// the request metadata is the one of the original request
+	assert.Equal(t, receivedAt, m.ReceivedAt)
+	assert.Equal(t, "/api/v1/ingest/my-source/events", m.HttpPath)
+	assert.Equal(t, "events", m.PathSuffix)
+	assert.Equal(t, "10.0.0.1", m.ClientIP)
+
+	_, err = s.Backfill(ctx, flow, "sink-3", from, to)
+	assert.ErrorContains(t, err, "sink not found: sink-3")
+
+	flow.Source.IngestLog = nil
+	_, err = s.Backfill(ctx, flow, "sink-2", from, to)
+	assert.ErrorContains(t, err, "ingest log not enabled for source source-1")
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/didil/inhooks/pkg/lib"
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/pkg/errors"
+)
+
+type CaptureService interface {
+	// Capture records a request of a capture source, keeping the most recent requests
+	Capture(ctx context.Context, flow *models.Flow, c *models.CapturedRequest) error
+	// List returns the captured requests of the flow source, most recent first
+	List(ctx context.Context, flow *models.Flow) ([]*models.CapturedRequest, error)
+}
+
+type captureService struct {
+	redisStore RedisStore
+	timeSvc    TimeService
+	codec      RecordCodec
+	appConf    *lib.AppConfig
+}
+
+func NewCaptureService(redisStore RedisStore, timeSvc TimeService, codec RecordCodec, appConf *lib.AppConfig) CaptureService {
+	return &captureService{
+		redisStore: redisStore,
+		timeSvc:    timeSvc,
+		codec:      codec,
+		appConf:    appConf,
+	}
+}
+
+func (s *captureService) Capture(ctx context.Context, flow *models.Flow, c *models.CapturedRequest) error {
+	if c.ReceivedAt.IsZero() {
+		c.ReceivedAt = s.timeSvc.Now()
+	}
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal captured request")
+	}
+
+	b, err = s.codec.Encode(b)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode captured request")
+	}
+
+	err = s.redisStore.LPushTrimExpire(ctx, captureKey(flow.ID), b, s.appConf.Ingest.CaptureMaxRequests, s.appConf.Ingest.CaptureTTL)
+	if err != nil {
+		return errors.Wrapf(err, "failed to store captured request")
+	}
+
+	return nil
+}
+
+func (s *captureService) List(ctx context.Context, flow *models.Flow) ([]*models.CapturedRequest, error) {
+	values, err := s.redisStore.LRangeAll(ctx, captureKey(flow.ID))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get captured requests")
+	}
+
+	captures := make([]*models.CapturedRequest, 0, len(values))
+	for _, value := range values {
+		b, err := s.codec.Decode([]byte(value))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode captured request")
+		}
+
+		c := &models.CapturedRequest{}
+		err = json.Unmarshal(b, c)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal captured request")
+		}
+
+		captures = append(captures, c)
+	}
+
+	return captures, nil
+}
+
+func captureKey(flowID string) string {
+	return fmt.Sprintf("f:%s:captures", flowID)
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/didil/inhooks/pkg/lib"
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/didil/inhooks/pkg/testsupport/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCaptureService(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	redisStore := mocks.NewMockRedisStore(ctrl)
+	timeSvc := mocks.NewMockTimeService(ctrl)
+	codec, err := NewRecordCodec(&lib.StorageConfig{})
+	assert.NoError(t, err)
+
+	appConf := &lib.AppConfig{Ingest: lib.IngestConfig{CaptureMaxRequests: 50, CaptureTTL: time.Hour}}
+	s := NewCaptureService(redisStore, timeSvc, codec, appConf)
+
+	flow := &models.Flow{ID: "flow-1", Source: &models.Source{Capture: true}}
+	now := time.Date(2023, 05, 5, 8, 9, 12, 0, time.UTC)
+
+	c := &models.CapturedRequest{
+		ReqID:              "req-1",
+		Method:             "POST",
+		Body:               `{"id": "abc"}`,
+		VerificationStatus: models.VerificationStatusVerified,
+	}
+
+	var stored []byte
+	timeSvc.EXPECT().Now().Return(now)
+	redisStore.EXPECT().LPushTrimExpire(ctx, "f:flow-1:captures", gomock.Any(), 50, time.Hour).DoAndReturn(func(ctx context.Context, key string, value []byte, maxLen int, ttl time.Duration) error {
+		stored = value
+		return nil
+	})
+
+	err = s.Capture(ctx, flow, c)
+	assert.NoError(t, err)
+	assert.Equal(t, now, c.ReceivedAt)
+
+	redisStore.EXPECT().LRangeAll(ctx, "f:flow-1:captures").Return([]string{string(stored)}, nil)
+
+	captures, err := s.List(ctx, flow)
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.CapturedRequest{c}, captures)
+}
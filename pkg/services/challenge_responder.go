@@ -0,0 +1,109 @@
+package services
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/pkg/errors"
+)
+
+type ChallengeResponder interface {
+	Respond(flow *models.Flow, r *http.Request) (*models.ChallengeResponse, error)
+}
+
+type challengeResponder struct {
+	messageVerifier MessageVerifier
+}
+
+func NewChallengeResponder(messageVerifier MessageVerifier) ChallengeResponder {
+	return &challengeResponder{
+		messageVerifier: messageVerifier,
+	}
+}
+
+// Respond returns the response to send if the request is a challenge request, or nil if the request should be ingested normally
+func (c *challengeResponder) Respond(flow *models.Flow, r *http.Request) (*models.ChallengeResponse, error) {
+	challenge := flow.Source.Challenge
+	if challenge == nil {
+		return nil, nil
+	}
+
+	switch challenge.ChallengeType {
+	case models.ChallengeTypeSlack:
+		return c.respondSlack(flow, r)
+	case models.ChallengeTypeMeta:
+		return c.respondMeta(challenge, r)
+	default:
+		return nil, fmt.Errorf("unexpected challenge type: %s", challenge.ChallengeType)
+	}
+}
+
+type slackURLVerification struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+}
+
+// respondSlack answers url verification requests signed like the source events
+func (c *challengeResponder) respondSlack(flow *models.Flow, r *http.Request) (*models.ChallengeResponse, error) {
+	if r.Method != http.MethodPost {
+		return nil, nil
+	}
+
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read body")
+	}
+	// restore the body for regular requests
+	r.Body = io.NopCloser(bytes.NewReader(payload))
+
+	// the body might be compressed
+	m, err := RequestMessage(flow.Source, r, payload)
+	if err != nil {
+		// not a challenge request, the decoding error is handled by the regular ingest
+		return nil, nil
+	}
+
+	verification := &slackURLVerification{}
+	err = json.Unmarshal(m.Payload, verification)
+	if err != nil || verification.Type != "url_verification" {
+		// not a challenge request
+		return nil, nil
+	}
+
+	err = c.messageVerifier.Verify(flow, m)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid url verification request")
+	}
+
+	resp := &models.ChallengeResponse{
+		ContentType: "text/plain",
+		Body:        []byte(verification.Challenge),
+	}
+
+	return resp, nil
+}
+
+func (c *challengeResponder) respondMeta(challenge *models.Challenge, r *http.Request) (*models.ChallengeResponse, error) {
+	query := r.URL.Query()
+	if r.Method != http.MethodGet || query.Get("hub.mode") != "subscribe" {
+		return nil, nil
+	}
+
+	expectedToken := os.Getenv(challenge.VerifyTokenEnvVar)
+	if expectedToken == "" || subtle.ConstantTimeCompare([]byte(expectedToken), []byte(query.Get("hub.verify_token"))) != 1 {
+		return nil, errors.New("invalid verify token")
+	}
+
+	resp := &models.ChallengeResponse{
+		ContentType: "text/plain",
+		Body:        []byte(query.Get("hub.challenge")),
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChallengeResponder_Slack(t *testing.T) {
+	c := NewChallengeResponder(NewMessageVerifier())
+
+	flow := &models.Flow{
+		Source: &models.Source{
+			Challenge: &models.Challenge{
+				ChallengeType: models.ChallengeTypeSlack,
+			},
+		},
+	}
+
+	payload := []byte(`{"token":"Jhj5dZrVaK7ZwHHjRyZWjbDl","challenge":"3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P","type":"url_verification"}`)
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/ingest/source-1", bytes.NewBuffer(payload))
+
+	resp, err := c.Respond(flow, r)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.ChallengeResponse{
+		ContentType: "text/plain",
+		Body:        []byte("3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P"),
+	}, resp)
+
+	// regular event
+	payload = []byte(`{"type":"event_callback","event":{"type":"app_mention"}}`)
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/ingest/source-1", bytes.NewBuffer(payload))
+
+	resp, err = c.Respond(flow, r)
+	assert.NoError(t, err)
+	assert.Nil(t, resp)
+
+	// the body can still be read
+	body, err := io.ReadAll(r.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, payload, body)
+}
+
+func TestChallengeResponder_Slack_VerifiedCompressed(t *testing.T) {
+	c := NewChallengeResponder(NewMessageVerifier())
+
+	secretEnvVar := "FLOW_CHALLENGE_SLACK_TOKEN"
+	os.Setenv(secretEnvVar, "my-token")
+
+	maxDecompressedBytes := int64(1024)
+	flow := &models.Flow{
+		Source: &models.Source{
+			Challenge: &models.Challenge{
+				ChallengeType: models.ChallengeTypeSlack,
+			},
+			Verification: &models.Verification{
+				VerificationType:    models.VerificationTypeToken,
+				SignatureHeader:     "X-Token",
+				CurrentSecretEnvVar: secretEnvVar,
+			},
+			Decompression: &models.Decompression{
+				SignedPayload:        models.SignedPayloadCompressed,
+				MaxDecompressedBytes: &maxDecompressedBytes,
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	_, err := gw.Write([]byte(`{"challenge":"3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P","type":"url_verification"}`))
+	assert.NoError(t, err)
+	assert.NoError(t, gw.Close())
+
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/ingest/source-1", bytes.NewReader(buf.Bytes()))
+	r.Header.Set("Content-Encoding", "gzip")
+	r.Header.Set("X-Token", "my-token")
+
+	resp, err := c.Respond(flow, r)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.ChallengeResponse{
+		ContentType: "text/plain",
+		Body:        []byte("3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P"),
+	}, resp)
+
+	// invalid token
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/ingest/source-1", bytes.NewReader(buf.Bytes()))
+	r.Header.Set("Content-Encoding", "gzip")
+	r.Header.Set("X-Token", "other-token")
+
+	resp, err = c.Respond(flow, r)
+	assert.ErrorContains(t, err, "invalid url verification request")
+	assert.Nil(t, resp)
+}
+
+func TestChallengeResponder_Meta(t *testing.T) {
+	c := NewChallengeResponder(NewMessageVerifier())
+
+	verifyTokenEnvVar := "FLOW_CHALLENGE_VERIFY_TOKEN"
+	os.Setenv(verifyTokenEnvVar, "my-token")
+
+	flow := &models.Flow{
+		Source: &models.Source{
+			Challenge: &models.Challenge{
+				ChallengeType:     models.ChallengeTypeMeta,
+				VerifyTokenEnvVar: verifyTokenEnvVar,
+			},
+		},
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/ingest/source-1?hub.mode=subscribe&hub.challenge=1158201444&hub.verify_token=my-token", nil)
+
+	resp, err := c.Respond(flow, r)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.ChallengeResponse{
+		ContentType: "text/plain",
+		Body:        []byte("1158201444"),
+	}, resp)
+
+	r = httptest.NewRequest(http.MethodGet, "/api/v1/ingest/source-1?hub.mode=subscribe&hub.challenge=1158201444&hub.verify_token=other-token", nil)
+
+	resp, err = c.Respond(flow, r)
+	assert.EqualError(t, err, "invalid verify token")
+	assert.Nil(t, resp)
+
+	// regular event
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/ingest/source-1", bytes.NewBufferString(`{"object":"whatsapp_business_account"}`))
+
+	resp, err = c.Respond(flow, r)
+	assert.NoError(t, err)
+	assert.Nil(t, resp)
+}
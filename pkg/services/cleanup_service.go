@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/didil/inhooks/pkg/lib"
@@ -13,16 +14,20 @@ type CleanupService interface {
 	CleanupDoneQueue(ctx context.Context, f *models.Flow, sink *models.Sink, doneQueueCleanupDelay time.Duration) (int, error)
 }
 
-func NewCleanupService(redisStore RedisStore, timeSvc TimeService) CleanupService {
+func NewCleanupService(redisStore RedisStore, timeSvc TimeService, codec RecordCodec, payloadStore PayloadStore) CleanupService {
 	return &cleanupService{
-		redisStore: redisStore,
-		timeSvc:    timeSvc,
+		redisStore:   redisStore,
+		timeSvc:      timeSvc,
+		codec:        codec,
+		payloadStore: payloadStore,
 	}
 }
 
 type cleanupService struct {
-	redisStore RedisStore
-	timeSvc    TimeService
+	redisStore   RedisStore
+	timeSvc      TimeService
+	codec        RecordCodec
+	payloadStore PayloadStore
 }
 
 func (s *cleanupService) CleanupDoneQueue(ctx context.Context, f *models.Flow, sink *models.Sink, doneQueueCleanupDelay time.Duration) (int, error) {
@@ -52,11 +57,72 @@ func (s *cleanupService) CleanupDoneQueue(ctx context.Context, f *models.Flow, s
 			messageKeys = append(messageKeys, mKey)
 		}
 
-		err := s.redisStore.ZRemDel(ctx, doneQueueKey, mIDChunks[i], messageKeys)
+		ingestKeys, payloadRefs, err := s.storedRefs(ctx, messageKeys)
+		if err != nil {
+			return 0, err
+		}
+
+		released, err := s.redisStore.ZRemDelReleaseIngests(ctx, doneQueueKey, mIDChunks[i], messageKeys, ingestKeys)
 		if err != nil {
 			return 0, errors.Wrapf(err, "failed to zremdel")
 		}
+
+		err = s.deletePayloads(ctx, payloadRefs, released)
+		if err != nil {
+			return 0, err
+		}
 	}
 
 	return len(mIDs), nil
 }
+
+// storedRefs returns the keys of the ingested requests and the payload refs of the messages, or empty strings for messages storing their request data and payload
+func (s *cleanupService) storedRefs(ctx context.Context, messageKeys []string) ([]string, []string, error) {
+	values, err := s.redisStore.MGet(ctx, messageKeys)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to get messages")
+	}
+
+	ingestKeys := make([]string, 0, len(messageKeys))
+	payloadRefs := make([]string, 0, len(messageKeys))
+	for i, b := range values {
+		if b == nil {
+			ingestKeys = append(ingestKeys, "")
+			payloadRefs = append(payloadRefs, "")
+			continue
+		}
+
+		m, err := decodeMessage(s.codec, b)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to decode message %s", messageKeys[i])
+		}
+
+		payloadRefs = append(payloadRefs, m.PayloadRef)
+		if m.IngestID == "" {
+			ingestKeys = append(ingestKeys, "")
+			continue
+		}
+		ingestKeys = append(ingestKeys, ingestKey(m.FlowID, m.IngestID))
+	}
+
+	return ingestKeys, payloadRefs, nil
+}
+
+// deletePayloads deletes the offloaded payloads not referenced by any message anymore
+func (s *cleanupService) deletePayloads(ctx context.Context, payloadRefs []string, released []bool) error {
+	for i, payloadRef := range payloadRefs {
+		if payloadRef == "" || i >= len(released) || !released[i] {
+			continue
+		}
+		if s.payloadStore == nil {
+			return fmt.Errorf("payload store not configured, failed to delete payload: %s", payloadRef)
+		}
+
+		err := s.payloadStore.Delete(ctx, payloadRef)
+		if err != nil {
+			return errors.Wrapf(err, "failed to delete payload: %s", payloadRef)
+		}
+	}
+
+	return nil
+}
@@ -2,9 +2,11 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 	"time"
 
+	"github.com/didil/inhooks/pkg/lib"
 	"github.com/didil/inhooks/pkg/models"
 	"github.com/didil/inhooks/pkg/testsupport/mocks"
 	"github.com/golang/mock/gomock"
@@ -16,6 +18,7 @@ func TestCleanUpServiceCleanupDoneQueue(t *testing.T) {
 	defer ctrl.Finish()
 
 	redisStore := mocks.NewMockRedisStore(ctrl)
+	payloadStore := mocks.NewMockPayloadStore(ctrl)
 	timeSvc := mocks.NewMockTimeService(ctrl)
 
 	now := time.Date(2023, 05, 5, 8, 46, 20, 0, time.UTC)
@@ -42,9 +45,21 @@ func TestCleanUpServiceCleanupDoneQueue(t *testing.T) {
 	messageKeys := []string{"f:flow-1:s:sink-1:m:message-1", "f:flow-1:s:sink-1:m:message-2"}
 
 	redisStore.EXPECT().ZRangeBelowScore(ctx, queueKey, float64(cutoffTime.Unix())).Return(mIds, nil)
-	redisStore.EXPECT().ZRemDel(ctx, queueKey, mIds, messageKeys).Return(nil)
+	message1Bytes, err := json.Marshal(&models.Message{ID: "message-1", FlowID: flowId, SinkID: sinkID, IngestID: "ingest-1", PayloadRef: "flow-1/i/ingest-1"})
+	assert.NoError(t, err)
+	message2Bytes, err := json.Marshal(&models.Message{ID: "message-2", FlowID: flowId, SinkID: sinkID, PayloadRef: "flow-1/s/sink-1/m/message-2"})
+	assert.NoError(t, err)
+	ingestKeys := []string{"f:flow-1:i:ingest-1", ""}
+
+	redisStore.EXPECT().MGet(ctx, messageKeys).Return([][]byte{message1Bytes, message2Bytes}, nil)
+	// the ingested request of message-1 is still referenced by another message
+	redisStore.EXPECT().ZRemDelReleaseIngests(ctx, queueKey, mIds, messageKeys, ingestKeys).Return([]bool{false, true}, nil)
+	payloadStore.EXPECT().Delete(ctx, "flow-1/s/sink-1/m/message-2").Return(nil)
+
+	codec, err := NewRecordCodec(&lib.StorageConfig{})
+	assert.NoError(t, err)
 
-	s := NewCleanupService(redisStore, timeSvc)
+	s := NewCleanupService(redisStore, timeSvc, codec, payloadStore)
 	count, err := s.CleanupDoneQueue(ctx, flow, sink, doneQueueCleanupDelay)
 	assert.NoError(t, err)
 
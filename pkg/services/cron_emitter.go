@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+type CronEmitter interface {
+	// Emit enqueues a message for each sink of the cron source flow, unless the tick was already emitted by another instance.
+	// Returns true if the tick was emitted by this call.
+	Emit(ctx context.Context, flow *models.Flow, tick time.Time) (bool, error)
+}
+
+// duration during which an instance emits a tick before other instances can take over
+const cronLockTTL = time.Minute
+
+// duration during which the emitted ticks are remembered, instances late by more than this duration would emit the tick again
+const cronDoneTTL = 24 * time.Hour
+
+type cronEmitter struct {
+	redisStore      RedisStore
+	messageBuilder  MessageBuilder
+	messageEnqueuer MessageEnqueuer
+}
+
+func NewCronEmitter(redisStore RedisStore, messageBuilder MessageBuilder, messageEnqueuer MessageEnqueuer) CronEmitter {
+	return &cronEmitter{
+		redisStore:      redisStore,
+		messageBuilder:  messageBuilder,
+		messageEnqueuer: messageEnqueuer,
+	}
+}
+
+func (e *cronEmitter) Emit(ctx context.Context, flow *models.Flow, tick time.Time) (bool, error) {
+	// the instances compute the same ticks, the first one to lock the tick emits it
+	lockKey := cronLockKey(flow.Source.ID, tick)
+	tickValue := []byte(tick.UTC().Format(time.RFC3339))
+	locked, err := e.redisStore.SetNX(ctx, lockKey, tickValue, cronLockTTL)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to lock cron tick")
+	}
+	if !locked {
+		return false, nil
+	}
+
+	// the done marker is checked once the tick is locked, as it is set with the messages of the instance that emitted the tick
+	doneKey := cronDoneKey(flow.Source.ID, tick)
+	done, err := e.redisStore.Get(ctx, doneKey)
+	if err != nil {
+		return false, e.release(ctx, lockKey, errors.Wrapf(err, "failed to check cron tick"))
+	}
+	if done != nil {
+		return false, nil
+	}
+
+	err = e.enqueue(ctx, flow, tick, &models.EnqueueMarker{Key: doneKey, Value: tickValue, TTL: cronDoneTTL})
+	if err != nil {
+		return false, e.release(ctx, lockKey, err)
+	}
+
+	return true, nil
+}
+
+// release unlocks the tick so that it can be emitted on retry, and returns the emit error
+func (e *cronEmitter) release(ctx context.Context, lockKey string, err error) error {
+	delErr := e.redisStore.Del(ctx, lockKey)
+	if delErr != nil {
+		return errors.Wrapf(err, "failed to release cron tick: %v", delErr)
+	}
+
+	return err
+}
+
+func (e *cronEmitter) enqueue(ctx context.Context, flow *models.Flow, tick time.Time, marker *models.EnqueueMarker) error {
+	cron := flow.Source.Cron
+
+	payload, err := cron.RenderPayload(&models.CronTick{Time: tick, FlowID: flow.ID, SourceID: flow.Source.ID})
+	if err != nil {
+		return err
+	}
+
+	headers := http.Header{}
+	for name, value := range cron.Headers {
+		headers.Set(name, value)
+	}
+
+	messages, err := e.messageBuilder.FromPayload(flow, payload, headers, uuid.New().String())
+	if err != nil {
+		return errors.Wrapf(err, "failed to build messages")
+	}
+
+	// the marker is set even if the sinks filters match no message
+	_, err = e.messageEnqueuer.EnqueueMarked(ctx, messages, marker)
+	if err != nil {
+		return errors.Wrapf(err, "failed to enqueue messages")
+	}
+
+	return nil
+}
+
+func cronLockKey(sourceID string, tick time.Time) string {
+	return fmt.Sprintf("src:%s:cron:%d:lock", sourceID, tick.Unix())
+}
+
+func cronDoneKey(sourceID string, tick time.Time) string {
+	return fmt.Sprintf("src:%s:cron:%d:done", sourceID, tick.Unix())
+}
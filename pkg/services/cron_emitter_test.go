@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/didil/inhooks/pkg/lib"
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/didil/inhooks/pkg/testsupport"
+	"github.com/didil/inhooks/pkg/testsupport/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCronEmitter(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	appConf, err := testsupport.InitAppConfig(ctx)
+	assert.NoError(t, err)
+
+	client, err := lib.InitRedisClient(appConf)
+	assert.NoError(t, err)
+	redisStore, err := NewRedisStore(client, appConf.Redis.InhooksDBName)
+	assert.NoError(t, err)
+
+	prefix := fmt.Sprintf("inhooks:%s", appConf.Redis.InhooksDBName)
+	defer func() {
+		err := testsupport.DeleteAllRedisKeys(ctx, client, prefix)
+		assert.NoError(t, err)
+	}()
+
+	timeSvc := mocks.NewMockTimeService(ctrl)
+	messageEnqueuer := mocks.NewMockMessageEnqueuer(ctrl)
+	messageBuilder := NewMessageBuilder(timeSvc)
+
+	// two inhooks instances
+	e1 := NewCronEmitter(redisStore, messageBuilder, messageEnqueuer)
+	e2 := NewCronEmitter(redisStore, messageBuilder, messageEnqueuer)
+
+	cron := &models.Cron{
+		Schedule: "0 2 * * *",
+		Payload:  `{"task": "nightly-sync", "date": "{{ .Time.Format "2006-01-02" }}"}`,
+		Headers:  map[string]string{"Content-Type": "application/json"},
+	}
+	assert.NoError(t, cron.Compile())
+
+	flow := &models.Flow{
+		ID:     "flow-1",
+		Source: &models.Source{ID: "source-1", Type: models.SourceTypeCron, Cron: cron},
+		Sinks:  []*models.Sink{{ID: "sink-1"}},
+	}
+
+	tick := time.Date(2023, 05, 5, 2, 0, 0, 0, time.UTC)
+	timeSvc.EXPECT().Now().Return(tick.Add(time.Second)).AnyTimes()
+
+	// the tick is released when the enqueue fails
+	messageEnqueuer.EXPECT().EnqueueMarked(ctx, gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("enqueue failed"))
+
+	emitted, err := e1.Emit(ctx, flow, tick)
+	assert.ErrorContains(t, err, "enqueue failed")
+	assert.False(t, emitted)
+
+	var messages []*models.Message
+	messageEnqueuer.EXPECT().EnqueueMarked(ctx, gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, ms []*models.Message, marker *models.EnqueueMarker) ([]*models.QueuedInfo, error) {
+		messages = ms
+		// the marker is set with the messages
+		assert.Equal(t, fmt.Sprintf("src:source-1:cron:%d:done", tick.Unix()), marker.Key)
+		return nil, redisStore.SetAndEnqueueBatchMarked(ctx, nil, nil, marker)
+	})
+
+	emitted, err = e1.Emit(ctx, flow, tick)
+	assert.NoError(t, err)
+	assert.True(t, emitted)
+
+	assert.Len(t, messages, 1)
+	assert.Equal(t, "flow-1", messages[0].FlowID)
+	assert.Equal(t, "sink-1", messages[0].SinkID)
+	assert.Equal(t, []byte(`{"task": "nightly-sync", "date": "2023-05-05"}`), messages[0].Payload)
+	assert.Equal(t, "application/json", messages[0].HttpHeaders.Get("Content-Type"))
+
+	// the tick is emitted once across instances
+	emitted, err = e2.Emit(ctx, flow, tick)
+	assert.NoError(t, err)
+	assert.False(t, emitted)
+
+	// the tick is not emitted again once the lock expired
+	err = redisStore.Del(ctx, fmt.Sprintf("src:source-1:cron:%d:lock", tick.Unix()))
+	assert.NoError(t, err)
+
+	emitted, err = e2.Emit(ctx, flow, tick)
+	assert.NoError(t, err)
+	assert.False(t, emitted)
+}
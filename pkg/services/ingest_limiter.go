@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/pkg/errors"
+)
+
+type IngestLimiter interface {
+	CheckRateLimit(ctx context.Context, source *models.Source) (bool, time.Duration, error)
+	CheckQueueDepth(ctx context.Context, flow *models.Flow) (bool, error)
+}
+
+func NewIngestLimiter(redisStore RedisStore, timeSvc TimeService) IngestLimiter {
+	return &ingestLimiter{
+		redisStore: redisStore,
+		timeSvc:    timeSvc,
+	}
+}
+
+type ingestLimiter struct {
+	redisStore RedisStore
+	timeSvc    TimeService
+}
+
+// CheckRateLimit returns true if the source rate limit allows the request. Otherwise the duration after which a request will be allowed is returned.
+func (l *ingestLimiter) CheckRateLimit(ctx context.Context, source *models.Source) (bool, time.Duration, error) {
+	rateLimit := source.RateLimit
+	if rateLimit == nil {
+		return true, 0, nil
+	}
+
+	capacity := rateLimit.Requests
+	if rateLimit.Burst != nil {
+		capacity = *rateLimit.Burst
+	}
+	refillPerSecond := float64(rateLimit.Requests) / rateLimit.Interval.Seconds()
+
+	allowed, retryAfter, err := l.redisStore.TakeToken(ctx, rateLimitKey(source.ID), capacity, refillPerSecond, l.timeSvc.Now())
+	if err != nil {
+		return false, 0, errors.Wrapf(err, "failed to take token")
+	}
+
+	return allowed, retryAfter, nil
+}
+
+// CheckQueueDepth returns true if the ready and scheduled queues of none of the flow sinks exceed the source max queue depth
+func (l *ingestLimiter) CheckQueueDepth(ctx context.Context, flow *models.Flow) (bool, error) {
+	if flow.Source.MaxQueueDepth == nil {
+		return true, nil
+	}
+
+	for _, sink := range flow.Sinks {
+		readyQueueKey := queueKey(flow.ID, sink.ID, models.QueueStatusReady)
+		scheduledQueueKey := queueKey(flow.ID, sink.ID, models.QueueStatusScheduled)
+
+		depth, err := l.redisStore.LLenZCard(ctx, readyQueueKey, scheduledQueueKey)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to get queue depth for sink: %s", sink.ID)
+		}
+
+		if depth > *flow.Source.MaxQueueDepth {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func rateLimitKey(sourceID string) string {
+	return fmt.Sprintf("src:%s:rl", sourceID)
+}
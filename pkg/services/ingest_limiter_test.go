@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/didil/inhooks/pkg/testsupport/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIngestLimiter_CheckRateLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	redisStore := mocks.NewMockRedisStore(ctrl)
+	timeSvc := mocks.NewMockTimeService(ctrl)
+
+	now := time.Date(2023, 05, 5, 8, 46, 20, 0, time.UTC)
+	timeSvc.EXPECT().Now().Return(now)
+
+	ctx := context.Background()
+
+	burst := 20
+	source := &models.Source{
+		ID: "source-1",
+		RateLimit: &models.RateLimit{
+			Requests: 10,
+			Interval: 5 * time.Second,
+			Burst:    &burst,
+		},
+	}
+
+	redisStore.EXPECT().TakeToken(ctx, "src:source-1:rl", 20, 2.0, now).Return(false, 300*time.Millisecond, nil)
+
+	l := NewIngestLimiter(redisStore, timeSvc)
+	allowed, retryAfter, err := l.CheckRateLimit(ctx, source)
+	assert.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 300*time.Millisecond, retryAfter)
+}
+
+func TestIngestLimiter_CheckQueueDepth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	redisStore := mocks.NewMockRedisStore(ctrl)
+
+	ctx := context.Background()
+
+	maxQueueDepth := 100
+	flow := &models.Flow{
+		ID: "flow-1",
+		Source: &models.Source{
+			ID:            "source-1",
+			MaxQueueDepth: &maxQueueDepth,
+		},
+		Sinks: []*models.Sink{
+			{ID: "sink-1"},
+			{ID: "sink-2"},
+		},
+	}
+
+	redisStore.EXPECT().LLenZCard(ctx, "f:flow-1:s:sink-1:q:ready", "f:flow-1:s:sink-1:q:scheduled").Return(10, nil)
+	redisStore.EXPECT().LLenZCard(ctx, "f:flow-1:s:sink-2:q:ready", "f:flow-1:s:sink-2:q:scheduled").Return(101, nil)
+
+	l := NewIngestLimiter(redisStore, nil)
+	allowed, err := l.CheckQueueDepth(ctx, flow)
+	assert.NoError(t, err)
+	assert.False(t, allowed)
+}
+
+func TestIngestLimiter_CheckQueueDepth_AtMax(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	redisStore := mocks.NewMockRedisStore(ctrl)
+
+	ctx := context.Background()
+
+	maxQueueDepth := 100
+	flow := &models.Flow{
+		ID: "flow-1",
+		Source: &models.Source{
+			ID:            "source-1",
+			MaxQueueDepth: &maxQueueDepth,
+		},
+		Sinks: []*models.Sink{
+			{ID: "sink-1"},
+		},
+	}
+
+	redisStore.EXPECT().LLenZCard(ctx, "f:flow-1:s:sink-1:q:ready", "f:flow-1:s:sink-1:q:scheduled").Return(100, nil)
+
+	l := NewIngestLimiter(redisStore, nil)
+	allowed, err := l.CheckQueueDepth(ctx, flow)
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+}
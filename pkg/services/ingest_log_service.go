@@ -0,0 +1,241 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/pkg/errors"
+)
+
+type IngestLogService interface {
+	// Append records a request in the ingest log of the flow source
+	Append(ctx context.Context, flow *models.Flow, entry *models.IngestLogEntry) error
+	// Scan calls fn with batches of the requests received between from and to, oldest first
+	Scan(ctx context.Context, flow *models.Flow, from time.Time, to time.Time, fn func(entries []*models.IngestLogEntry) error) error
+}
+
+// number of entries loaded at once when scanning the ingest log
+const ingestLogScanBatchSize = 100
+
+type ingestLogService struct {
+	redisStore            RedisStore
+	timeSvc               TimeService
+	codec                 RecordCodec
+	payloadStore          PayloadStore
+	payloadStoreThreshold int
+}
+
+// NewIngestLogService returns an IngestLogService. Bodies larger than payloadStoreThreshold bytes are stored in payloadStore instead of redis, unless payloadStore is nil.
+func NewIngestLogService(redisStore RedisStore, timeSvc TimeService, codec RecordCodec, payloadStore PayloadStore, payloadStoreThreshold int) IngestLogService {
+	return &ingestLogService{
+		redisStore:            redisStore,
+		timeSvc:               timeSvc,
+		codec:                 codec,
+		payloadStore:          payloadStore,
+		payloadStoreThreshold: payloadStoreThreshold,
+	}
+}
+
+func (s *ingestLogService) Append(ctx context.Context, flow *models.Flow, entry *models.IngestLogEntry) error {
+	now := s.timeSvc.Now()
+	if entry.ReceivedAt.IsZero() {
+		entry.ReceivedAt = now
+	}
+
+	retention := *flow.Source.IngestLog.Retention
+	minScore := float64(now.Add(-retention).UnixMilli())
+	score := float64(entry.ReceivedAt.UnixMilli())
+
+	stored := *entry
+	if s.payloadStore != nil && len(entry.Body) > s.payloadStoreThreshold {
+		bodyRef, err := s.offloadBody(ctx, flow.Source.ID, entry, score)
+		if err != nil {
+			return err
+		}
+		stored.Body = nil
+		stored.BodyRef = bodyRef
+	}
+
+	b, err := json.Marshal(&stored)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal ingest log entry")
+	}
+
+	b, err = s.codec.Encode(b)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode ingest log entry")
+	}
+
+	err = s.redisStore.SetExZAddTrim(ctx, ingestLogEntryKey(flow.Source.ID, entry.IngestedReqID), b, retention, ingestLogKey(flow.Source.ID), entry.IngestedReqID, score, minScore)
+	if err != nil {
+		return errors.Wrapf(err, "failed to store ingest log entry")
+	}
+
+	if s.payloadStore != nil {
+		err = s.deleteExpiredBodies(ctx, flow.Source.ID, minScore)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// offloadBody stores the entry body in the payload store and returns its key.
+// The body is indexed before the entry is stored, so that it is deleted once expired even if the entry could not be stored.
+func (s *ingestLogService) offloadBody(ctx context.Context, sourceID string, entry *models.IngestLogEntry, score float64) (string, error) {
+	bodyRef := ingestLogBodyKey(sourceID, entry.IngestedReqID)
+
+	b, err := s.codec.EncodeBlob(entry.Body)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to encode ingest log body")
+	}
+
+	err = s.redisStore.ZAdd(ctx, ingestLogBodiesKey(sourceID), entry.IngestedReqID, score)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to index ingest log body")
+	}
+
+	err = s.payloadStore.Put(ctx, bodyRef, b)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to store ingest log body")
+	}
+
+	return bodyRef, nil
+}
+
+// deleteExpiredBodies deletes the offloaded bodies of the entries older than the retention, as the entries expire in redis
+func (s *ingestLogService) deleteExpiredBodies(ctx context.Context, sourceID string, minScore float64) error {
+	maxScore := minScore - 1
+	reqIDs, err := s.redisStore.ZRangeBelowScore(ctx, ingestLogBodiesKey(sourceID), maxScore)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get expired ingest log bodies")
+	}
+	if len(reqIDs) == 0 {
+		return nil
+	}
+
+	for _, reqID := range reqIDs {
+		err := s.payloadStore.Delete(ctx, ingestLogBodyKey(sourceID, reqID))
+		if err != nil {
+			return errors.Wrapf(err, "failed to delete expired ingest log body")
+		}
+	}
+
+	_, err = s.redisStore.ZRemRangeBelowScore(ctx, ingestLogBodiesKey(sourceID), int(maxScore))
+	if err != nil {
+		return errors.Wrapf(err, "failed to remove expired ingest log bodies")
+	}
+
+	return nil
+}
+
+func (s *ingestLogService) Scan(ctx context.Context, flow *models.Flow, from time.Time, to time.Time, fn func(entries []*models.IngestLogEntry) error) error {
+	reqIDs, err := s.redisStore.ZRangeByScore(ctx, ingestLogKey(flow.Source.ID), float64(from.UnixMilli()), float64(to.UnixMilli()))
+	if err != nil {
+		return errors.Wrapf(err, "failed to get ingest log request ids")
+	}
+
+	for start := 0; start < len(reqIDs); start += ingestLogScanBatchSize {
+		end := min(start+ingestLogScanBatchSize, len(reqIDs))
+
+		keys := make([]string, 0, end-start)
+		for _, reqID := range reqIDs[start:end] {
+			keys = append(keys, ingestLogEntryKey(flow.Source.ID, reqID))
+		}
+
+		values, err := s.redisStore.MGet(ctx, keys)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get ingest log entries")
+		}
+
+		entries := make([]*models.IngestLogEntry, 0, len(values))
+		for i, value := range values {
+			if value == nil {
+				// expired since the ids were read
+				continue
+			}
+
+			b, err := s.codec.Decode(value)
+			if err != nil {
+				return errors.Wrapf(err, "failed to decode ingest log entry %s", keys[i])
+			}
+
+			entry := &models.IngestLogEntry{}
+			err = json.Unmarshal(b, entry)
+			if err != nil {
+				return errors.Wrapf(err, "failed to unmarshal ingest log entry %s", keys[i])
+			}
+
+			if entry.BodyRef != "" {
+				found, err := s.loadBody(ctx, entry)
+				if err != nil {
+					return errors.Wrapf(err, "failed to load ingest log body %s", entry.BodyRef)
+				}
+				if !found {
+					// deleted with the expired entries since the ids were read
+					continue
+				}
+			}
+
+			entries = append(entries, entry)
+		}
+
+		if len(entries) == 0 {
+			continue
+		}
+
+		err = fn(entries)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadBody sets the body of an entry offloaded to the payload store. Returns false if the body does not exist anymore.
+func (s *ingestLogService) loadBody(ctx context.Context, entry *models.IngestLogEntry) (bool, error) {
+	if s.payloadStore == nil {
+		return false, fmt.Errorf("payload store not configured")
+	}
+
+	b, err := s.payloadStore.Get(ctx, entry.BodyRef)
+	if errors.Is(err, ErrPayloadNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	entry.Body, err = s.codec.Decode(b)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func ingestLogKey(sourceID string) string {
+	return fmt.Sprintf("src:%s:log", sourceID)
+}
+
+func ingestLogEntryKey(sourceID string, reqID string) string {
+	return fmt.Sprintf("src:%s:log:r:%s", sourceID, reqID)
+}
+
+// ingestLogBodiesKey returns the key of the sorted set of the entries with an offloaded body, scored by reception time
+func ingestLogBodiesKey(sourceID string) string {
+	return fmt.Sprintf("src:%s:log:bodies", sourceID)
+}
+
+// ingestLogBodyKey returns the payload store key of an entry body. The request id is set by the client, it is hashed to keep it out of the key path.
+func ingestLogBodyKey(sourceID string, reqID string) string {
+	hash := sha256.Sum256([]byte(reqID))
+	return fmt.Sprintf("src/%s/log/%s", sourceID, hex.EncodeToString(hash[:]))
+}
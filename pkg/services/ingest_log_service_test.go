@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/didil/inhooks/pkg/lib"
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/didil/inhooks/pkg/testsupport/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIngestLogService(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	redisStore := mocks.NewMockRedisStore(ctrl)
+	timeSvc := mocks.NewMockTimeService(ctrl)
+	codec, err := NewRecordCodec(&lib.StorageConfig{})
+	assert.NoError(t, err)
+
+	s := NewIngestLogService(redisStore, timeSvc, codec, nil, 0)
+
+	retention := 2 * time.Hour
+	flow := &models.Flow{ID: "flow-1", Source: &models.Source{ID: "source-1", IngestLog: &models.IngestLog{Retention: &retention}}}
+	now := time.Date(2023, 05, 5, 8, 9, 12, 0, time.UTC)
+
+	entry := &models.IngestLogEntry{
+		IngestedReqID: "req-1",
+		HttpMethod:    "POST",
+		HttpHeaders:   map[string][]string{"Content-Type": {"application/json"}},
+		Body:          []byte(`{"id": "abc"}`),
+	}
+
+	var stored []byte
+	timeSvc.EXPECT().Now().Return(now)
+	redisStore.EXPECT().
+		SetExZAddTrim(ctx, "src:source-1:log:r:req-1", gomock.Any(), retention, "src:source-1:log", "req-1", float64(now.UnixMilli()), float64(now.Add(-retention).UnixMilli())).
+		DoAndReturn(func(ctx context.Context, key string, value []byte, ttl time.Duration, zsetKey string, member string, score float64, minScore float64) error {
+			stored = value
+			return nil
+		})
+
+	err = s.Append(ctx, flow, entry)
+	assert.NoError(t, err)
+	assert.Equal(t, now, entry.ReceivedAt)
+
+	from := now.Add(-time.Hour)
+	to := now
+	redisStore.EXPECT().ZRangeByScore(ctx, "src:source-1:log", float64(from.UnixMilli()), float64(to.UnixMilli())).Return([]string{"req-1", "req-2"}, nil)
+	// req-2 expired after the ids were read
+	redisStore.EXPECT().MGet(ctx, []string{"src:source-1:log:r:req-1", "src:source-1:log:r:req-2"}).Return([][]byte{stored, nil}, nil)
+
+	var scanned []*models.IngestLogEntry
+	err = s.Scan(ctx, flow, from, to, func(entries []*models.IngestLogEntry) error {
+		scanned = append(scanned, entries...)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.IngestLogEntry{entry}, scanned)
+}
+
+func TestIngestLogService_OffloadedBody(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	redisStore := mocks.NewMockRedisStore(ctrl)
+	timeSvc := mocks.NewMockTimeService(ctrl)
+	codec, err := NewRecordCodec(&lib.StorageConfig{})
+	assert.NoError(t, err)
+	payloadStore, err := NewLocalPayloadStore(t.TempDir())
+	assert.NoError(t, err)
+
+	s := NewIngestLogService(redisStore, timeSvc, codec, payloadStore, 10)
+
+	retention := 2 * time.Hour
+	flow := &models.Flow{ID: "flow-1", Source: &models.Source{ID: "source-1", IngestLog: &models.IngestLog{Retention: &retention}}}
+	now := time.Date(2023, 05, 5, 8, 9, 12, 0, time.UTC)
+	minScore := float64(now.Add(-retention).UnixMilli())
+
+	entry := &models.IngestLogEntry{
+		IngestedReqID: "req-1",
+		ReceivedAt:    now,
+		HttpMethod:    "POST",
+		Body:          []byte(`{"id": "abcdefghijklmnopqrstuvwxyz"}`),
+	}
+	bodyRef := ingestLogBodyKey("source-1", "req-1")
+
+	// body of an entry older than the retention
+	expiredBodyRef := ingestLogBodyKey("source-1", "req-0")
+	err = payloadStore.Put(ctx, expiredBodyRef, []byte("expired"))
+	assert.NoError(t, err)
+
+	var stored []byte
+	timeSvc.EXPECT().Now().Return(now)
+	redisStore.EXPECT().ZAdd(ctx, "src:source-1:log:bodies", "req-1", float64(now.UnixMilli())).Return(nil)
+	redisStore.EXPECT().
+		SetExZAddTrim(ctx, "src:source-1:log:r:req-1", gomock.Any(), retention, "src:source-1:log", "req-1", float64(now.UnixMilli()), minScore).
+		DoAndReturn(func(ctx context.Context, key string, value []byte, ttl time.Duration, zsetKey string, member string, score float64, minScore float64) error {
+			stored = value
+			return nil
+		})
+	redisStore.EXPECT().ZRangeBelowScore(ctx, "src:source-1:log:bodies", minScore-1).Return([]string{"req-0"}, nil)
+	redisStore.EXPECT().ZRemRangeBelowScore(ctx, "src:source-1:log:bodies", int(minScore-1)).Return(1, nil)
+
+	err = s.Append(ctx, flow, entry)
+	assert.NoError(t, err)
+
+	// the body is not stored in redis
+	decoded, err := codec.Decode(stored)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(decoded), "abcdefghijklmnopqrstuvwxyz")
+
+	_, err = payloadStore.Get(ctx, expiredBodyRef)
+	assert.ErrorIs(t, err, ErrPayloadNotFound)
+
+	redisStore.EXPECT().ZRangeByScore(ctx, "src:source-1:log", float64(now.UnixMilli()), float64(now.UnixMilli())).Return([]string{"req-1"}, nil)
+	redisStore.EXPECT().MGet(ctx, []string{"src:source-1:log:r:req-1"}).Return([][]byte{stored}, nil)
+
+	var scanned []*models.IngestLogEntry
+	err = s.Scan(ctx, flow, now, now, func(entries []*models.IngestLogEntry) error {
+		scanned = append(scanned, entries...)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, scanned, 1)
+	assert.Equal(t, entry.Body, scanned[0].Body)
+	assert.Equal(t, bodyRef, scanned[0].BodyRef)
+}
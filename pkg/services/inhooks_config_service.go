@@ -17,6 +17,7 @@ type InhooksConfigService interface {
 	GetFlow(flowID string) *models.Flow
 	GetFlows() map[string]*models.Flow
 	GetTransformDefinition(transformID string) *models.TransformDefinition
+	GetUnknownSourceResponse() *models.IngestResponse
 }
 
 type inhooksConfigService struct {
@@ -88,6 +89,10 @@ func (s *inhooksConfigService) GetTransformDefinition(transformID string) *model
 	return s.transformDefinitionsByID[transformID]
 }
 
+func (s *inhooksConfigService) GetUnknownSourceResponse() *models.IngestResponse {
+	return s.inhooksConfig.UnknownSourceResponse
+}
+
 func (s *inhooksConfigService) initFlowsMaps() error {
 	s.flowsBySourceSlug = map[string]*models.Flow{}
 	s.flowsByID = map[string]*models.Flow{}
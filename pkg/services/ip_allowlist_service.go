@@ -0,0 +1,162 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/didil/inhooks/pkg/lib"
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/pkg/errors"
+)
+
+type IPAllowlistService interface {
+	Reload() error
+	ClientIP(r *http.Request) (netip.Addr, error)
+	IsAllowed(source *models.Source, ip netip.Addr) bool
+}
+
+type ipAllowlistService struct {
+	inhooksConfigSvc InhooksConfigService
+	trustedProxies   []netip.Prefix
+
+	mu                 sync.RWMutex
+	prefixesBySourceID map[string][]netip.Prefix
+}
+
+func NewIPAllowlistService(inhooksConfigSvc InhooksConfigService, appConf *lib.AppConfig) (IPAllowlistService, error) {
+	trustedProxies, err := lib.ParseIPPrefixes(appConf.Server.TrustedProxies)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid trusted proxies")
+	}
+
+	svc := &ipAllowlistService{
+		inhooksConfigSvc:   inhooksConfigSvc,
+		trustedProxies:     trustedProxies,
+		prefixesBySourceID: map[string][]netip.Prefix{},
+	}
+
+	return svc, nil
+}
+
+// Reload loads the allowed CIDR ranges of all sources, including the ranges defined in files.
+// On failure, the previously loaded ranges are kept.
+func (s *ipAllowlistService) Reload() error {
+	prefixesBySourceID := map[string][]netip.Prefix{}
+
+	for _, f := range s.inhooksConfigSvc.GetFlows() {
+		source := f.Source
+		if !source.HasIPAllowlist() {
+			continue
+		}
+
+		prefixes, err := lib.ParseIPPrefixes(source.AllowedCIDRs)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse allowed cidrs for source %s", source.ID)
+		}
+
+		if source.AllowedCIDRsFile != "" {
+			filePrefixes, err := readIPPrefixesFile(source.AllowedCIDRsFile)
+			if err != nil {
+				return errors.Wrapf(err, "failed to read allowed cidrs file for source %s", source.ID)
+			}
+			prefixes = append(prefixes, filePrefixes...)
+		}
+
+		prefixesBySourceID[source.ID] = prefixes
+	}
+
+	s.mu.Lock()
+	s.prefixesBySourceID = prefixesBySourceID
+	s.mu.Unlock()
+
+	return nil
+}
+
+// ClientIP returns the ip address of the client. The X-Forwarded-For header is only used if the request comes from a trusted proxy.
+func (s *ipAllowlistService) ClientIP(r *http.Request) (netip.Addr, error) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	remoteIP, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, errors.Wrapf(err, "failed to parse remote address %s", r.RemoteAddr)
+	}
+	remoteIP = remoteIP.Unmap()
+
+	if !lib.PrefixesContain(s.trustedProxies, remoteIP) {
+		return remoteIP, nil
+	}
+
+	forwardedIPs := []string{}
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		forwardedIPs = append(forwardedIPs, strings.Split(header, ",")...)
+	}
+
+	// walk the chain from the closest hop and return the first address that is not a trusted proxy
+	clientIP := remoteIP
+	for i := len(forwardedIPs) - 1; i >= 0; i-- {
+		ip, err := netip.ParseAddr(strings.TrimSpace(forwardedIPs[i]))
+		if err != nil {
+			return netip.Addr{}, fmt.Errorf("invalid X-Forwarded-For address %s", forwardedIPs[i])
+		}
+		clientIP = ip.Unmap()
+
+		if !lib.PrefixesContain(s.trustedProxies, clientIP) {
+			break
+		}
+	}
+
+	return clientIP, nil
+}
+
+func (s *ipAllowlistService) IsAllowed(source *models.Source, ip netip.Addr) bool {
+	if !source.HasIPAllowlist() {
+		return true
+	}
+
+	s.mu.RLock()
+	prefixes := s.prefixesBySourceID[source.ID]
+	s.mu.RUnlock()
+
+	return lib.PrefixesContain(prefixes, ip)
+}
+
+// readIPPrefixesFile reads a file containing one CIDR range per line. Empty lines and lines starting with # are ignored.
+func readIPPrefixesFile(path string) ([]netip.Prefix, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	prefixes := []netip.Prefix{}
+	scanner := bufio.NewScanner(f)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		prefix, err := lib.ParseIPPrefix(line)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid cidr on line %d", lineNumber)
+		}
+		prefixes = append(prefixes, prefix)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return prefixes, nil
+}
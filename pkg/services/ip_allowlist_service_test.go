@@ -0,0 +1,112 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/didil/inhooks/pkg/lib"
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/didil/inhooks/pkg/testsupport/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIPAllowlistService_ClientIP(t *testing.T) {
+	appConf := &lib.AppConfig{
+		Server: lib.ServerConfig{
+			TrustedProxies: []string{"10.0.0.0/8"},
+		},
+	}
+
+	s, err := NewIPAllowlistService(nil, appConf)
+	assert.NoError(t, err)
+
+	// untrusted remote address, X-Forwarded-For is ignored
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/ingest/source-1", nil)
+	r.RemoteAddr = "203.0.113.7:5000"
+	r.Header.Set("X-Forwarded-For", "198.51.100.1")
+
+	ip, err := s.ClientIP(r)
+	assert.NoError(t, err)
+	assert.Equal(t, netip.MustParseAddr("203.0.113.7"), ip)
+
+	// trusted proxy chain
+	r.RemoteAddr = "10.0.0.2:5000"
+	r.Header.Set("X-Forwarded-For", "192.0.2.99, 198.51.100.1, 10.1.1.1")
+
+	ip, err = s.ClientIP(r)
+	assert.NoError(t, err)
+	assert.Equal(t, netip.MustParseAddr("198.51.100.1"), ip)
+
+	// trusted proxy without X-Forwarded-For
+	r.Header.Del("X-Forwarded-For")
+
+	ip, err = s.ClientIP(r)
+	assert.NoError(t, err)
+	assert.Equal(t, netip.MustParseAddr("10.0.0.2"), ip)
+
+	// invalid X-Forwarded-For
+	r.Header.Set("X-Forwarded-For", "not-an-ip")
+
+	_, err = s.ClientIP(r)
+	assert.EqualError(t, err, "invalid X-Forwarded-For address not-an-ip")
+}
+
+func TestIPAllowlistService_Reload_IsAllowed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cidrsFile := filepath.Join(t.TempDir(), "cidrs.txt")
+	err := os.WriteFile(cidrsFile, []byte("# provider ranges\n192.0.2.0/24\n\n2001:db8::/32\n"), 0600)
+	assert.NoError(t, err)
+
+	source1 := &models.Source{
+		ID:               "source-1",
+		AllowedCIDRs:     []string{"198.51.100.1"},
+		AllowedCIDRsFile: cidrsFile,
+	}
+	source2 := &models.Source{
+		ID: "source-2",
+	}
+
+	inhooksConfigSvc := mocks.NewMockInhooksConfigService(ctrl)
+	inhooksConfigSvc.EXPECT().GetFlows().Times(3).Return(map[string]*models.Flow{
+		"flow-1": {ID: "flow-1", Source: source1},
+		"flow-2": {ID: "flow-2", Source: source2},
+	})
+
+	s, err := NewIPAllowlistService(inhooksConfigSvc, &lib.AppConfig{})
+	assert.NoError(t, err)
+
+	err = s.Reload()
+	assert.NoError(t, err)
+
+	assert.True(t, s.IsAllowed(source1, netip.MustParseAddr("198.51.100.1")))
+	assert.True(t, s.IsAllowed(source1, netip.MustParseAddr("192.0.2.10")))
+	assert.True(t, s.IsAllowed(source1, netip.MustParseAddr("2001:db8::5")))
+	assert.False(t, s.IsAllowed(source1, netip.MustParseAddr("198.51.100.2")))
+	assert.True(t, s.IsAllowed(source2, netip.MustParseAddr("198.51.100.2")))
+
+	// reload the file
+	err = os.WriteFile(cidrsFile, []byte("203.0.113.0/24\n"), 0600)
+	assert.NoError(t, err)
+
+	err = s.Reload()
+	assert.NoError(t, err)
+
+	assert.False(t, s.IsAllowed(source1, netip.MustParseAddr("192.0.2.10")))
+	assert.True(t, s.IsAllowed(source1, netip.MustParseAddr("203.0.113.10")))
+
+	// invalid file, previous ranges are kept
+	err = os.WriteFile(cidrsFile, []byte("203.0.113.0/24\ninvalid\n"), 0600)
+	assert.NoError(t, err)
+
+	err = s.Reload()
+	assert.ErrorContains(t, err, "invalid cidr on line 2")
+
+	assert.True(t, s.IsAllowed(source1, netip.MustParseAddr("203.0.113.10")))
+}
@@ -1,16 +1,30 @@
 package services
 
 import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/tls"
+	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
+	"github.com/andybalholm/brotli"
+	"github.com/didil/inhooks/pkg/lib"
 	"github.com/didil/inhooks/pkg/models"
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/pkg/errors"
 )
 
 type MessageBuilder interface {
+	// FromHttp builds a message for each flow sink whose filter matches the request, or for each element of the payload when the source splits payloads
 	FromHttp(flow *models.Flow, r *http.Request, reqID string) ([]*models.Message, error)
+	// FromPayload builds the messages of a payload that was not received over http, e.g. a stream entry, as if it was posted to the ingest endpoint with the headers
+	FromPayload(flow *models.Flow, payload []byte, headers http.Header, reqID string) ([]*models.Message, error)
 }
 
 type messageBuilder struct {
@@ -24,37 +38,240 @@ func NewMessageBuilder(timeSvc TimeService) MessageBuilder {
 }
 
 func (b *messageBuilder) FromHttp(flow *models.Flow, r *http.Request, reqID string) ([]*models.Message, error) {
-	httpHeaders := r.Header
-	payload, err := io.ReadAll(r.Body)
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, signedPayload, httpHeaders, err := decodeBody(flow.Source, r.Header, body)
 	if err != nil {
 		return nil, err
 	}
 	query := r.URL.RawQuery
+	pathSuffix, err := requestPathSuffix(r)
+	if err != nil {
+		return nil, err
+	}
+
+	payloads := [][]byte{payload}
+	if flow.Source.Split != nil {
+		payloads, err = flow.Source.Split.Elements(payload)
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidSplitPayload, "%v", err)
+		}
+
+		// the signature covers the original body
+		if signedPayload == nil {
+			signedPayload = payload
+		}
+	}
+
+	receivedAt := b.timeSvc.Now()
+	var tlsVersion string
+	if r.TLS != nil {
+		tlsVersion = tls.VersionName(r.TLS.Version)
+	}
 
 	messages := []*models.Message{}
+	for i, payload := range payloads {
+		var splitIndex *int
+		if flow.Source.Split != nil {
+			splitIndex = &i
+		}
+
+		// the request data is stored once for all the sinks
+		ingestID := uuid.New().String()
+
+		// the payload is decoded at most once for the sinks filters
+		var doc interface{}
+		docDecoded := false
+
+		for _, s := range flow.Sinks {
+			if s.Filter != nil {
+				if !docDecoded && s.Filter.UsesJSONPath() {
+					// the JSON path conditions do not match non JSON payloads
+					doc, _ = lib.DecodeJSON(payload)
+					docDecoded = true
+				}
+
+				if !s.Filter.Match(httpHeaders, doc) {
+					continue
+				}
+			}
+
+			m := &models.Message{}
+
+			m.FlowID = flow.ID
+			m.SourceID = flow.Source.ID
+			m.IngestedReqID = reqID
+			m.IngestID = ingestID
+			m.SplitIndex = splitIndex
+			m.SinkID = s.ID
+			m.ID = uuid.New().String()
+			m.HttpMethod = r.Method
+			m.HttpHeaders = httpHeaders
+			m.RawQuery = query
+			m.PathSuffix = pathSuffix
+			m.ReceivedAt = receivedAt
+			m.HttpPath = r.URL.EscapedPath()
+			m.UserAgent = r.UserAgent()
+			m.TLSVersion = tlsVersion
+			m.Payload = payload
+			m.SignedPayload = signedPayload
 
-	for _, s := range flow.Sinks {
-		m := &models.Message{}
-
-		m.FlowID = flow.ID
-		m.SourceID = flow.Source.ID
-		m.IngestedReqID = reqID
-		m.SinkID = s.ID
-		m.ID = uuid.New().String()
-		m.HttpHeaders = httpHeaders
-		m.RawQuery = query
-		m.Payload = payload
-
-		// init processing info
-		var delay time.Duration
-		if s.Delay != nil {
-			delay = *s.Delay
+			// init processing info
+			var delay time.Duration
+			if s.Delay != nil {
+				delay = *s.Delay
+			}
+
+			m.DeliverAfter = receivedAt.Add(delay)
+
+			messages = append(messages, m)
 		}
+	}
 
-		m.DeliverAfter = b.timeSvc.Now().Add(delay)
+	return messages, nil
+}
 
-		messages = append(messages, m)
+func (b *messageBuilder) FromPayload(flow *models.Flow, payload []byte, headers http.Header, reqID string) ([]*models.Message, error) {
+	r, err := http.NewRequest(http.MethodPost, "/", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range headers {
+		r.Header[name] = values
+	}
+
+	messages, err := b.FromHttp(flow, r, reqID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range messages {
+		// the payload was not received over http
+		m.HttpPath = ""
 	}
 
 	return messages, nil
 }
+
+// RequestMessage builds a message holding the data of a request without sink, from its raw body. It is used to verify requests that do not produce any message.
+func RequestMessage(source *models.Source, r *http.Request, body []byte) (*models.Message, error) {
+	payload, signedPayload, httpHeaders, err := decodeBody(source, r.Header, body)
+	if err != nil {
+		return nil, err
+	}
+
+	pathSuffix, err := requestPathSuffix(r)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &models.Message{
+		SourceID:      source.ID,
+		HttpMethod:    r.Method,
+		HttpHeaders:   httpHeaders,
+		RawQuery:      r.URL.RawQuery,
+		PathSuffix:    pathSuffix,
+		HttpPath:      r.URL.EscapedPath(),
+		Payload:       payload,
+		SignedPayload: signedPayload,
+	}
+
+	return m, nil
+}
+
+// decodeBody decompresses the body if required by the source. Returns the payload, the bytes covered by the signature if they differ from the payload, and the headers to forward.
+func decodeBody(source *models.Source, httpHeaders http.Header, body []byte) ([]byte, []byte, http.Header, error) {
+	decompression := source.Decompression
+	if decompression == nil || isIdentityEncoding(httpHeaders.Get("Content-Encoding")) {
+		return body, nil, httpHeaders, nil
+	}
+
+	decompressed, err := decompress(httpHeaders.Get("Content-Encoding"), body, *decompression.MaxDecompressedBytes)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var signedPayload []byte
+	if decompression.SignedPayload == models.SignedPayloadCompressed {
+		signedPayload = body
+	}
+
+	// the sinks receive the decompressed payload
+	httpHeaders = httpHeaders.Clone()
+	httpHeaders.Del("Content-Encoding")
+	httpHeaders.Del("Content-Length")
+
+	return decompressed, signedPayload, httpHeaders, nil
+}
+
+// requestPathSuffix returns the escaped path captured by the ingest route wildcard
+func requestPathSuffix(r *http.Request) (string, error) {
+	suffix := chi.URLParam(r, "*")
+	if suffix == "" {
+		return "", nil
+	}
+
+	if r.URL.RawPath == "" {
+		// the wildcard was matched on the unescaped path
+		suffix = (&url.URL{Path: suffix}).EscapedPath()
+	}
+
+	for _, segment := range strings.Split(suffix, "/") {
+		unescapedSegment, err := url.PathUnescape(segment)
+		if err != nil {
+			return "", fmt.Errorf("invalid path suffix: %s", suffix)
+		}
+		if unescapedSegment == ".." || unescapedSegment == "." {
+			return "", fmt.Errorf("path suffix cannot contain relative segments: %s", suffix)
+		}
+	}
+
+	return "/" + suffix, nil
+}
+
+var (
+	ErrUnsupportedContentEncoding = errors.New("unsupported content encoding")
+	ErrDecompressedBodyTooLarge   = errors.New("decompressed body too large")
+	ErrInvalidSplitPayload        = errors.New("invalid split payload")
+)
+
+func isIdentityEncoding(contentEncoding string) bool {
+	contentEncoding = strings.TrimSpace(contentEncoding)
+	return contentEncoding == "" || strings.EqualFold(contentEncoding, "identity")
+}
+
+// decompress decodes a gzip, deflate or brotli payload, reading at most maxBytes decompressed bytes
+func decompress(contentEncoding string, payload []byte, maxBytes int64) ([]byte, error) {
+	var reader io.ReadCloser
+	var err error
+
+	switch strings.ToLower(strings.TrimSpace(contentEncoding)) {
+	case "gzip", "x-gzip":
+		reader, err = gzip.NewReader(bytes.NewReader(payload))
+	case "deflate":
+		reader, err = zlib.NewReader(bytes.NewReader(payload))
+	case "br":
+		reader = io.NopCloser(brotli.NewReader(bytes.NewReader(payload)))
+	default:
+		return nil, errors.Wrapf(ErrUnsupportedContentEncoding, "content encoding %s", contentEncoding)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to init %s reader", contentEncoding)
+	}
+	defer reader.Close()
+
+	// read one extra byte to detect payloads above the limit
+	decompressed, err := io.ReadAll(io.LimitReader(reader, maxBytes+1))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decompress %s payload", contentEncoding)
+	}
+
+	if int64(len(decompressed)) > maxBytes {
+		return nil, ErrDecompressedBodyTooLarge
+	}
+
+	return decompressed, nil
+}
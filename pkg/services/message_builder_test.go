@@ -2,14 +2,19 @@ package services
 
 import (
 	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/andybalholm/brotli"
 	"github.com/didil/inhooks/pkg/models"
 	"github.com/didil/inhooks/pkg/testsupport/mocks"
+	"github.com/go-chi/chi/v5"
 	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -27,8 +32,9 @@ func TestMessageBuilderFromHttp_OK(t *testing.T) {
 	reqID := "request-id-xyz"
 
 	r.Header = http.Header{
-		"header-1": []string{"abc"},
-		"header-2": []string{"def"},
+		"header-1":   []string{"abc"},
+		"header-2":   []string{"def"},
+		"User-Agent": []string{"GitHub-Hookshot/abc"},
 	}
 
 	delay := 5 * time.Minute
@@ -54,7 +60,7 @@ func TestMessageBuilderFromHttp_OK(t *testing.T) {
 
 	timeSvc := mocks.NewMockTimeService(ctrl)
 	now := time.Date(2023, 05, 5, 8, 9, 12, 0, time.UTC)
-	timeSvc.EXPECT().Now().Times(2).Return(now)
+	timeSvc.EXPECT().Now().Return(now)
 
 	d := NewMessageBuilder(timeSvc)
 	messages, err := d.FromHttp(flow, r, reqID)
@@ -69,10 +75,15 @@ func TestMessageBuilderFromHttp_OK(t *testing.T) {
 	assert.Equal(t, sourceID, m1.SourceID)
 	assert.Equal(t, reqID, m1.IngestedReqID)
 	assert.Equal(t, sink1ID, m1.SinkID)
+	assert.Equal(t, http.MethodPost, m1.HttpMethod)
 	assert.Equal(t, rawQuery, m1.RawQuery)
 	assert.Equal(t, r.Header, m1.HttpHeaders)
 	assert.Equal(t, jsonPayload, m1.Payload)
 	assert.Equal(t, now, m1.DeliverAfter)
+	assert.Equal(t, now, m1.ReceivedAt)
+	assert.Equal(t, "/api/v1/ingest/flow-1", m1.HttpPath)
+	assert.Equal(t, "GitHub-Hookshot/abc", m1.UserAgent)
+	assert.Equal(t, "", m1.TLSVersion)
 
 	m2 := messages[1]
 
@@ -87,4 +98,353 @@ func TestMessageBuilderFromHttp_OK(t *testing.T) {
 	assert.Equal(t, r.Header, m2.HttpHeaders)
 	assert.Equal(t, jsonPayload, m2.Payload)
 	assert.Equal(t, now.Add(5*time.Minute), m2.DeliverAfter)
+	assert.Equal(t, now, m2.ReceivedAt)
+
+	// the request data is shared by the messages
+	_, err = uuid.Parse(m1.IngestID)
+	assert.NoError(t, err)
+	assert.Equal(t, m1.IngestID, m2.IngestID)
+}
+
+func TestMessageBuilderFromHttp_PathSuffix(t *testing.T) {
+	flow := &models.Flow{
+		ID: "flow-1",
+		Source: &models.Source{
+			ID: "source-1",
+		},
+		Sinks: []*models.Sink{
+			{
+				ID: "sink-1",
+			},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	timeSvc := mocks.NewMockTimeService(ctrl)
+	timeSvc.EXPECT().Now().AnyTimes().Return(time.Date(2023, 05, 5, 8, 9, 12, 0, time.UTC))
+
+	d := NewMessageBuilder(timeSvc)
+
+	testCases := []struct {
+		path               string
+		wildcard           string
+		expectedPathSuffix string
+		expectedErr        string
+	}{
+		{path: "/api/v1/ingest/source-1", wildcard: "", expectedPathSuffix: ""},
+		{path: "/api/v1/ingest/source-1/orders/created", wildcard: "orders/created", expectedPathSuffix: "/orders/created"},
+		{path: "/api/v1/ingest/source-1/orders/a%20b", wildcard: "orders/a b", expectedPathSuffix: "/orders/a%20b"},
+		{path: "/api/v1/ingest/source-1/orders/a%2Fb", wildcard: "orders/a%2Fb", expectedPathSuffix: "/orders/a%2Fb"},
+		{path: "/api/v1/ingest/source-1/orders/%2E%2E/admin", wildcard: "orders/%2E%2E/admin", expectedErr: "path suffix cannot contain relative segments: orders/%2E%2E/admin"},
+	}
+
+	for _, tc := range testCases {
+		r := httptest.NewRequest(http.MethodPost, tc.path, bytes.NewBufferString(`{}`))
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("*", tc.wildcard)
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+		messages, err := d.FromHttp(flow, r, "request-id-xyz")
+		if tc.expectedErr != "" {
+			assert.EqualError(t, err, tc.expectedErr)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expectedPathSuffix, messages[0].PathSuffix)
+	}
+}
+
+func TestMessageBuilderFromHttp_Filter(t *testing.T) {
+	push := "push"
+	opened := "opened"
+	pushFilter := &models.Filter{Header: "X-GitHub-Event", Equals: &push}
+	openedFilter := &models.Filter{JSONPath: "$.action", Equals: &opened}
+	assert.NoError(t, pushFilter.Compile())
+	assert.NoError(t, openedFilter.Compile())
+
+	flow := &models.Flow{
+		ID: "flow-1",
+		Source: &models.Source{
+			ID: "source-1",
+		},
+		Sinks: []*models.Sink{
+			{ID: "sink-all"},
+			{ID: "sink-push", Filter: pushFilter},
+			{ID: "sink-opened", Filter: openedFilter},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	timeSvc := mocks.NewMockTimeService(ctrl)
+	timeSvc.EXPECT().Now().AnyTimes().Return(time.Date(2023, 05, 5, 8, 9, 12, 0, time.UTC))
+
+	d := NewMessageBuilder(timeSvc)
+
+	sinkIDs := func(payload string, event string) []string {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/ingest/source-1", bytes.NewBufferString(payload))
+		r.Header.Set("X-GitHub-Event", event)
+
+		messages, err := d.FromHttp(flow, r, "request-id-xyz")
+		assert.NoError(t, err)
+
+		ids := []string{}
+		for _, m := range messages {
+			ids = append(ids, m.SinkID)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []string{"sink-all", "sink-push"}, sinkIDs(`{"ref":"main"}`, "push"))
+	assert.Equal(t, []string{"sink-all", "sink-opened"}, sinkIDs(`{"action":"opened"}`, "issues"))
+	assert.Equal(t, []string{"sink-all"}, sinkIDs(`not json`, "issues"))
+}
+
+func TestMessageBuilderFromHttp_Split(t *testing.T) {
+	split := &models.PayloadSplit{JSONPath: "$.events"}
+	assert.NoError(t, split.Compile())
+
+	flow := &models.Flow{
+		ID: "flow-1",
+		Source: &models.Source{
+			ID:    "source-1",
+			Split: split,
+		},
+		Sinks: []*models.Sink{
+			{ID: "sink-1"},
+			{ID: "sink-2"},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	timeSvc := mocks.NewMockTimeService(ctrl)
+	timeSvc.EXPECT().Now().AnyTimes().Return(time.Date(2023, 05, 5, 8, 9, 12, 0, time.UTC))
+
+	d := NewMessageBuilder(timeSvc)
+
+	payload := []byte(`{"events": [{"id": "a"}, {"id": "b"}]}`)
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/ingest/source-1", bytes.NewBuffer(payload))
+
+	messages, err := d.FromHttp(flow, r, "request-id-xyz")
+	assert.NoError(t, err)
+	assert.Len(t, messages, 4)
+
+	expected := []struct {
+		sinkID     string
+		splitIndex int
+		payload    string
+	}{
+		{sinkID: "sink-1", splitIndex: 0, payload: `{"id": "a"}`},
+		{sinkID: "sink-2", splitIndex: 0, payload: `{"id": "a"}`},
+		{sinkID: "sink-1", splitIndex: 1, payload: `{"id": "b"}`},
+		{sinkID: "sink-2", splitIndex: 1, payload: `{"id": "b"}`},
+	}
+	for i, e := range expected {
+		m := messages[i]
+		assert.Equal(t, "request-id-xyz", m.IngestedReqID)
+		assert.Equal(t, e.sinkID, m.SinkID)
+		assert.Equal(t, e.splitIndex, *m.SplitIndex)
+		assert.Equal(t, e.payload, string(m.Payload))
+		// the signature is verified on the original body
+		assert.Equal(t, payload, m.SignedPayload)
+	}
+
+	// each element is stored once for all the sinks
+	assert.Equal(t, messages[0].IngestID, messages[1].IngestID)
+	assert.Equal(t, messages[2].IngestID, messages[3].IngestID)
+	assert.NotEqual(t, messages[0].IngestID, messages[2].IngestID)
+
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/ingest/source-1", bytes.NewBufferString(`{"events": {"id": "a"}}`))
+	_, err = d.FromHttp(flow, r, "request-id-xyz")
+	assert.ErrorIs(t, err, ErrInvalidSplitPayload)
+}
+
+func TestMessageBuilderFromHttp_Decompression(t *testing.T) {
+	jsonPayload := []byte(`{"id":"1234","status":"complete"}`)
+
+	compressed := &bytes.Buffer{}
+	gw := gzip.NewWriter(compressed)
+	_, err := gw.Write(jsonPayload)
+	assert.NoError(t, err)
+	assert.NoError(t, gw.Close())
+
+	maxDecompressedBytes := int64(1024)
+	flow := &models.Flow{
+		ID: "flow-1",
+		Source: &models.Source{
+			ID: "source-1",
+			Decompression: &models.Decompression{
+				SignedPayload:        models.SignedPayloadCompressed,
+				MaxDecompressedBytes: &maxDecompressedBytes,
+			},
+		},
+		Sinks: []*models.Sink{
+			{
+				ID: "sink-1",
+			},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	timeSvc := mocks.NewMockTimeService(ctrl)
+	timeSvc.EXPECT().Now().AnyTimes().Return(time.Date(2023, 05, 5, 8, 9, 12, 0, time.UTC))
+
+	d := NewMessageBuilder(timeSvc)
+
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/ingest/source-1", bytes.NewReader(compressed.Bytes()))
+	r.Header.Set("Content-Encoding", "gzip")
+	r.Header.Set("X-Custom", "abc")
+
+	messages, err := d.FromHttp(flow, r, "request-id-xyz")
+	assert.NoError(t, err)
+
+	m := messages[0]
+	assert.Equal(t, jsonPayload, m.Payload)
+	assert.Equal(t, compressed.Bytes(), m.SignedPayload)
+	assert.Equal(t, "", m.HttpHeaders.Get("Content-Encoding"))
+	assert.Equal(t, "abc", m.HttpHeaders.Get("X-Custom"))
+	// the request headers are not modified
+	assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+
+	// signature over the decompressed payload
+	flow.Source.Decompression.SignedPayload = models.SignedPayloadDecompressed
+
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/ingest/source-1", bytes.NewReader(compressed.Bytes()))
+	r.Header.Set("Content-Encoding", "gzip")
+
+	messages, err = d.FromHttp(flow, r, "request-id-xyz")
+	assert.NoError(t, err)
+	assert.Equal(t, jsonPayload, messages[0].Payload)
+	assert.Nil(t, messages[0].SignedPayload)
+
+	// decompressed payload above the limit
+	maxDecompressedBytes = 10
+
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/ingest/source-1", bytes.NewReader(compressed.Bytes()))
+	r.Header.Set("Content-Encoding", "gzip")
+
+	_, err = d.FromHttp(flow, r, "request-id-xyz")
+	assert.ErrorIs(t, err, ErrDecompressedBodyTooLarge)
+
+	// unsupported encoding
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/ingest/source-1", bytes.NewReader(compressed.Bytes()))
+	r.Header.Set("Content-Encoding", "compress")
+
+	_, err = d.FromHttp(flow, r, "request-id-xyz")
+	assert.ErrorIs(t, err, ErrUnsupportedContentEncoding)
+}
+
+func TestDecompress_Deflate(t *testing.T) {
+	payload := []byte("test-payload")
+
+	compressed := &bytes.Buffer{}
+	zw := zlib.NewWriter(compressed)
+	_, err := zw.Write(payload)
+	assert.NoError(t, err)
+	assert.NoError(t, zw.Close())
+
+	decompressed, err := decompress("deflate", compressed.Bytes(), int64(len(payload)))
+	assert.NoError(t, err)
+	assert.Equal(t, payload, decompressed)
+}
+
+func TestDecompress_Brotli(t *testing.T) {
+	payload := []byte("test-payload")
+
+	compressed := &bytes.Buffer{}
+	bw := brotli.NewWriter(compressed)
+	_, err := bw.Write(payload)
+	assert.NoError(t, err)
+	assert.NoError(t, bw.Close())
+
+	decompressed, err := decompress("br", compressed.Bytes(), int64(len(payload)))
+	assert.NoError(t, err)
+	assert.Equal(t, payload, decompressed)
+
+	_, err = decompress("br", compressed.Bytes(), int64(len(payload))-1)
+	assert.ErrorIs(t, err, ErrDecompressedBodyTooLarge)
+}
+
+func TestRequestMessage(t *testing.T) {
+	maxBytes := int64(1024)
+	source := &models.Source{
+		ID: "source-1",
+		Decompression: &models.Decompression{
+			SignedPayload:        models.SignedPayloadCompressed,
+			MaxDecompressedBytes: &maxBytes,
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	gw := gzip.NewWriter(buf)
+	_, err := gw.Write([]byte(`{"id": "abc"}`))
+	assert.NoError(t, err)
+	assert.NoError(t, gw.Close())
+	body := buf.Bytes()
+
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/ingest/source-1?x=1", nil)
+	r.Header.Set("Content-Encoding", "gzip")
+	r.Header.Set("X-Signature", "abc")
+
+	m, err := RequestMessage(source, r, body)
+	assert.NoError(t, err)
+	assert.Equal(t, "source-1", m.SourceID)
+	assert.Equal(t, http.MethodPost, m.HttpMethod)
+	assert.Equal(t, "x=1", m.RawQuery)
+	assert.Equal(t, "abc", m.HttpHeaders.Get("X-Signature"))
+	assert.Equal(t, "", m.HttpHeaders.Get("Content-Encoding"))
+	assert.Equal(t, []byte(`{"id": "abc"}`), m.Payload)
+	assert.Equal(t, body, m.SignedPayload)
+
+	_, err = RequestMessage(source, r, []byte("not gzip"))
+	assert.Error(t, err)
+}
+
+func TestMessageBuilderFromPayload(t *testing.T) {
+	flow := &models.Flow{
+		ID: "flow-1",
+		Source: &models.Source{
+			ID:    "source-1",
+			Type:  models.SourceTypeRedisStream,
+			Split: &models.PayloadSplit{JSONPath: "$.events"},
+		},
+		Sinks: []*models.Sink{
+			{ID: "sink-1"},
+		},
+	}
+	assert.NoError(t, flow.Source.Split.Compile())
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	timeSvc := mocks.NewMockTimeService(ctrl)
+	now := time.Date(2023, 05, 5, 8, 9, 12, 0, time.UTC)
+	timeSvc.EXPECT().Now().Return(now)
+
+	headers := http.Header{}
+	headers.Set("Content-Type", "application/json")
+	headers.Set("X-Event-Type", "order.created")
+
+	d := NewMessageBuilder(timeSvc)
+	messages, err := d.FromPayload(flow, []byte(`{"events":[{"id":"a"},{"id":"b"}]}`), headers, "1700000000000-0")
+	assert.NoError(t, err)
+
+	assert.Len(t, messages, 2)
+	for i, m := range messages {
+		assert.Equal(t, "1700000000000-0", m.IngestedReqID)
+		assert.Equal(t, "sink-1", m.SinkID)
+		assert.Equal(t, http.MethodPost, m.HttpMethod)
+		assert.Equal(t, headers, m.HttpHeaders)
+		assert.Equal(t, []byte(fmt.Sprintf(`{"id":"%s"}`, []string{"a", "b"}[i])), m.Payload)
+		assert.Equal(t, now, m.ReceivedAt)
+		// the payload was not received over http
+		assert.Equal(t, "", m.HttpPath)
+	}
 }
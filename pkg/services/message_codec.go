@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/pkg/errors"
+)
+
+// encodeMessage serializes a message. The request data of messages referencing an ingested request is not included as it is stored once in the ingested request,
+// and offloaded payloads are not included as they are stored in the payload store.
+func encodeMessage(codec RecordCodec, m *models.Message) ([]byte, error) {
+	if m.IngestID != "" || m.PayloadRef != "" {
+		stored := *m
+		if m.IngestID != "" {
+			stored.SetIngestedRequest(&models.IngestedRequest{})
+		}
+		if m.PayloadRef != "" {
+			stored.Payload = nil
+		}
+		m = &stored
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return codec.Encode(b)
+}
+
+func decodeMessage(codec RecordCodec, b []byte) (*models.Message, error) {
+	b, err := codec.Decode(b)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &models.Message{}
+	err = json.Unmarshal(b, m)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// encodeIngestedRequest serializes the request data of a message, without the payload if it is offloaded
+func encodeIngestedRequest(codec RecordCodec, m *models.Message) ([]byte, error) {
+	r := m.IngestedRequest()
+	if m.PayloadRef != "" {
+		r.Payload = nil
+	}
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return codec.Encode(b)
+}
+
+// loadIngestedRequest sets the request data of a message referencing an ingested request
+func loadIngestedRequest(ctx context.Context, redisStore RedisStore, codec RecordCodec, m *models.Message) error {
+	if m.IngestID == "" {
+		return nil
+	}
+
+	iKey := ingestKey(m.FlowID, m.IngestID)
+	b, err := redisStore.GetIngest(ctx, iKey)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get ingested request")
+	}
+	if b == nil {
+		return fmt.Errorf("ingested request not found: %s", iKey)
+	}
+
+	b, err = codec.Decode(b)
+	if err != nil {
+		return errors.Wrapf(err, "failed to decode ingested request")
+	}
+
+	r := &models.IngestedRequest{}
+	err = json.Unmarshal(b, r)
+	if err != nil {
+		return errors.Wrapf(err, "failed to decode ingested request")
+	}
+	m.SetIngestedRequest(r)
+
+	return nil
+}
+
+// loadPayload sets the payload of a message offloaded to the payload store
+func loadPayload(ctx context.Context, payloadStore PayloadStore, codec RecordCodec, m *models.Message) error {
+	if m.PayloadRef == "" {
+		return nil
+	}
+	if payloadStore == nil {
+		return fmt.Errorf("payload store not configured, failed to load payload: %s", m.PayloadRef)
+	}
+
+	b, err := payloadStore.Get(ctx, m.PayloadRef)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get payload: %s", m.PayloadRef)
+	}
+
+	m.Payload, err = codec.Decode(b)
+	if err != nil {
+		return errors.Wrapf(err, "failed to decode payload: %s", m.PayloadRef)
+	}
+
+	return nil
+}
+
+// payloadKey returns the payload store key of a message payload, shared by the messages referencing the same ingested request
+func payloadKey(m *models.Message) string {
+	if m.IngestID != "" {
+		return fmt.Sprintf("%s/i/%s", m.FlowID, m.IngestID)
+	}
+	return fmt.Sprintf("%s/s/%s/m/%s", m.FlowID, m.SinkID, m.ID)
+}
+
+func ingestKey(flowID string, ingestID string) string {
+	return fmt.Sprintf("f:%s:i:%s", flowID, ingestID)
+}
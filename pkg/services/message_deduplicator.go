@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/didil/inhooks/pkg/lib"
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/pkg/errors"
+)
+
+type MessageDeduplicator interface {
+	IdempotencyKey(flow *models.Flow, m *models.Message) (string, error)
+	Claim(ctx context.Context, flow *models.Flow, key string, reqID string) (bool, error)
+	Release(ctx context.Context, flow *models.Flow, key string) error
+}
+
+type messageDeduplicator struct {
+	redisStore RedisStore
+}
+
+func NewMessageDeduplicator(redisStore RedisStore) MessageDeduplicator {
+	return &messageDeduplicator{
+		redisStore: redisStore,
+	}
+}
+
+// IdempotencyKey returns the idempotency key of the message, or an empty string if the message does not contain one
+func (d *messageDeduplicator) IdempotencyKey(flow *models.Flow, m *models.Message) (string, error) {
+	dedupe := flow.Source.Dedupe
+	if dedupe == nil {
+		return "", nil
+	}
+
+	if dedupe.Header != "" {
+		return m.HttpHeaders.Get(dedupe.Header), nil
+	}
+
+	doc, err := lib.DecodeJSON(m.Payload)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to decode payload")
+	}
+
+	value, ok, err := dedupe.PayloadKey(doc)
+	if err != nil || !ok {
+		return "", err
+	}
+
+	return lib.JSONValueString(value)
+}
+
+// Claim records the idempotency key for the source dedupe ttl. Returns false if the key was already claimed.
+func (d *messageDeduplicator) Claim(ctx context.Context, flow *models.Flow, key string, reqID string) (bool, error) {
+	ok, err := d.redisStore.SetNX(ctx, idempotencyKey(flow.Source.ID, key), []byte(reqID), *flow.Source.Dedupe.TTL)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to claim idempotency key")
+	}
+
+	return ok, nil
+}
+
+// Release removes the idempotency key so that the request can be retried
+func (d *messageDeduplicator) Release(ctx context.Context, flow *models.Flow, key string) error {
+	err := d.redisStore.Del(ctx, idempotencyKey(flow.Source.ID, key))
+	if err != nil {
+		return errors.Wrapf(err, "failed to release idempotency key")
+	}
+
+	return nil
+}
+
+// idempotencyKey hashes the key to bound the redis key length
+func idempotencyKey(sourceID string, key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return fmt.Sprintf("src:%s:idem:%s", sourceID, hex.EncodeToString(hash[:]))
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/didil/inhooks/pkg/testsupport/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageDeduplicator_IdempotencyKey(t *testing.T) {
+	d := NewMessageDeduplicator(nil)
+
+	flow := &models.Flow{
+		Source: &models.Source{
+			Dedupe: &models.Dedupe{
+				Header: "X-GitHub-Delivery",
+			},
+		},
+	}
+
+	m := &models.Message{
+		HttpHeaders: http.Header{"X-Github-Delivery": []string{"72d3162e-cc78-11e3-81ab-4c9367dc0958"}},
+		Payload:     []byte(`{"id": "evt_123", "data": {"object": {"id": 12345678901234567890}}}`),
+	}
+
+	key, err := d.IdempotencyKey(flow, m)
+	assert.NoError(t, err)
+	assert.Equal(t, "72d3162e-cc78-11e3-81ab-4c9367dc0958", key)
+
+	flow.Source.Dedupe = &models.Dedupe{JSONPath: "$.id"}
+	assert.NoError(t, flow.Source.Dedupe.Compile())
+	key, err = d.IdempotencyKey(flow, m)
+	assert.NoError(t, err)
+	assert.Equal(t, "evt_123", key)
+
+	flow.Source.Dedupe = &models.Dedupe{JSONPath: "$.data.object.id"}
+	assert.NoError(t, flow.Source.Dedupe.Compile())
+	key, err = d.IdempotencyKey(flow, m)
+	assert.NoError(t, err)
+	assert.Equal(t, "12345678901234567890", key)
+
+	flow.Source.Dedupe = &models.Dedupe{JSONPath: "$.other"}
+	assert.NoError(t, flow.Source.Dedupe.Compile())
+	key, err = d.IdempotencyKey(flow, m)
+	assert.NoError(t, err)
+	assert.Equal(t, "", key)
+
+	m.Payload = []byte("not json")
+	_, err = d.IdempotencyKey(flow, m)
+	assert.ErrorContains(t, err, "failed to decode payload")
+}
+
+func TestMessageDeduplicator_ClaimRelease(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	redisStore := mocks.NewMockRedisStore(ctrl)
+	d := NewMessageDeduplicator(redisStore)
+
+	ctx := context.Background()
+	ttl := time.Hour
+	flow := &models.Flow{
+		Source: &models.Source{
+			ID: "source-1",
+			Dedupe: &models.Dedupe{
+				Header: "X-GitHub-Delivery",
+				TTL:    &ttl,
+			},
+		},
+	}
+
+	redisKey := "src:source-1:idem:ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+	redisStore.EXPECT().SetNX(ctx, redisKey, []byte("req-1"), ttl).Return(false, nil)
+
+	claimed, err := d.Claim(ctx, flow, "abc", "req-1")
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+
+	redisStore.EXPECT().Del(ctx, redisKey).Return(nil)
+
+	err = d.Release(ctx, flow, "abc")
+	assert.NoError(t, err)
+}
@@ -2,7 +2,6 @@ package services
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"time"
 
@@ -12,62 +11,179 @@ import (
 
 type MessageEnqueuer interface {
 	Enqueue(ctx context.Context, messages []*models.Message) ([]*models.QueuedInfo, error)
+	// EnqueueMarked enqueues the messages like Enqueue and sets the marker key in the same transaction
+	EnqueueMarked(ctx context.Context, messages []*models.Message, marker *models.EnqueueMarker) ([]*models.QueuedInfo, error)
 }
 
-func NewMessageEnqueuer(redisStore RedisStore, timeSvc TimeService) MessageEnqueuer {
+// NewMessageEnqueuer returns a MessageEnqueuer. Payloads larger than payloadStoreThreshold bytes are stored in payloadStore instead of redis, unless payloadStore is nil.
+func NewMessageEnqueuer(redisStore RedisStore, timeSvc TimeService, codec RecordCodec, payloadStore PayloadStore, payloadStoreThreshold int) MessageEnqueuer {
 	return &messageEnqueuer{
-		redisStore: redisStore,
-		timeSvc:    timeSvc,
+		redisStore:            redisStore,
+		timeSvc:               timeSvc,
+		codec:                 codec,
+		payloadStore:          payloadStore,
+		payloadStoreThreshold: payloadStoreThreshold,
 	}
 }
 
 type messageEnqueuer struct {
-	redisStore RedisStore
-	timeSvc    TimeService
+	redisStore            RedisStore
+	timeSvc               TimeService
+	codec                 RecordCodec
+	payloadStore          PayloadStore
+	payloadStoreThreshold int
 }
 
+// Enqueue stores and enqueues the messages in a single transaction, so that all the sinks receive the messages or none does
 func (e *messageEnqueuer) Enqueue(ctx context.Context, messages []*models.Message) ([]*models.QueuedInfo, error) {
+	return e.enqueue(ctx, messages, nil)
+}
+
+func (e *messageEnqueuer) EnqueueMarked(ctx context.Context, messages []*models.Message, marker *models.EnqueueMarker) ([]*models.QueuedInfo, error) {
+	return e.enqueue(ctx, messages, marker)
+}
+
+func (e *messageEnqueuer) enqueue(ctx context.Context, messages []*models.Message, marker *models.EnqueueMarker) ([]*models.QueuedInfo, error) {
 	queuedInfos := []*models.QueuedInfo{}
+	entries := []*models.EnqueueEntry{}
+
+	payloadKeys, err := e.offloadPayloads(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	ingestEntries, err := e.ingestEntries(messages)
+	if err != nil {
+		e.deletePayloads(ctx, payloadKeys)
+		return nil, err
+	}
 
 	for _, m := range messages {
 		queueStatus := getQueueStatus(m, e.timeSvc.Now())
+		if m.DeliverInline {
+			// kept out of the ready queue until the caller handles the delivery result
+			queueStatus = models.QueueStatusProcessing
+		}
 
-		err := e.redisEnqueue(ctx, m, queueStatus)
+		entry, err := e.enqueueEntry(m, queueStatus)
 		if err != nil {
+			e.deletePayloads(ctx, payloadKeys)
 			return nil, err
 		}
+		entries = append(entries, entry)
 
 		queuedInfos = append(queuedInfos, &models.QueuedInfo{MessageID: m.ID, QueueStatus: queueStatus, DeliverAfter: m.DeliverAfter})
 	}
 
+	if marker == nil {
+		err = e.redisStore.SetAndEnqueueBatch(ctx, ingestEntries, entries)
+	} else {
+		err = e.redisStore.SetAndEnqueueBatchMarked(ctx, ingestEntries, entries, marker)
+	}
+	if err != nil {
+		e.deletePayloads(ctx, payloadKeys)
+		return nil, errors.Wrapf(err, "failed to set and enqueue messages")
+	}
+
 	return queuedInfos, nil
 }
 
-func (e *messageEnqueuer) redisEnqueue(ctx context.Context, m *models.Message, queueStatus models.QueueStatus) error {
-	b, err := json.Marshal(&m)
+func (e *messageEnqueuer) enqueueEntry(m *models.Message, queueStatus models.QueueStatus) (*models.EnqueueEntry, error) {
+	b, err := encodeMessage(e.codec, m)
 	if err != nil {
-		return errors.Wrapf(err, "failed to encode message for sink: %s", m.SinkID)
+		return nil, errors.Wrapf(err, "failed to encode message for sink: %s", m.SinkID)
 	}
 
-	mKey := messageKey(m.FlowID, m.SinkID, m.ID)
-	qKey := queueKey(m.FlowID, m.SinkID, queueStatus)
+	entry := &models.EnqueueEntry{
+		MessageKey: messageKey(m.FlowID, m.SinkID, m.ID),
+		Value:      b,
+		QueueKey:   queueKey(m.FlowID, m.SinkID, queueStatus),
+		MessageID:  m.ID,
+	}
 
 	switch queueStatus {
-	case models.QueueStatusReady:
-		err = e.redisStore.SetAndEnqueue(ctx, mKey, b, qKey, m.ID)
-		if err != nil {
-			return errors.Wrapf(err, "failed to set and enqueue message for sink: %s", m.SinkID)
-		}
+	case models.QueueStatusReady, models.QueueStatusProcessing:
 	case models.QueueStatusScheduled:
-		err = e.redisStore.SetAndZAdd(ctx, mKey, b, qKey, m.ID, float64(m.DeliverAfter.Unix()))
+		score := float64(m.DeliverAfter.Unix())
+		entry.Score = &score
+	default:
+		return nil, fmt.Errorf("unexpected queue status %s", queueStatus)
+	}
+
+	return entry, nil
+}
+
+// ingestEntries returns the ingested requests referenced by the messages. The request data is read from the first message referencing each ingested request.
+func (e *messageEnqueuer) ingestEntries(messages []*models.Message) ([]*models.IngestEntry, error) {
+	ingestEntries := []*models.IngestEntry{}
+	entriesByKey := map[string]*models.IngestEntry{}
+
+	for _, m := range messages {
+		if m.IngestID == "" {
+			continue
+		}
+
+		iKey := ingestKey(m.FlowID, m.IngestID)
+		if ingestEntry, ok := entriesByKey[iKey]; ok {
+			ingestEntry.Refs++
+			continue
+		}
+
+		b, err := encodeIngestedRequest(e.codec, m)
 		if err != nil {
-			return errors.Wrapf(err, "failed to set and enqueue message for sink: %s", m.SinkID)
+			return nil, errors.Wrapf(err, "failed to encode ingested request")
 		}
-	default:
-		return fmt.Errorf("unexpected queue status %s", queueStatus)
+
+		ingestEntry := &models.IngestEntry{IngestKey: iKey, Value: b, Refs: 1}
+		entriesByKey[iKey] = ingestEntry
+		ingestEntries = append(ingestEntries, ingestEntry)
+	}
+
+	return ingestEntries, nil
+}
+
+// offloadPayloads stores the payloads above the threshold in the payload store and sets the messages payload refs. It returns the keys of the stored payloads.
+func (e *messageEnqueuer) offloadPayloads(ctx context.Context, messages []*models.Message) ([]string, error) {
+	payloadKeys := []string{}
+	if e.payloadStore == nil {
+		return payloadKeys, nil
 	}
 
-	return nil
+	stored := map[string]bool{}
+	for _, m := range messages {
+		if m.PayloadRef != "" || len(m.Payload) <= e.payloadStoreThreshold {
+			continue
+		}
+
+		key := payloadKey(m)
+		if !stored[key] {
+			b, err := e.codec.EncodeBlob(m.Payload)
+			if err != nil {
+				e.deletePayloads(ctx, payloadKeys)
+				return nil, errors.Wrapf(err, "failed to encode payload")
+			}
+
+			err = e.payloadStore.Put(ctx, key, b)
+			if err != nil {
+				e.deletePayloads(ctx, payloadKeys)
+				return nil, errors.Wrapf(err, "failed to store payload")
+			}
+
+			stored[key] = true
+			payloadKeys = append(payloadKeys, key)
+		}
+
+		m.PayloadRef = key
+	}
+
+	return payloadKeys, nil
+}
+
+// deletePayloads deletes the payloads stored for messages that could not be enqueued. Errors are ignored as the enqueue error is returned.
+func (e *messageEnqueuer) deletePayloads(ctx context.Context, payloadKeys []string) {
+	for _, key := range payloadKeys {
+		_ = e.payloadStore.Delete(ctx, key)
+	}
 }
 
 func getQueueStatus(m *models.Message, now time.Time) models.QueueStatus {
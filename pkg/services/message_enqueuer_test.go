@@ -3,9 +3,12 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"net/http"
 	"testing"
 	"time"
 
+	"github.com/didil/inhooks/pkg/lib"
 	"github.com/didil/inhooks/pkg/models"
 	"github.com/didil/inhooks/pkg/testsupport/mocks"
 	"github.com/golang/mock/gomock"
@@ -22,9 +25,12 @@ func TestMessageEnqueuer(t *testing.T) {
 
 	timeSvc := mocks.NewMockTimeService(ctrl)
 	now := time.Date(2023, 05, 5, 8, 9, 12, 0, time.UTC)
-	timeSvc.EXPECT().Now().Times(2).Return(now)
+	timeSvc.EXPECT().Now().Times(4).Return(now)
+
+	codec, err := NewRecordCodec(&lib.StorageConfig{})
+	assert.NoError(t, err)
 
-	messageEnqueuer := NewMessageEnqueuer(redisStore, timeSvc)
+	messageEnqueuer := NewMessageEnqueuer(redisStore, timeSvc, codec, nil, 0)
 
 	m1ID := "a5b6e039-f368-46fd-b0ed-ec9c68932179"
 	m1 := &models.Message{
@@ -42,11 +48,6 @@ func TestMessageEnqueuer(t *testing.T) {
 	m1Bytes, err := json.Marshal(&m1)
 	assert.NoError(t, err)
 
-	redisStore.EXPECT().
-		SetAndEnqueue(ctx, messageKey1, m1Bytes, queueKey1, m1ID).
-		Times(1).
-		Return(nil)
-
 	m2ID := "6e41b51c-1b90-4b0e-8504-3d0e633f8043"
 	m2 := &models.Message{
 		ID:           m2ID,
@@ -63,8 +64,15 @@ func TestMessageEnqueuer(t *testing.T) {
 	m2Bytes, err := json.Marshal(&m2)
 	assert.NoError(t, err)
 
+	m2Score := float64(m2.DeliverAfter.Unix())
+
+	entries := []*models.EnqueueEntry{
+		{MessageKey: messageKey1, Value: m1Bytes, QueueKey: queueKey1, MessageID: m1ID},
+		{MessageKey: messageKey2, Value: m2Bytes, QueueKey: queueKey2, MessageID: m2ID, Score: &m2Score},
+	}
+
 	redisStore.EXPECT().
-		SetAndZAdd(ctx, messageKey2, m2Bytes, queueKey2, m2ID, float64(m2.DeliverAfter.Unix())).
+		SetAndEnqueueBatch(ctx, []*models.IngestEntry{}, entries).
 		Times(1).
 		Return(nil)
 
@@ -77,4 +85,199 @@ func TestMessageEnqueuer(t *testing.T) {
 	}
 
 	assert.Equal(t, expectedInfos, queuedInfos)
+
+	// the marker is set in the same transaction
+	marker := &models.EnqueueMarker{Key: "src:source-1:cron:1683252000:done", Value: []byte("2023-05-05T02:00:00Z"), TTL: 24 * time.Hour}
+	redisStore.EXPECT().
+		SetAndEnqueueBatchMarked(ctx, []*models.IngestEntry{}, entries, marker).
+		Return(nil)
+
+	queuedInfos, err = messageEnqueuer.EnqueueMarked(ctx, []*models.Message{m1, m2}, marker)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedInfos, queuedInfos)
+}
+
+func TestMessageEnqueuer_IngestedRequest(t *testing.T) {
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	redisStore := mocks.NewMockRedisStore(ctrl)
+
+	timeSvc := mocks.NewMockTimeService(ctrl)
+	now := time.Date(2023, 05, 5, 8, 9, 12, 0, time.UTC)
+	timeSvc.EXPECT().Now().Times(2).Return(now)
+
+	codec, err := NewRecordCodec(&lib.StorageConfig{})
+	assert.NoError(t, err)
+
+	messageEnqueuer := NewMessageEnqueuer(redisStore, timeSvc, codec, nil, 0)
+
+	ingestID := "c7d5f3b8-6a2e-4f0e-9a5b-0d7c8e1f2a3b"
+	messages := []*models.Message{}
+	for _, sinkID := range []string{"sink-1", "sink-2"} {
+		messages = append(messages, &models.Message{
+			ID:            "message-" + sinkID,
+			FlowID:        "flow-1",
+			SourceID:      "source-1",
+			IngestedReqID: "req-1",
+			IngestID:      ingestID,
+			SinkID:        sinkID,
+			HttpMethod:    http.MethodPost,
+			HttpHeaders:   http.Header{"Content-Type": []string{"application/json"}},
+			RawQuery:      "x=123",
+			Payload:       []byte(`{"id":"abc"}`),
+			DeliverAfter:  now,
+		})
+	}
+
+	ingestedRequestBytes, err := json.Marshal(&models.IngestedRequest{
+		IngestedReqID: "req-1",
+		HttpMethod:    http.MethodPost,
+		HttpHeaders:   http.Header{"Content-Type": []string{"application/json"}},
+		RawQuery:      "x=123",
+		Payload:       []byte(`{"id":"abc"}`),
+	})
+	assert.NoError(t, err)
+
+	ingestEntries := []*models.IngestEntry{
+		{IngestKey: "f:flow-1:i:c7d5f3b8-6a2e-4f0e-9a5b-0d7c8e1f2a3b", Value: ingestedRequestBytes, Refs: 2},
+	}
+
+	redisStore.EXPECT().
+		SetAndEnqueueBatch(ctx, ingestEntries, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, ingestEntries []*models.IngestEntry, entries []*models.EnqueueEntry) error {
+			assert.Len(t, entries, 2)
+			for _, entry := range entries {
+				// the request data is not stored in the messages
+				m := &models.Message{}
+				err := json.Unmarshal(entry.Value, m)
+				assert.NoError(t, err)
+				assert.Equal(t, ingestID, m.IngestID)
+				assert.Nil(t, m.Payload)
+				assert.Nil(t, m.HttpHeaders)
+				assert.Equal(t, "", m.RawQuery)
+			}
+			return nil
+		})
+
+	_, err = messageEnqueuer.Enqueue(ctx, messages)
+	assert.NoError(t, err)
+
+	// the messages are not modified
+	assert.Equal(t, []byte(`{"id":"abc"}`), messages[0].Payload)
+}
+
+func TestMessageEnqueuer_OffloadPayload(t *testing.T) {
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	redisStore := mocks.NewMockRedisStore(ctrl)
+	payloadStore := mocks.NewMockPayloadStore(ctrl)
+
+	timeSvc := mocks.NewMockTimeService(ctrl)
+	now := time.Date(2023, 05, 5, 8, 9, 12, 0, time.UTC)
+	timeSvc.EXPECT().Now().AnyTimes().Return(now)
+
+	codec, err := NewRecordCodec(&lib.StorageConfig{})
+	assert.NoError(t, err)
+
+	messageEnqueuer := NewMessageEnqueuer(redisStore, timeSvc, codec, payloadStore, 10)
+
+	ingestID := "c7d5f3b8-6a2e-4f0e-9a5b-0d7c8e1f2a3b"
+	payloadRef := "flow-1/i/c7d5f3b8-6a2e-4f0e-9a5b-0d7c8e1f2a3b"
+	payload := []byte(`{"id":"abcdefghijkl"}`)
+	// offloaded payloads are always marked
+	storedPayload := append([]byte{recordMarker, recordFormatRaw}, payload...)
+
+	newMessages := func() []*models.Message {
+		messages := []*models.Message{}
+		for _, sinkID := range []string{"sink-1", "sink-2"} {
+			messages = append(messages, &models.Message{
+				ID:           "message-" + sinkID,
+				FlowID:       "flow-1",
+				IngestID:     ingestID,
+				SinkID:       sinkID,
+				Payload:      payload,
+				DeliverAfter: now,
+			})
+		}
+		return messages
+	}
+
+	// the payload is stored once for all the sinks
+	payloadStore.EXPECT().Put(ctx, payloadRef, storedPayload).Return(nil)
+	redisStore.EXPECT().
+		SetAndEnqueueBatch(ctx, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, ingestEntries []*models.IngestEntry, entries []*models.EnqueueEntry) error {
+			assert.Len(t, ingestEntries, 1)
+			r := &models.IngestedRequest{}
+			err := json.Unmarshal(ingestEntries[0].Value, r)
+			assert.NoError(t, err)
+			assert.Nil(t, r.Payload)
+
+			assert.Len(t, entries, 2)
+			for _, entry := range entries {
+				m := &models.Message{}
+				err := json.Unmarshal(entry.Value, m)
+				assert.NoError(t, err)
+				assert.Equal(t, payloadRef, m.PayloadRef)
+			}
+			return nil
+		})
+
+	messages := newMessages()
+	_, err = messageEnqueuer.Enqueue(ctx, messages)
+	assert.NoError(t, err)
+	assert.Equal(t, payload, messages[0].Payload)
+
+	// the payload is deleted when the messages can't be enqueued
+	payloadStore.EXPECT().Put(ctx, payloadRef, storedPayload).Return(nil)
+	redisStore.EXPECT().SetAndEnqueueBatch(ctx, gomock.Any(), gomock.Any()).Return(fmt.Errorf("redis error"))
+	payloadStore.EXPECT().Delete(ctx, payloadRef).Return(nil)
+
+	_, err = messageEnqueuer.Enqueue(ctx, newMessages())
+	assert.ErrorContains(t, err, "redis error")
+}
+
+func TestMessageEnqueuer_DeliverInline(t *testing.T) {
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	redisStore := mocks.NewMockRedisStore(ctrl)
+
+	timeSvc := mocks.NewMockTimeService(ctrl)
+	now := time.Date(2023, 05, 5, 8, 9, 12, 0, time.UTC)
+	timeSvc.EXPECT().Now().Return(now)
+
+	codec, err := NewRecordCodec(&lib.StorageConfig{})
+	assert.NoError(t, err)
+
+	messageEnqueuer := NewMessageEnqueuer(redisStore, timeSvc, codec, nil, 0)
+
+	// message delivered inline to a sync sink
+	m := &models.Message{
+		ID:            "message-1",
+		FlowID:        "flow-1",
+		SinkID:        "sink-1",
+		DeliverInline: true,
+	}
+
+	redisStore.EXPECT().
+		SetAndEnqueueBatch(ctx, []*models.IngestEntry{}, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, ingestEntries []*models.IngestEntry, entries []*models.EnqueueEntry) error {
+			assert.Len(t, entries, 1)
+			assert.Equal(t, "f:flow-1:s:sink-1:q:processing", entries[0].QueueKey)
+			assert.Nil(t, entries[0].Score)
+			return nil
+		})
+
+	queuedInfos, err := messageEnqueuer.Enqueue(ctx, []*models.Message{m})
+	assert.NoError(t, err)
+	assert.Equal(t, models.QueueStatusProcessing, queuedInfos[0].QueueStatus)
 }
@@ -2,7 +2,6 @@ package services
 
 import (
 	"context"
-	"encoding/json"
 	"time"
 
 	"github.com/didil/inhooks/pkg/models"
@@ -13,16 +12,20 @@ type MessageFetcher interface {
 	GetMessageForProcessing(ctx context.Context, timeout time.Duration, flowID string, sinkID string) (*models.Message, error)
 }
 
-func NewMessageFetcher(redisStore RedisStore, timeSvc TimeService) MessageFetcher {
+func NewMessageFetcher(redisStore RedisStore, timeSvc TimeService, codec RecordCodec, payloadStore PayloadStore) MessageFetcher {
 	return &messageFetcher{
-		redisStore: redisStore,
-		timeSvc:    timeSvc,
+		redisStore:   redisStore,
+		timeSvc:      timeSvc,
+		codec:        codec,
+		payloadStore: payloadStore,
 	}
 }
 
 type messageFetcher struct {
-	redisStore RedisStore
-	timeSvc    TimeService
+	redisStore   RedisStore
+	timeSvc      TimeService
+	codec        RecordCodec
+	payloadStore PayloadStore
 }
 
 func (f *messageFetcher) GetMessageForProcessing(ctx context.Context, timeout time.Duration, flowID string, sinkID string) (*models.Message, error) {
@@ -44,11 +47,20 @@ func (f *messageFetcher) GetMessageForProcessing(ctx context.Context, timeout ti
 		return nil, errors.Wrapf(err, "failed to redis get. flow: %s sink: %s", flowID, sinkID)
 	}
 
-	var m models.Message
-	err = json.Unmarshal(b, &m)
+	m, err := decodeMessage(f.codec, b)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to unmarshal message. m: %s", string(b))
+		return nil, errors.Wrapf(err, "failed to decode message. flow: %s sink: %s id: %s", flowID, sinkID, mID)
 	}
 
-	return &m, nil
+	err = loadIngestedRequest(ctx, f.redisStore, f.codec, m)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load ingested request. flow: %s sink: %s", flowID, sinkID)
+	}
+
+	err = loadPayload(ctx, f.payloadStore, f.codec, m)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load payload. flow: %s sink: %s", flowID, sinkID)
+	}
+
+	return m, nil
 }
@@ -3,9 +3,11 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"net/http"
 	"testing"
 	"time"
 
+	"github.com/didil/inhooks/pkg/lib"
 	"github.com/didil/inhooks/pkg/models"
 	"github.com/didil/inhooks/pkg/testsupport/mocks"
 	"github.com/golang/mock/gomock"
@@ -21,7 +23,10 @@ func TestMessageFetcher(t *testing.T) {
 	redisStore := mocks.NewMockRedisStore(ctrl)
 	timeSvc := mocks.NewMockTimeService(ctrl)
 
-	messageFetcher := NewMessageFetcher(redisStore, timeSvc)
+	codec, err := NewRecordCodec(&lib.StorageConfig{})
+	assert.NoError(t, err)
+
+	messageFetcher := NewMessageFetcher(redisStore, timeSvc, codec, nil)
 
 	mID := "8d291081-a0ea-4511-9445-35f231d1c676"
 	flowID := "flow-1"
@@ -41,3 +46,99 @@ func TestMessageFetcher(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, message, m)
 }
+
+func TestMessageFetcher_IngestedRequest(t *testing.T) {
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	redisStore := mocks.NewMockRedisStore(ctrl)
+	timeSvc := mocks.NewMockTimeService(ctrl)
+
+	codec, err := NewRecordCodec(&lib.StorageConfig{})
+	assert.NoError(t, err)
+
+	messageFetcher := NewMessageFetcher(redisStore, timeSvc, codec, nil)
+
+	mID := "8d291081-a0ea-4511-9445-35f231d1c676"
+	timeout := 1 * time.Second
+
+	messageBytes, err := json.Marshal(&models.Message{ID: mID, FlowID: "flow-1", SinkID: "sink-1", IngestID: "ingest-1"})
+	assert.NoError(t, err)
+
+	ingestedRequest := &models.IngestedRequest{
+		IngestedReqID: "req-1",
+		HttpMethod:    http.MethodPost,
+		HttpHeaders:   http.Header{"Content-Type": []string{"application/json"}},
+		RawQuery:      "x=123",
+		PathSuffix:    "/events",
+		Payload:       []byte(`{"id":"abc"}`),
+	}
+	ingestedRequestBytes, err := json.Marshal(ingestedRequest)
+	assert.NoError(t, err)
+
+	redisStore.EXPECT().BLMove(ctx, timeout, "f:flow-1:s:sink-1:q:ready", "f:flow-1:s:sink-1:q:processing").Return([]byte(mID), nil)
+	redisStore.EXPECT().Get(ctx, "f:flow-1:s:sink-1:m:8d291081-a0ea-4511-9445-35f231d1c676").Return(messageBytes, nil)
+	redisStore.EXPECT().GetIngest(ctx, "f:flow-1:i:ingest-1").Return(ingestedRequestBytes, nil)
+
+	m, err := messageFetcher.GetMessageForProcessing(ctx, timeout, "flow-1", "sink-1")
+	assert.NoError(t, err)
+	assert.Equal(t, mID, m.ID)
+	assert.Equal(t, ingestedRequest.HttpMethod, m.HttpMethod)
+	assert.Equal(t, ingestedRequest.HttpHeaders, m.HttpHeaders)
+	assert.Equal(t, ingestedRequest.RawQuery, m.RawQuery)
+	assert.Equal(t, ingestedRequest.PathSuffix, m.PathSuffix)
+	assert.Equal(t, ingestedRequest.Payload, m.Payload)
+
+	// missing ingested request
+	redisStore.EXPECT().BLMove(ctx, timeout, "f:flow-1:s:sink-1:q:ready", "f:flow-1:s:sink-1:q:processing").Return([]byte(mID), nil)
+	redisStore.EXPECT().Get(ctx, "f:flow-1:s:sink-1:m:8d291081-a0ea-4511-9445-35f231d1c676").Return(messageBytes, nil)
+	redisStore.EXPECT().GetIngest(ctx, "f:flow-1:i:ingest-1").Return(nil, nil)
+
+	_, err = messageFetcher.GetMessageForProcessing(ctx, timeout, "flow-1", "sink-1")
+	assert.ErrorContains(t, err, "ingested request not found: f:flow-1:i:ingest-1")
+}
+
+func TestMessageFetcher_PayloadRef(t *testing.T) {
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	redisStore := mocks.NewMockRedisStore(ctrl)
+	payloadStore := mocks.NewMockPayloadStore(ctrl)
+	timeSvc := mocks.NewMockTimeService(ctrl)
+
+	codec, err := NewRecordCodec(&lib.StorageConfig{Compression: lib.StorageCompressionZstd})
+	assert.NoError(t, err)
+
+	messageFetcher := NewMessageFetcher(redisStore, timeSvc, codec, payloadStore)
+
+	mID := "8d291081-a0ea-4511-9445-35f231d1c676"
+	timeout := 1 * time.Second
+
+	messageBytes, err := json.Marshal(&models.Message{ID: mID, FlowID: "flow-1", SinkID: "sink-1", PayloadRef: "flow-1/s/sink-1/m/8d291081-a0ea-4511-9445-35f231d1c676"})
+	assert.NoError(t, err)
+
+	payload := []byte(`{"id":"abc"}`)
+	storedPayload, err := codec.Encode(payload)
+	assert.NoError(t, err)
+
+	redisStore.EXPECT().BLMove(ctx, timeout, "f:flow-1:s:sink-1:q:ready", "f:flow-1:s:sink-1:q:processing").Return([]byte(mID), nil)
+	redisStore.EXPECT().Get(ctx, "f:flow-1:s:sink-1:m:8d291081-a0ea-4511-9445-35f231d1c676").Return(messageBytes, nil)
+	payloadStore.EXPECT().Get(ctx, "flow-1/s/sink-1/m/8d291081-a0ea-4511-9445-35f231d1c676").Return(storedPayload, nil)
+
+	m, err := messageFetcher.GetMessageForProcessing(ctx, timeout, "flow-1", "sink-1")
+	assert.NoError(t, err)
+	assert.Equal(t, payload, m.Payload)
+
+	// payload store not configured
+	messageFetcher = NewMessageFetcher(redisStore, timeSvc, codec, nil)
+
+	redisStore.EXPECT().BLMove(ctx, timeout, "f:flow-1:s:sink-1:q:ready", "f:flow-1:s:sink-1:q:processing").Return([]byte(mID), nil)
+	redisStore.EXPECT().Get(ctx, "f:flow-1:s:sink-1:m:8d291081-a0ea-4511-9445-35f231d1c676").Return(messageBytes, nil)
+
+	_, err = messageFetcher.GetMessageForProcessing(ctx, timeout, "flow-1", "sink-1")
+	assert.ErrorContains(t, err, "payload store not configured")
+}
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 
 	"github.com/didil/inhooks/pkg/models"
 	"github.com/didil/inhooks/pkg/version"
@@ -13,8 +15,13 @@ import (
 
 type MessageProcessor interface {
 	Process(ctx context.Context, sink *models.Sink, m *models.Message) error
+	// ProcessWithResponse processes the message and returns the sink response, including error statuses
+	ProcessWithResponse(ctx context.Context, sink *models.Sink, m *models.Message) (*models.SinkResponse, error)
 }
 
+// max size of the sink response bodies returned by ProcessWithResponse
+const maxSinkResponseBodyBytes = 10 << 20
+
 type messageProcessor struct {
 	httpClient *http.Client
 }
@@ -26,40 +33,105 @@ func NewMessageProcessor(httpClient *http.Client) MessageProcessor {
 }
 
 func (p *messageProcessor) Process(ctx context.Context, sink *models.Sink, m *models.Message) error {
+	_, err := p.process(ctx, sink, m, false)
+	return err
+}
+
+func (p *messageProcessor) ProcessWithResponse(ctx context.Context, sink *models.Sink, m *models.Message) (*models.SinkResponse, error) {
+	return p.process(ctx, sink, m, true)
+}
+
+func (p *messageProcessor) process(ctx context.Context, sink *models.Sink, m *models.Message, withResponse bool) (*models.SinkResponse, error) {
 	switch sink.Type {
 	case models.SinkTypeHttp:
-		return p.processHTTP(ctx, sink, m)
+		return p.processHTTP(ctx, sink, m, withResponse)
 	default:
-		return fmt.Errorf("unkown sink type %s", sink.Type)
+		return nil, fmt.Errorf("unkown sink type %s", sink.Type)
 	}
 }
 
-func (p *messageProcessor) processHTTP(ctx context.Context, sink *models.Sink, m *models.Message) error {
+func (p *messageProcessor) processHTTP(ctx context.Context, sink *models.Sink, m *models.Message, withResponse bool) (*models.SinkResponse, error) {
 	buf := bytes.NewBuffer(m.Payload)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sink.URL, buf)
+	sinkURL, err := p.sinkURL(sink, m)
 	if err != nil {
-		return errors.Wrapf(err, "failed to build http request")
+		return nil, errors.Wrapf(err, "failed to build sink url")
 	}
 
-	req.Header = m.HttpHeaders
+	req, err := http.NewRequestWithContext(ctx, p.httpMethod(sink, m), sinkURL, buf)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to build http request")
+	}
+
+	// headers might be shared between messages, clone them before modifying
+	req.Header = m.HttpHeaders.Clone()
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
 	if m.RawQuery != "" {
 		req.URL.RawQuery = m.RawQuery
 	}
 
 	req.Header["User-Agent"] = []string{p.userAgent()}
 
+	if sink.ForwardMetadata {
+		// the sender cannot set the metadata headers
+		for _, k := range models.RequestMetadataHeaders {
+			req.Header.Del(k)
+		}
+		for k, v := range m.RequestMetadata().Headers() {
+			req.Header[k] = v
+		}
+	}
+
 	resp, err := p.httpClient.Do(req)
 	if err != nil {
-		return errors.Wrapf(err, "failed to send http request")
+		return nil, errors.Wrapf(err, "failed to send http request")
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode >= 400 {
-		return errors.Wrapf(err, "http reponse error %d", resp.StatusCode)
+	// sink error statuses are not treated as delivery failures
+	if !withResponse {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSinkResponseBodyBytes+1))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read http response body")
+	}
+	if len(body) > maxSinkResponseBodyBytes {
+		return nil, fmt.Errorf("http response body too large")
 	}
 
-	return nil
+	return &models.SinkResponse{StatusCode: resp.StatusCode, Headers: resp.Header, Body: body}, nil
+}
+
+func (p *messageProcessor) sinkURL(sink *models.Sink, m *models.Message) (string, error) {
+	if sink.PathMode != models.SinkPathModeAppend || m.PathSuffix == "" {
+		return sink.URL, nil
+	}
+
+	u, err := url.Parse(sink.URL)
+	if err != nil {
+		return "", err
+	}
+
+	return u.JoinPath(m.PathSuffix).String(), nil
+}
+
+func (p *messageProcessor) httpMethod(sink *models.Sink, m *models.Message) string {
+	switch sink.Method {
+	case "":
+		return http.MethodPost
+	case models.SinkMethodPreserve:
+		if m.HttpMethod == "" {
+			// messages ingested before the method was recorded
+			return http.MethodPost
+		}
+		return m.HttpMethod
+	default:
+		return sink.Method
+	}
 }
 
 func (p *messageProcessor) userAgent() string {
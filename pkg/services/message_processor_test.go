@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/didil/inhooks/pkg/models"
 	"github.com/didil/inhooks/pkg/version"
@@ -60,6 +61,171 @@ func TestMessageProcessor(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestMessageProcessor_Method(t *testing.T) {
+	ctx := context.Background()
+	cl := &http.Client{}
+	p := NewMessageProcessor(cl)
+
+	testCases := []struct {
+		sinkMethod     string
+		messageMethod  string
+		expectedMethod string
+	}{
+		{sinkMethod: "", messageMethod: http.MethodPut, expectedMethod: http.MethodPost},
+		{sinkMethod: http.MethodPatch, messageMethod: http.MethodPut, expectedMethod: http.MethodPatch},
+		{sinkMethod: models.SinkMethodPreserve, messageMethod: http.MethodPut, expectedMethod: http.MethodPut},
+		{sinkMethod: models.SinkMethodPreserve, messageMethod: "", expectedMethod: http.MethodPost},
+	}
+
+	for _, tc := range testCases {
+		var receivedMethod string
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			receivedMethod = req.Method
+		}))
+
+		sink := &models.Sink{
+			Type:   "http",
+			URL:    s.URL,
+			Method: tc.sinkMethod,
+		}
+
+		m := &models.Message{
+			HttpMethod:  tc.messageMethod,
+			HttpHeaders: http.Header{},
+		}
+
+		err := p.Process(ctx, sink, m)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expectedMethod, receivedMethod)
+
+		s.Close()
+	}
+}
+
+func TestMessageProcessor_PathSuffix(t *testing.T) {
+	ctx := context.Background()
+	cl := &http.Client{}
+	p := NewMessageProcessor(cl)
+
+	var receivedPath string
+	var receivedQuery string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		receivedPath = req.URL.EscapedPath()
+		receivedQuery = req.URL.RawQuery
+	}))
+	defer s.Close()
+
+	m := &models.Message{
+		HttpHeaders: http.Header{},
+		RawQuery:    "k1=v1",
+		PathSuffix:  "/orders/a%2Fb",
+	}
+
+	sink := &models.Sink{
+		Type:     "http",
+		URL:      s.URL + "/hooks/",
+		PathMode: models.SinkPathModeAppend,
+	}
+
+	err := p.Process(ctx, sink, m)
+	assert.NoError(t, err)
+	assert.Equal(t, "/hooks/orders/a%2Fb", receivedPath)
+	assert.Equal(t, "k1=v1", receivedQuery)
+
+	sink.PathMode = models.SinkPathModeIgnore
+
+	err = p.Process(ctx, sink, m)
+	assert.NoError(t, err)
+	assert.Equal(t, "/hooks/", receivedPath)
+}
+
+func TestMessageProcessor_ProcessWithResponse(t *testing.T) {
+	ctx := context.Background()
+	cl := &http.Client{}
+	p := NewMessageProcessor(cl)
+
+	statusCode := http.StatusCreated
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		_, err := w.Write([]byte(`{"text":"ok"}`))
+		assert.NoError(t, err)
+	}))
+	defer s.Close()
+
+	sink := &models.Sink{
+		Type: "http",
+		URL:  s.URL,
+	}
+
+	m := &models.Message{
+		HttpHeaders: http.Header{},
+	}
+
+	resp, err := p.ProcessWithResponse(ctx, sink, m)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Headers.Get("Content-Type"))
+	assert.Equal(t, []byte(`{"text":"ok"}`), resp.Body)
+
+	statusCode = http.StatusInternalServerError
+	resp, err = p.ProcessWithResponse(ctx, sink, m)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, []byte(`{"text":"ok"}`), resp.Body)
+
+	err = p.Process(ctx, sink, m)
+	assert.NoError(t, err)
+}
+
+func TestMessageProcessor_ForwardMetadata(t *testing.T) {
+	version.SetVersion("test")
+
+	ctx := context.Background()
+	cl := &http.Client{}
+	p := NewMessageProcessor(cl)
+
+	var receivedHeaders http.Header
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		receivedHeaders = req.Header
+	}))
+	defer s.Close()
+
+	sink := &models.Sink{
+		Type:            "http",
+		URL:             s.URL,
+		ForwardMetadata: true,
+	}
+
+	headers := http.Header{
+		"X-Key": []string{"123"},
+		// set by the sender
+		"X-Inhooks-Client-Ip": []string{"10.0.0.1"},
+	}
+	m := &models.Message{
+		HttpHeaders: headers,
+		HttpMethod:  http.MethodPut,
+		ReceivedAt:  time.Date(2023, 05, 5, 8, 9, 12, 0, time.UTC),
+		HttpPath:    "/api/v1/ingest/github",
+		UserAgent:   "GitHub-Hookshot/abc",
+		TLSVersion:  "TLS 1.3",
+	}
+
+	err := p.Process(ctx, sink, m)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "123", receivedHeaders.Get("X-Key"))
+	assert.Equal(t, "2023-05-05T08:09:12Z", receivedHeaders.Get("X-Inhooks-Received-At"))
+	assert.Equal(t, http.MethodPut, receivedHeaders.Get("X-Inhooks-Method"))
+	assert.Equal(t, "/api/v1/ingest/github", receivedHeaders.Get("X-Inhooks-Path"))
+	assert.Equal(t, "GitHub-Hookshot/abc", receivedHeaders.Get("X-Inhooks-User-Agent"))
+	assert.Equal(t, "TLS 1.3", receivedHeaders.Get("X-Inhooks-TLS-Version"))
+	// unknown values are not forwarded
+	assert.Empty(t, receivedHeaders.Values("X-Inhooks-Client-IP"))
+	// the message headers are not modified
+	assert.Equal(t, http.Header{"X-Key": []string{"123"}, "X-Inhooks-Client-Ip": []string{"10.0.0.1"}}, m.HttpHeaders)
+}
+
 func TestMessageProcessor_userAgent(t *testing.T) {
 	version.SetVersion("1.2.3")
 
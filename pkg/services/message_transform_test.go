@@ -54,6 +54,39 @@ func TestMessageTransformer_Transform_Javascript(t *testing.T) {
 	assert.Equal(t, http.Header{"Content-Type": []string{"application/json"}, "X-AUTH-TOKEN": []string{"token123"}, "X-Request-Id": []string{"123"}}, m.HttpHeaders)
 }
 
+func TestMessageTransformer_Transform_Javascript_Metadata(t *testing.T) {
+	config := &lib.TransformConfig{
+		JavascriptTimeout: 5000 * time.Millisecond,
+	}
+
+	mt := NewMessageTransformer(config)
+
+	m := &models.Message{
+		Payload:     []byte(`{"id": "abc"}`),
+		HttpHeaders: http.Header{},
+		HttpMethod:  http.MethodPost,
+		ReceivedAt:  time.Date(2023, 05, 5, 8, 9, 12, 0, time.UTC),
+		HttpPath:    "/api/v1/ingest/github",
+		ClientIP:    "140.82.115.10",
+		UserAgent:   "GitHub-Hookshot/abc",
+	}
+	transformDefinition := &models.TransformDefinition{
+		Type: models.TransformTypeJavascript,
+		Script: `
+			function transform(bodyStr, headers, metadata) {
+				const body = JSON.parse(bodyStr);
+				body.receivedAt = metadata.receivedAt;
+				body.source = metadata.method + " " + metadata.path + " from " + metadata.clientIP + " (" + metadata.userAgent + ")";
+				return [JSON.stringify(body), headers];
+			}
+			`,
+	}
+	err := mt.Transform(context.Background(), transformDefinition, m)
+	assert.NoError(t, err)
+
+	assert.JSONEq(t, `{"id":"abc","receivedAt":"2023-05-05T08:09:12Z","source":"POST /api/v1/ingest/github from 140.82.115.10 (GitHub-Hookshot/abc)"}`, string(m.Payload))
+}
+
 func TestMessageTransformer_Transform_Javascript_Error(t *testing.T) {
 	config := &lib.TransformConfig{
 		JavascriptTimeout: 5000 * time.Millisecond,
@@ -29,7 +29,7 @@ func NewMessageTransformer(config *lib.TransformConfig) MessageTransformer {
 func (mt *messageTransformer) Transform(ctx context.Context, transformDefinition *models.TransformDefinition, m *models.Message) error {
 	switch transformDefinition.Type {
 	case models.TransformTypeJavascript:
-		transformedPayload, transformedHeaders, err := mt.runJavascriptTransform(m.Payload, m.HttpHeaders, transformDefinition.Script)
+		transformedPayload, transformedHeaders, err := mt.runJavascriptTransform(m.Payload, m.HttpHeaders, m.RequestMetadata(), transformDefinition.Script)
 		if err != nil {
 			return fmt.Errorf("failed to transform message: %w", err)
 		}
@@ -42,7 +42,7 @@ func (mt *messageTransformer) Transform(ctx context.Context, transformDefinition
 	}
 }
 
-func (mt *messageTransformer) runJavascriptTransform(payload []byte, headers http.Header, jsScript string) ([]byte, http.Header, error) {
+func (mt *messageTransformer) runJavascriptTransform(payload []byte, headers http.Header, metadata *models.RequestMetadata, jsScript string) ([]byte, http.Header, error) {
 	vm := goja.New()
 
 	time.AfterFunc(mt.config.JavascriptTimeout, func() {
@@ -63,6 +63,15 @@ func (mt *messageTransformer) runJavascriptTransform(payload []byte, headers htt
 		return nil, nil, fmt.Errorf("failed to set headersStr: %w", err)
 	}
 
+	metadataStr, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal metadata to JSON: %w", err)
+	}
+	err = vm.Set("metadataStr", string(metadataStr))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to set metadataStr: %w", err)
+	}
+
 	// Prepare the full script
 	fullScript := fmt.Sprintf(`
 		/* User Function */
@@ -70,7 +79,8 @@ func (mt *messageTransformer) runJavascriptTransform(payload []byte, headers htt
 		/* End User Function */
 
 		const headers = JSON.parse(headersStr);
-		var results = transform(bodyStr, headers);
+		const metadata = JSON.parse(metadataStr);
+		var results = transform(bodyStr, headers, metadata);
 		[results[0], results[1]];
 	`, jsScript)
 
@@ -3,10 +3,14 @@ package services
 import (
 	"crypto/hmac"
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
 	"fmt"
 	"hash"
+	"net/http"
+	"net/url"
 	"os"
+	"strings"
 
 	"github.com/didil/inhooks/pkg/models"
 	"github.com/pkg/errors"
@@ -14,6 +18,7 @@ import (
 
 type MessageVerifier interface {
 	Verify(flow *models.Flow, m *models.Message) error
+	StripCredentials(flow *models.Flow, m *models.Message)
 }
 
 type messageVerifier struct {
@@ -31,20 +36,109 @@ func (v *messageVerifier) Verify(flow *models.Flow, m *models.Message) error {
 		return nil
 	}
 
-	if verification.VerificationType == models.VerificationTypeHMAC {
-		signature := []byte(m.HttpHeaders.Get(verification.SignatureHeader))
-		signaturePrefix := verification.SignaturePrefix
-		algorithm := verification.HMACAlgorithm
-		err := v.verifyHMAC(algorithm, signature, signaturePrefix, os.Getenv(verification.CurrentSecretEnvVar), m.Payload)
+	var verifyFunc func(secret string) error
 
-		if err != nil && verification.PreviousSecretEnvVar != "" {
-			// try again with previous secret
-			err = v.verifyHMAC(algorithm, signature, signaturePrefix, os.Getenv(verification.PreviousSecretEnvVar), m.Payload)
+	switch verification.VerificationType {
+	case "":
+		// no verification type set
+		return nil
+	case models.VerificationTypeHMAC:
+		signature := []byte(m.HttpHeaders.Get(verification.SignatureHeader))
+		verifyFunc = func(secret string) error {
+			return v.verifyHMAC(verification.HMACAlgorithm, signature, verification.SignaturePrefix, secret, signedPayload(m))
 		}
-
-		if err != nil {
-			return errors.Wrapf(err, "failed to verify message")
+	case models.VerificationTypeBasicAuth:
+		r := &http.Request{Header: m.HttpHeaders}
+		username, password, ok := r.BasicAuth()
+		verifyFunc = func(secret string) error {
+			if !ok {
+				return errors.New("missing basic auth credentials")
+			}
+			return v.verifyBasicAuth(verification.BasicAuthUsername, secret, username, password)
+		}
+	case models.VerificationTypeToken:
+		token := m.HttpHeaders.Get(verification.SignatureHeader)
+		verifyFunc = func(secret string) error {
+			if secret == "" {
+				// the prefix alone must not be accepted when the secret env var is not set
+				return errors.New("missing expected token")
+			}
+			return v.verifyToken(verification.SignaturePrefix+secret, token)
+		}
+	case models.VerificationTypeQueryToken:
+		query, err := url.ParseQuery(m.RawQuery)
+		verifyFunc = func(secret string) error {
+			if err != nil {
+				return errors.Wrapf(err, "failed to parse query")
+			}
+			return v.verifyToken(secret, query.Get(verification.TokenQueryParam))
 		}
+	default:
+		return fmt.Errorf("unexpected verification type: %s", verification.VerificationType)
+	}
+
+	err := verifyFunc(os.Getenv(verification.CurrentSecretEnvVar))
+	if err != nil && verification.PreviousSecretEnvVar != "" {
+		// try again with previous secret
+		err = verifyFunc(os.Getenv(verification.PreviousSecretEnvVar))
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "failed to verify message")
+	}
+
+	return nil
+}
+
+// StripCredentials removes the verification credentials from the message so that they are not forwarded to the sinks
+func (v *messageVerifier) StripCredentials(flow *models.Flow, m *models.Message) {
+	verification := flow.Source.Verification
+
+	if verification == nil {
+		return
+	}
+
+	switch verification.VerificationType {
+	case models.VerificationTypeBasicAuth:
+		// headers might be shared between messages, clone them before modifying
+		m.HttpHeaders = m.HttpHeaders.Clone()
+		m.HttpHeaders.Del("Authorization")
+	case models.VerificationTypeToken:
+		m.HttpHeaders = m.HttpHeaders.Clone()
+		m.HttpHeaders.Del(verification.SignatureHeader)
+	case models.VerificationTypeQueryToken:
+		m.RawQuery = removeQueryParam(m.RawQuery, verification.TokenQueryParam)
+	}
+}
+
+func (v *messageVerifier) verifyBasicAuth(expectedUsername, expectedPassword string, username, password string) error {
+	if expectedPassword == "" {
+		// an unset secret env var must not accept empty passwords
+		return errors.New("missing expected password")
+	}
+
+	// compare both values to avoid leaking which one is invalid through timing
+	usernameOK := subtle.ConstantTimeCompare([]byte(expectedUsername), []byte(username)) == 1
+	passwordOK := subtle.ConstantTimeCompare([]byte(expectedPassword), []byte(password)) == 1
+
+	if !usernameOK || !passwordOK {
+		return errors.New("invalid basic auth credentials")
+	}
+
+	return nil
+}
+
+func (v *messageVerifier) verifyToken(expectedToken string, token string) error {
+	if expectedToken == "" {
+		return errors.New("missing expected token")
+	}
+
+	if token == "" {
+		return errors.New("missing token")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(expectedToken), []byte(token)) != 1 {
+		return errors.New("invalid token")
 	}
 
 	return nil
@@ -82,3 +176,32 @@ func (v *messageVerifier) verifyHMAC(hmacAlgorithm *models.HMACAlgorithm, signat
 
 	return nil
 }
+
+// removeQueryParam removes a parameter from a raw query while preserving the order and encoding of the other parameters
+func removeQueryParam(rawQuery string, param string) string {
+	if rawQuery == "" {
+		return rawQuery
+	}
+
+	parts := strings.Split(rawQuery, "&")
+	keptParts := make([]string, 0, len(parts))
+	for _, part := range parts {
+		key, _, _ := strings.Cut(part, "=")
+		unescapedKey, err := url.QueryUnescape(key)
+		if err == nil && unescapedKey == param {
+			continue
+		}
+		keptParts = append(keptParts, part)
+	}
+
+	return strings.Join(keptParts, "&")
+}
+
+// signedPayload returns the bytes covered by the request signature
+func signedPayload(m *models.Message) []byte {
+	if m.SignedPayload != nil {
+		return m.SignedPayload
+	}
+
+	return m.Payload
+}
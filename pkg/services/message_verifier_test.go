@@ -43,6 +43,40 @@ func TestMessageVerifier_Verify_OK(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestMessageVerifier_Verify_SignedPayload_OK(t *testing.T) {
+	v := NewMessageVerifier()
+
+	algorithm := models.HMACAlgorithmSHA256
+	signatureHeader := "X-WEBHOOK-HMAC-256"
+	currentSecretEnvVar := "FLOW_VERIF_CURRENT_SECRET"
+	os.Setenv(currentSecretEnvVar, "ABC123456")
+
+	flow := &models.Flow{
+		Source: &models.Source{
+			Verification: &models.Verification{
+				VerificationType:    models.VerificationTypeHMAC,
+				HMACAlgorithm:       &algorithm,
+				SignatureHeader:     signatureHeader,
+				CurrentSecretEnvVar: currentSecretEnvVar,
+			},
+		},
+	}
+
+	expectedSignature := "b5dbd4567522ac835856391a2f1aaf41a2ea64a5167cf1886cdc974f799f4976"
+
+	headers := http.Header{}
+	headers.Add(signatureHeader, string(expectedSignature))
+
+	m := &models.Message{
+		HttpHeaders:   headers,
+		Payload:       []byte("decompressed-payload"),
+		SignedPayload: []byte("test-payload"),
+	}
+
+	err := v.Verify(flow, m)
+	assert.NoError(t, err)
+}
+
 func TestMessageVerifier_Verify_PrevSecret_OK(t *testing.T) {
 	v := NewMessageVerifier()
 
@@ -147,3 +181,239 @@ func TestMessageVerifier_Verify_Failed(t *testing.T) {
 	err = v.Verify(flow, m)
 	assert.EqualError(t, err, "failed to verify message: invalid signature")
 }
+
+func TestMessageVerifier_Verify_BasicAuth_OK(t *testing.T) {
+	v := NewMessageVerifier()
+
+	currentSecretEnvVar := "FLOW_VERIF_CURRENT_SECRET"
+	os.Setenv(currentSecretEnvVar, "my-password")
+
+	flow := &models.Flow{
+		Source: &models.Source{
+			Verification: &models.Verification{
+				VerificationType:    models.VerificationTypeBasicAuth,
+				BasicAuthUsername:   "my-user",
+				CurrentSecretEnvVar: currentSecretEnvVar,
+			},
+		},
+	}
+
+	headers := http.Header{}
+	headers.Add("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte("my-user:my-password")))
+
+	m := &models.Message{
+		HttpHeaders: headers,
+		Payload:     []byte("test-payload"),
+	}
+
+	err := v.Verify(flow, m)
+	assert.NoError(t, err)
+}
+
+func TestMessageVerifier_Verify_BasicAuth_Failed(t *testing.T) {
+	v := NewMessageVerifier()
+
+	currentSecretEnvVar := "FLOW_VERIF_CURRENT_SECRET"
+	os.Setenv(currentSecretEnvVar, "my-password")
+
+	flow := &models.Flow{
+		Source: &models.Source{
+			Verification: &models.Verification{
+				VerificationType:    models.VerificationTypeBasicAuth,
+				BasicAuthUsername:   "my-user",
+				CurrentSecretEnvVar: currentSecretEnvVar,
+			},
+		},
+	}
+
+	headers := http.Header{}
+	headers.Add("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte("my-user:wrong-password")))
+
+	m := &models.Message{
+		HttpHeaders: headers,
+	}
+
+	err := v.Verify(flow, m)
+	assert.EqualError(t, err, "failed to verify message: invalid basic auth credentials")
+
+	m = &models.Message{
+		HttpHeaders: http.Header{},
+	}
+
+	err = v.Verify(flow, m)
+	assert.EqualError(t, err, "failed to verify message: missing basic auth credentials")
+}
+
+func TestMessageVerifier_Verify_BasicAuth_EmptySecret(t *testing.T) {
+	v := NewMessageVerifier()
+
+	currentSecretEnvVar := "FLOW_VERIF_UNSET_SECRET"
+	os.Unsetenv(currentSecretEnvVar)
+
+	flow := &models.Flow{
+		Source: &models.Source{
+			Verification: &models.Verification{
+				VerificationType:    models.VerificationTypeBasicAuth,
+				BasicAuthUsername:   "my-user",
+				CurrentSecretEnvVar: currentSecretEnvVar,
+			},
+		},
+	}
+
+	// an empty password does not match an unset secret
+	headers := http.Header{}
+	headers.Add("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte("my-user:")))
+
+	m := &models.Message{
+		HttpHeaders: headers,
+	}
+
+	err := v.Verify(flow, m)
+	assert.EqualError(t, err, "failed to verify message: missing expected password")
+
+	// same for tokens
+	flow.Source.Verification = &models.Verification{
+		VerificationType:    models.VerificationTypeToken,
+		SignatureHeader:     "Authorization",
+		SignaturePrefix:     "Bearer ",
+		CurrentSecretEnvVar: currentSecretEnvVar,
+	}
+	m = &models.Message{
+		HttpHeaders: http.Header{"Authorization": []string{"Bearer "}},
+	}
+
+	err = v.Verify(flow, m)
+	assert.EqualError(t, err, "failed to verify message: missing expected token")
+}
+
+func TestMessageVerifier_Verify_Token_PrevSecret_OK(t *testing.T) {
+	v := NewMessageVerifier()
+
+	signatureHeader := "Authorization"
+	currentSecretEnvVar := "FLOW_VERIF_CURRENT_SECRET"
+	previousSecretEnvVar := "FLOW_VERIF_PREVIOUS_SECRET"
+	os.Setenv(currentSecretEnvVar, "ABC123")
+	os.Setenv(previousSecretEnvVar, "XYZ789")
+
+	flow := &models.Flow{
+		Source: &models.Source{
+			Verification: &models.Verification{
+				VerificationType:     models.VerificationTypeToken,
+				SignatureHeader:      signatureHeader,
+				SignaturePrefix:      "Bearer ",
+				CurrentSecretEnvVar:  currentSecretEnvVar,
+				PreviousSecretEnvVar: previousSecretEnvVar,
+			},
+		},
+	}
+
+	headers := http.Header{}
+	headers.Add(signatureHeader, "Bearer XYZ789")
+
+	m := &models.Message{
+		HttpHeaders: headers,
+	}
+
+	err := v.Verify(flow, m)
+	assert.NoError(t, err)
+}
+
+func TestMessageVerifier_Verify_Token_Failed(t *testing.T) {
+	v := NewMessageVerifier()
+
+	signatureHeader := "X-Webhook-Token"
+	currentSecretEnvVar := "FLOW_VERIF_CURRENT_SECRET"
+	os.Setenv(currentSecretEnvVar, "ABC123")
+
+	flow := &models.Flow{
+		Source: &models.Source{
+			Verification: &models.Verification{
+				VerificationType:    models.VerificationTypeToken,
+				SignatureHeader:     signatureHeader,
+				CurrentSecretEnvVar: currentSecretEnvVar,
+			},
+		},
+	}
+
+	headers := http.Header{}
+	headers.Add(signatureHeader, "ABC1234")
+
+	m := &models.Message{
+		HttpHeaders: headers,
+	}
+
+	err := v.Verify(flow, m)
+	assert.EqualError(t, err, "failed to verify message: invalid token")
+}
+
+func TestMessageVerifier_Verify_QueryToken(t *testing.T) {
+	v := NewMessageVerifier()
+
+	currentSecretEnvVar := "FLOW_VERIF_CURRENT_SECRET"
+	os.Setenv(currentSecretEnvVar, "ABC 123")
+
+	flow := &models.Flow{
+		Source: &models.Source{
+			Verification: &models.Verification{
+				VerificationType:    models.VerificationTypeQueryToken,
+				TokenQueryParam:     "token",
+				CurrentSecretEnvVar: currentSecretEnvVar,
+			},
+		},
+	}
+
+	m := &models.Message{
+		HttpHeaders: http.Header{},
+		RawQuery:    "a=1&token=ABC%20123",
+	}
+
+	err := v.Verify(flow, m)
+	assert.NoError(t, err)
+
+	m.RawQuery = "a=1"
+	err = v.Verify(flow, m)
+	assert.EqualError(t, err, "failed to verify message: missing token")
+}
+
+func TestMessageVerifier_StripCredentials(t *testing.T) {
+	v := NewMessageVerifier()
+
+	headers := http.Header{}
+	headers.Add("Authorization", "Bearer XYZ789")
+	headers.Add("X-Other", "abc")
+
+	m1 := &models.Message{
+		HttpHeaders: headers,
+		RawQuery:    "z=1&token=ABC&a=%20b",
+	}
+	m2 := &models.Message{
+		HttpHeaders: headers,
+		RawQuery:    "z=1&token=ABC&a=%20b",
+	}
+
+	tokenFlow := &models.Flow{
+		Source: &models.Source{
+			Verification: &models.Verification{
+				VerificationType: models.VerificationTypeToken,
+				SignatureHeader:  "Authorization",
+			},
+		},
+	}
+
+	v.StripCredentials(tokenFlow, m1)
+	assert.Equal(t, http.Header{"X-Other": []string{"abc"}}, m1.HttpHeaders)
+	// headers shared with other messages are not modified
+	assert.Equal(t, "Bearer XYZ789", m2.HttpHeaders.Get("Authorization"))
+
+	queryTokenFlow := &models.Flow{
+		Source: &models.Source{
+			Verification: &models.Verification{
+				VerificationType: models.VerificationTypeQueryToken,
+				TokenQueryParam:  "token",
+			},
+		},
+	}
+
+	v.StripCredentials(queryTokenFlow, m2)
+	assert.Equal(t, "z=1&a=%20b", m2.RawQuery)
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/didil/inhooks/pkg/lib"
+	"github.com/pkg/errors"
+)
+
+var ErrPayloadNotFound = errors.New("payload not found")
+
+// PayloadStore stores the payloads too large to be kept in redis
+type PayloadStore interface {
+	Put(ctx context.Context, key string, payload []byte) error
+	// Get returns ErrPayloadNotFound if no payload is stored at key
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete does not fail if no payload is stored at key
+	Delete(ctx context.Context, key string) error
+}
+
+// NewPayloadStore returns the payload store configured in the storage settings, or nil if payloads are kept in redis
+func NewPayloadStore(storageConf *lib.StorageConfig, httpClient *http.Client) (PayloadStore, error) {
+	switch storageConf.PayloadStore {
+	case lib.PayloadStoreTypeNone:
+		return nil, nil
+	case lib.PayloadStoreTypeLocal:
+		return NewLocalPayloadStore(storageConf.PayloadStoreDir)
+	case lib.PayloadStoreTypeS3:
+		return NewS3PayloadStore(&storageConf.S3, httpClient)
+	default:
+		return nil, fmt.Errorf("invalid payload store: %s", storageConf.PayloadStore)
+	}
+}
+
+func NewLocalPayloadStore(dir string) (PayloadStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("payload store dir is required")
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve payload store dir")
+	}
+
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create payload store dir")
+	}
+
+	return &localPayloadStore{dir: dir}, nil
+}
+
+type localPayloadStore struct {
+	dir string
+}
+
+func (s *localPayloadStore) Put(ctx context.Context, key string, payload []byte) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create payload dir")
+	}
+
+	// write to a temporary file first so that readers never see a partial payload
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create payload file")
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(payload)
+	if err != nil {
+		f.Close()
+		return errors.Wrapf(err, "failed to write payload file")
+	}
+
+	err = f.Close()
+	if err != nil {
+		return errors.Wrapf(err, "failed to close payload file")
+	}
+
+	err = os.Rename(f.Name(), path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to rename payload file")
+	}
+
+	return nil
+}
+
+func (s *localPayloadStore) Get(ctx context.Context, key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrPayloadNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read payload file")
+	}
+
+	return payload, nil
+}
+
+func (s *localPayloadStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.Wrapf(err, "failed to delete payload file")
+	}
+
+	return nil
+}
+
+func (s *localPayloadStore) path(key string) (string, error) {
+	path := filepath.Join(s.dir, filepath.FromSlash(key))
+	if !strings.HasPrefix(path, s.dir+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid payload key: %s", key)
+	}
+
+	return path, nil
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/didil/inhooks/pkg/lib"
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// SchemaValidationError is returned when a payload does not match the source schema
+type SchemaValidationError struct {
+	Violations []string
+}
+
+func (e *SchemaValidationError) Error() string {
+	return "payload does not match schema"
+}
+
+type PayloadValidator interface {
+	// Validate validates the message payload against the source schema. It returns a *SchemaValidationError if the payload does not match the schema.
+	Validate(source *models.Source, m *models.Message) error
+}
+
+func NewPayloadValidator() PayloadValidator {
+	return &payloadValidator{}
+}
+
+type payloadValidator struct {
+}
+
+func (v *payloadValidator) Validate(source *models.Source, m *models.Message) error {
+	if source.Schema == nil {
+		return nil
+	}
+
+	doc, err := lib.DecodeJSON(m.Payload)
+	if err != nil {
+		return &SchemaValidationError{Violations: []string{fmt.Sprintf("invalid json: %s", err)}}
+	}
+
+	err = source.Schema.Validate(doc)
+	var validationErr *jsonschema.ValidationError
+	if errors.As(err, &validationErr) {
+		return &SchemaValidationError{Violations: schemaViolations(validationErr)}
+	}
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// schemaViolations returns the validation errors formatted as "<json pointer>: <error>"
+func schemaViolations(validationErr *jsonschema.ValidationError) []string {
+	violations := []string{}
+	for _, unit := range validationErr.BasicOutput().Errors {
+		if unit.Error == nil {
+			continue
+		}
+
+		b, err := json.Marshal(unit.Error)
+		if err != nil {
+			continue
+		}
+		msg := ""
+		err = json.Unmarshal(b, &msg)
+		if err != nil {
+			continue
+		}
+
+		location := unit.InstanceLocation
+		if location == "" {
+			location = "/"
+		}
+		violations = append(violations, fmt.Sprintf("%s: %s", location, msg))
+	}
+
+	return violations
+}
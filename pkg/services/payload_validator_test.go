@@ -0,0 +1,58 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPayloadValidator_Validate(t *testing.T) {
+	schema := &models.PayloadSchema{
+		Inline: map[string]interface{}{
+			"type":     "object",
+			"required": []interface{}{"id", "amount"},
+			"properties": map[string]interface{}{
+				"id":     map[string]interface{}{"type": "string"},
+				"amount": map[string]interface{}{"type": "integer", "minimum": 1},
+			},
+		},
+	}
+	err := schema.Compile()
+	assert.NoError(t, err)
+
+	source := &models.Source{Schema: schema}
+	v := NewPayloadValidator()
+
+	err = v.Validate(source, &models.Message{Payload: []byte(`{"id":"abc","amount":10}`)})
+	assert.NoError(t, err)
+
+	err = v.Validate(source, &models.Message{Payload: []byte(`{"id":123,"amount":0}`)})
+	assert.EqualError(t, err, "payload does not match schema")
+	validationErr, ok := err.(*SchemaValidationError)
+	assert.True(t, ok)
+	assert.ElementsMatch(t, []string{
+		"/id: got number, want string",
+		"/amount: minimum: got 0, want 1",
+	}, validationErr.Violations)
+
+	err = v.Validate(source, &models.Message{Payload: []byte(`{"amount":5}`)})
+	validationErr, ok = err.(*SchemaValidationError)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"/: missing property 'id'"}, validationErr.Violations)
+
+	err = v.Validate(source, &models.Message{Payload: []byte(`not json`)})
+	validationErr, ok = err.(*SchemaValidationError)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"invalid json: invalid character 'o' in literal null (expecting 'u')"}, validationErr.Violations)
+
+	// trailing data after a valid document
+	err = v.Validate(source, &models.Message{Payload: []byte(`{"id": "abc"} {"other": true}`)})
+	validationErr, ok = err.(*SchemaValidationError)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"invalid json: invalid character after top-level value"}, validationErr.Violations)
+
+	// no schema
+	err = v.Validate(&models.Source{}, &models.Message{Payload: []byte(`not json`)})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/didil/inhooks/pkg/lib"
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+type Poller interface {
+	// Poll calls the poll source url once and enqueues a message for each sink of the flow for each new item.
+	// The call is skipped if another instance polled the source during the poll interval. Returns the number of new items.
+	Poll(ctx context.Context, flow *models.Flow) (int, error)
+}
+
+type poller struct {
+	redisStore      RedisStore
+	httpClient      *http.Client
+	messageBuilder  MessageBuilder
+	messageEnqueuer MessageEnqueuer
+	appConf         *lib.AppConfig
+}
+
+func NewPoller(redisStore RedisStore, httpClient *http.Client, messageBuilder MessageBuilder, messageEnqueuer MessageEnqueuer, appConf *lib.AppConfig) Poller {
+	return &poller{
+		redisStore:      redisStore,
+		httpClient:      httpClient,
+		messageBuilder:  messageBuilder,
+		messageEnqueuer: messageEnqueuer,
+		appConf:         appConf,
+	}
+}
+
+func (p *poller) Poll(ctx context.Context, flow *models.Flow) (int, error) {
+	poll := flow.Source.Poll
+
+	// the source is polled once per interval across instances.
+	// the lock expires slightly before the interval so that the instance polling on schedule is not locked out by its own previous poll
+	lockKey := pollLockKey(flow.Source.ID)
+	locked, err := p.redisStore.SetNX(ctx, lockKey, []byte(flow.Source.ID), poll.Interval-poll.Interval/10)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to lock poll")
+	}
+	if !locked {
+		return 0, nil
+	}
+
+	count, err := p.poll(ctx, flow)
+	if err != nil || (count > 0 && poll.Cursor != nil) {
+		// release the lock to retry, or to poll the next page right away
+		delErr := p.redisStore.Del(ctx, lockKey)
+		if err == nil {
+			err = delErr
+		}
+	}
+
+	return count, err
+}
+
+func (p *poller) poll(ctx context.Context, flow *models.Flow) (int, error) {
+	poll := flow.Source.Poll
+
+	cursorKey := pollCursorKey(flow.Source.ID)
+	cursor, err := p.redisStore.Get(ctx, cursorKey)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to get poll cursor")
+	}
+
+	body, err := p.call(ctx, poll, string(cursor))
+	if err != nil {
+		return 0, err
+	}
+
+	items, err := poll.Items(body)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to read poll items")
+	}
+
+	// drop the items already seen
+	reqID := uuid.New().String()
+	messages := []*models.Message{}
+	newItemKeys := []string{}
+	lastItemKey := ""
+	for _, item := range items {
+		itemKey, err := poll.ItemKey(item)
+		if err != nil {
+			p.releaseItems(ctx, newItemKeys)
+			return 0, errors.Wrapf(err, "failed to read poll item key")
+		}
+		lastItemKey = itemKey
+
+		seenKey := pollItemKey(flow.Source.ID, itemKey)
+		isNew, err := p.redisStore.SetNX(ctx, seenKey, []byte(reqID), *poll.DedupeTTL)
+		if err != nil {
+			p.releaseItems(ctx, newItemKeys)
+			return 0, errors.Wrapf(err, "failed to dedupe poll item")
+		}
+		if !isNew {
+			continue
+		}
+		newItemKeys = append(newItemKeys, seenKey)
+
+		itemMessages, err := p.buildMessages(flow, item, reqID)
+		if err != nil {
+			p.releaseItems(ctx, newItemKeys)
+			return 0, errors.Wrapf(err, "failed to build messages")
+		}
+		messages = append(messages, itemMessages...)
+	}
+
+	if len(messages) > 0 {
+		_, err = p.messageEnqueuer.Enqueue(ctx, messages)
+		if err != nil {
+			// the items are polled again on retry
+			p.releaseItems(ctx, newItemKeys)
+			return 0, errors.Wrapf(err, "failed to enqueue messages")
+		}
+	}
+
+	nextCursor, ok, err := poll.NextCursor(body, lastItemKey)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to read poll cursor")
+	}
+	if ok {
+		err = p.redisStore.Set(ctx, cursorKey, []byte(nextCursor))
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to set poll cursor")
+		}
+	}
+
+	return len(newItemKeys), nil
+}
+
+// call fetches the items listed after the cursor
+func (p *poller) call(ctx context.Context, poll *models.Poll, cursor string) ([]byte, error) {
+	u, err := url.Parse(poll.URL)
+	if err != nil {
+		return nil, err
+	}
+	if poll.Cursor != nil && cursor != "" {
+		query := u.Query()
+		query.Set(poll.Cursor.QueryParam, cursor)
+		u.RawQuery = query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range poll.Headers {
+		req.Header.Set(name, value)
+	}
+	for name, envVar := range poll.HeaderEnvVars {
+		req.Header.Set(name, os.Getenv(envVar))
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to call poll url")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("poll url returned status %d", resp.StatusCode)
+	}
+
+	maxBytes := p.appConf.Ingest.MaxBodyBytes
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read poll response")
+	}
+	if int64(len(body)) > maxBytes {
+		return nil, fmt.Errorf("poll response larger than %d bytes", maxBytes)
+	}
+
+	return body, nil
+}
+
+// buildMessages builds the messages of an item as if it was posted to the ingest endpoint
+func (p *poller) buildMessages(flow *models.Flow, item []byte, reqID string) ([]*models.Message, error) {
+	headers := http.Header{}
+	headers.Set("Content-Type", "application/json")
+
+	return p.messageBuilder.FromPayload(flow, item, headers, reqID)
+}
+
+// releaseItems forgets the items of a failed poll so that they are enqueued on retry
+func (p *poller) releaseItems(ctx context.Context, seenKeys []string) {
+	for _, key := range seenKeys {
+		// the item is skipped on retry if the release fails
+		_ = p.redisStore.Del(ctx, key)
+	}
+}
+
+func pollLockKey(sourceID string) string {
+	return fmt.Sprintf("src:%s:poll:lock", sourceID)
+}
+
+func pollCursorKey(sourceID string) string {
+	return fmt.Sprintf("src:%s:poll:cursor", sourceID)
+}
+
+func pollItemKey(sourceID string, itemKey string) string {
+	hash := sha256.Sum256([]byte(itemKey))
+	return fmt.Sprintf("src:%s:poll:item:%s", sourceID, hex.EncodeToString(hash[:]))
+}
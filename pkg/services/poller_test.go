@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/didil/inhooks/pkg/lib"
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/didil/inhooks/pkg/testsupport"
+	"github.com/didil/inhooks/pkg/testsupport/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoller(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	appConf, err := testsupport.InitAppConfig(ctx)
+	assert.NoError(t, err)
+
+	client, err := lib.InitRedisClient(appConf)
+	assert.NoError(t, err)
+	redisStore, err := NewRedisStore(client, appConf.Redis.InhooksDBName)
+	assert.NoError(t, err)
+
+	prefix := fmt.Sprintf("inhooks:%s", appConf.Redis.InhooksDBName)
+	defer func() {
+		err := testsupport.DeleteAllRedisKeys(ctx, client, prefix)
+		assert.NoError(t, err)
+	}()
+
+	t.Setenv("POLL_TEST_API_KEY", "secret")
+
+	// vendor api listing the events after a cursor
+	pages := map[string]string{
+		"":   `{"data": [{"id": "evt_1"}, {"id": "evt_2"}], "next": "c1"}`,
+		"c1": `{"data": [{"id": "evt_2"}, {"id": "evt_3"}], "next": "c2"}`,
+		"c2": `{"data": [], "next": null}`,
+	}
+	calls := []string{}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "secret", r.Header.Get("X-Api-Key"))
+		assert.Equal(t, "application/json", r.Header.Get("Accept"))
+
+		cursor := r.URL.Query().Get("after")
+		calls = append(calls, cursor)
+		_, err := w.Write([]byte(pages[cursor]))
+		assert.NoError(t, err)
+	}))
+	defer s.Close()
+
+	timeSvc := mocks.NewMockTimeService(ctrl)
+	timeSvc.EXPECT().Now().Return(time.Date(2023, 05, 5, 8, 9, 12, 0, time.UTC)).AnyTimes()
+	messageEnqueuer := mocks.NewMockMessageEnqueuer(ctrl)
+
+	p := NewPoller(redisStore, http.DefaultClient, NewMessageBuilder(timeSvc), messageEnqueuer, appConf)
+
+	poll := &models.Poll{
+		URL:             s.URL + "/events?limit=2",
+		Interval:        time.Minute,
+		Headers:         map[string]string{"Accept": "application/json"},
+		HeaderEnvVars:   map[string]string{"X-Api-Key": "POLL_TEST_API_KEY"},
+		ItemsJSONPath:   "$.data",
+		ItemKeyJSONPath: "$.id",
+		Cursor:          &models.PollCursor{QueryParam: "after", JSONPath: "$.next"},
+	}
+	dedupeTTL := time.Hour
+	poll.DedupeTTL = &dedupeTTL
+	assert.NoError(t, poll.Compile())
+
+	flow := &models.Flow{
+		ID:     "flow-1",
+		Source: &models.Source{ID: "source-1", Type: models.SourceTypePoll, Poll: poll},
+		Sinks:  []*models.Sink{{ID: "sink-1"}},
+	}
+
+	var payloads []string
+	messageEnqueuer.EXPECT().Enqueue(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, ms []*models.Message) ([]*models.QueuedInfo, error) {
+		for _, m := range ms {
+			assert.Equal(t, "sink-1", m.SinkID)
+			payloads = append(payloads, string(m.Payload))
+		}
+		return nil, nil
+	}).Times(2)
+
+	count, err := p.Poll(ctx, flow)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	// the next page is polled right away, the items already seen are dropped
+	count, err = p.Poll(ctx, flow)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	count, err = p.Poll(ctx, flow)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	assert.Equal(t, []string{`{"id": "evt_1"}`, `{"id": "evt_2"}`, `{"id": "evt_3"}`}, payloads)
+	assert.Equal(t, []string{"", "c1", "c2"}, calls)
+
+	// the item keys are hashed
+	itemHash := sha256.Sum256([]byte("evt_1"))
+	seen, err := redisStore.Get(ctx, "src:source-1:poll:item:"+hex.EncodeToString(itemHash[:]))
+	assert.NoError(t, err)
+	assert.NotNil(t, seen)
+
+	// the source is not polled again before the interval
+	count, err = p.Poll(ctx, flow)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+	assert.Len(t, calls, 3)
+}
+
+func TestPoller_LastSeenID(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	appConf, err := testsupport.InitAppConfig(ctx)
+	assert.NoError(t, err)
+
+	client, err := lib.InitRedisClient(appConf)
+	assert.NoError(t, err)
+	redisStore, err := NewRedisStore(client, appConf.Redis.InhooksDBName)
+	assert.NoError(t, err)
+
+	prefix := fmt.Sprintf("inhooks:%s", appConf.Redis.InhooksDBName)
+	defer func() {
+		err := testsupport.DeleteAllRedisKeys(ctx, client, prefix)
+		assert.NoError(t, err)
+	}()
+
+	calls := []string{}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sinceID := r.URL.Query().Get("since_id")
+		calls = append(calls, sinceID)
+		body := `[]`
+		if sinceID == "" {
+			body = `[{"id": 41}, {"id": 42}]`
+		}
+		_, err := w.Write([]byte(body))
+		assert.NoError(t, err)
+	}))
+	defer s.Close()
+
+	timeSvc := mocks.NewMockTimeService(ctrl)
+	timeSvc.EXPECT().Now().Return(time.Date(2023, 05, 5, 8, 9, 12, 0, time.UTC)).AnyTimes()
+	messageEnqueuer := mocks.NewMockMessageEnqueuer(ctrl)
+
+	p := NewPoller(redisStore, http.DefaultClient, NewMessageBuilder(timeSvc), messageEnqueuer, appConf)
+
+	poll := &models.Poll{
+		URL:             s.URL,
+		Interval:        time.Minute,
+		ItemsJSONPath:   "$",
+		ItemKeyJSONPath: "$.id",
+		Cursor:          &models.PollCursor{QueryParam: "since_id"},
+	}
+	dedupeTTL := time.Hour
+	poll.DedupeTTL = &dedupeTTL
+	assert.NoError(t, poll.Compile())
+
+	flow := &models.Flow{
+		ID:     "flow-1",
+		Source: &models.Source{ID: "source-1", Type: models.SourceTypePoll, Poll: poll},
+		Sinks:  []*models.Sink{{ID: "sink-1"}},
+	}
+
+	// the items are released when the enqueue fails
+	messageEnqueuer.EXPECT().Enqueue(ctx, gomock.Any()).Return(nil, fmt.Errorf("enqueue failed"))
+
+	_, err = p.Poll(ctx, flow)
+	assert.ErrorContains(t, err, "enqueue failed")
+
+	messageEnqueuer.EXPECT().Enqueue(ctx, gomock.Any()).Return(nil, nil)
+
+	count, err := p.Poll(ctx, flow)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	// the key of the last item is the cursor
+	count, err = p.Poll(ctx, flow)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	assert.Equal(t, []string{"", "", "42"}, calls)
+}
@@ -2,7 +2,6 @@ package services
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 
 	"github.com/didil/inhooks/pkg/models"
@@ -18,13 +17,15 @@ type processingResultsService struct {
 	timeSvc         TimeService
 	redisStore      RedisStore
 	retryCalculator RetryCalculator
+	codec           RecordCodec
 }
 
-func NewProcessingResultsService(timeSvc TimeService, redisStore RedisStore, retryCalculator RetryCalculator) ProcessingResultsService {
+func NewProcessingResultsService(timeSvc TimeService, redisStore RedisStore, retryCalculator RetryCalculator, codec RecordCodec) ProcessingResultsService {
 	return &processingResultsService{
 		timeSvc:         timeSvc,
 		redisStore:      redisStore,
 		retryCalculator: retryCalculator,
+		codec:           codec,
 	}
 }
 
@@ -50,7 +51,7 @@ func (s *processingResultsService) HandleFailed(ctx context.Context, sink *model
 	mKey := messageKey(m.FlowID, m.SinkID, m.ID)
 	sourceQueueKey := queueKey(m.FlowID, m.SinkID, models.QueueStatusProcessing)
 
-	b, err := json.Marshal(&m)
+	b, err := encodeMessage(s.codec, m)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to encode message")
 	}
@@ -99,7 +100,7 @@ func (s *processingResultsService) HandleOK(ctx context.Context, m *models.Messa
 	sourceQueueKey := queueKey(m.FlowID, m.SinkID, models.QueueStatusProcessing)
 	destQueueKey := queueKey(m.FlowID, m.SinkID, models.QueueStatusDone)
 
-	b, err := json.Marshal(&m)
+	b, err := encodeMessage(s.codec, m)
 	if err != nil {
 		return errors.Wrapf(err, "failed to encode message")
 	}
@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/didil/inhooks/pkg/lib"
 	"github.com/didil/inhooks/pkg/models"
 	"github.com/didil/inhooks/pkg/testsupport/mocks"
 	"github.com/golang/mock/gomock"
@@ -58,7 +59,10 @@ func TestProcessingResultsServiceHandleOK(t *testing.T) {
 
 	redisStore.EXPECT().SetLRemZAdd(ctx, messageKey, b, sourceQueueKey, destQueueKey, mID, float64(now.Unix())).Return(nil)
 
-	s := NewProcessingResultsService(timeSvc, redisStore, retryCalculator)
+	codec, err := NewRecordCodec(&lib.StorageConfig{})
+	assert.NoError(t, err)
+
+	s := NewProcessingResultsService(timeSvc, redisStore, retryCalculator, codec)
 	err = s.HandleOK(ctx, m)
 	assert.NoError(t, err)
 }
@@ -128,7 +132,10 @@ func TestProcessingResultsServiceHandleFailed_Dead(t *testing.T) {
 	redisStore.EXPECT().SetAndMove(ctx, messageKey, b, sourceQueueKey, destQueueKey, mID).Return(nil)
 	retryCalculator.EXPECT().NextAttemptInterval(len(m.DeliveryAttempts)+1, &retryInterval, &retryExpMultiplier).Return(retryInterval)
 
-	s := NewProcessingResultsService(timeSvc, redisStore, retryCalculator)
+	codec, err := NewRecordCodec(&lib.StorageConfig{})
+	assert.NoError(t, err)
+
+	s := NewProcessingResultsService(timeSvc, redisStore, retryCalculator, codec)
 	queuedInfo, err := s.HandleFailed(ctx, sink, m, processingErr)
 	assert.NoError(t, err)
 	assert.Equal(t, mID, queuedInfo.MessageID)
@@ -198,7 +205,10 @@ func TestProcessingResultsServiceHandleFailed_Scheduled(t *testing.T) {
 	redisStore.EXPECT().SetLRemZAdd(ctx, messageKey, b, sourceQueueKey, destQueueKey, mID, float64(mUpdated.DeliverAfter.Unix())).Return(nil)
 	retryCalculator.EXPECT().NextAttemptInterval(len(m.DeliveryAttempts)+1, &retryInterval, &retryExpMultiplier).Return(nextAttemptInterval)
 
-	s := NewProcessingResultsService(timeSvc, redisStore, retryCalculator)
+	codec, err := NewRecordCodec(&lib.StorageConfig{})
+	assert.NoError(t, err)
+
+	s := NewProcessingResultsService(timeSvc, redisStore, retryCalculator, codec)
 	queuedInfo, err := s.HandleFailed(ctx, sink, m, processingErr)
 	assert.NoError(t, err)
 	assert.Equal(t, mID, queuedInfo.MessageID)
@@ -267,7 +277,10 @@ func TestProcessingResultsServiceHandleFailed_Ready(t *testing.T) {
 	redisStore.EXPECT().SetAndMove(ctx, messageKey, b, sourceQueueKey, destQueueKey, mID).Return(nil)
 	retryCalculator.EXPECT().NextAttemptInterval(len(m.DeliveryAttempts)+1, &retryInterval, &retryExpMultiplier).Return(nextAttemptInterval)
 
-	s := NewProcessingResultsService(timeSvc, redisStore, retryCalculator)
+	codec, err := NewRecordCodec(&lib.StorageConfig{})
+	assert.NoError(t, err)
+
+	s := NewProcessingResultsService(timeSvc, redisStore, retryCalculator, codec)
 	queuedInfo, err := s.HandleFailed(ctx, sink, m, processingErr)
 	assert.NoError(t, err)
 	assert.Equal(t, mID, queuedInfo.MessageID)
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/didil/inhooks/pkg/lib"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+)
+
+// RecordCodec encodes the records stored in redis
+type RecordCodec interface {
+	Encode(b []byte) ([]byte, error)
+	EncodeBlob(b []byte) ([]byte, error)
+	Decode(b []byte) ([]byte, error)
+	EncryptionKeyID() string
+	RecordKeyID(b []byte) string
+}
+
+// encoded records start with a marker byte followed by the format byte. legacy records are JSON and never start with the marker byte.
+const recordMarker byte = 0x00
+
+const (
+	recordFormatGzip byte = 'g'
+	recordFormatZstd byte = 'z'
+	// uncompressed blobs, marked as binary payloads may start with the marker byte
+	recordFormatRaw byte = 'r'
+	// encrypted records: key id length byte, key id, nonce, AES-GCM sealed compressed record
+	recordFormatEncrypted byte = 'e'
+)
+
+type recordCodec struct {
+	compression lib.StorageCompression
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+
+	encryptionKeyID string
+	aeadsByKeyID    map[string]cipher.AEAD
+}
+
+func NewRecordCodec(storageConf *lib.StorageConfig) (RecordCodec, error) {
+	switch storageConf.Compression {
+	case lib.StorageCompressionNone, lib.StorageCompressionGzip, lib.StorageCompressionZstd:
+	default:
+		return nil, fmt.Errorf("invalid storage compression: %s", storageConf.Compression)
+	}
+
+	zstdEncoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to init zstd encoder")
+	}
+
+	zstdDecoder, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to init zstd decoder")
+	}
+
+	keyEntries := storageConf.EncryptionKeys
+	if storageConf.EncryptionKeysFile != "" {
+		fileKeyEntries, err := readEncryptionKeysFile(storageConf.EncryptionKeysFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read encryption keys file")
+		}
+		keyEntries = append(keyEntries, fileKeyEntries...)
+	}
+
+	aeadsByKeyID, err := parseEncryptionKeys(keyEntries)
+	if err != nil {
+		return nil, err
+	}
+
+	if storageConf.EncryptionKeyID != "" && aeadsByKeyID[storageConf.EncryptionKeyID] == nil {
+		return nil, fmt.Errorf("encryption key not found: %s", storageConf.EncryptionKeyID)
+	}
+
+	c := &recordCodec{
+		compression:     storageConf.Compression,
+		zstdEncoder:     zstdEncoder,
+		zstdDecoder:     zstdDecoder,
+		encryptionKeyID: storageConf.EncryptionKeyID,
+		aeadsByKeyID:    aeadsByKeyID,
+	}
+
+	return c, nil
+}
+
+// Encode compresses the record with the configured compression, then encrypts it if an encryption key is set
+func (c *recordCodec) Encode(b []byte) ([]byte, error) {
+	return c.encode(b, false)
+}
+
+// EncodeBlob encodes a binary payload like Encode, but always marks it so that payloads starting with the marker byte are not read as encoded records
+func (c *recordCodec) EncodeBlob(b []byte) ([]byte, error) {
+	return c.encode(b, true)
+}
+
+func (c *recordCodec) encode(b []byte, markRaw bool) ([]byte, error) {
+	compressed, err := c.compress(b, markRaw)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.encryptionKeyID == "" {
+		return compressed, nil
+	}
+
+	return c.encrypt(compressed)
+}
+
+// Decode decrypts and decompresses the record. Records without marker are returned as is, so that records written before enabling compression or encryption can still be read.
+func (c *recordCodec) Decode(b []byte) ([]byte, error) {
+	if len(b) >= 2 && b[0] == recordMarker && b[1] == recordFormatEncrypted {
+		decrypted, err := c.decrypt(b)
+		if err != nil {
+			return nil, err
+		}
+		b = decrypted
+	}
+
+	return c.decompress(b)
+}
+
+// EncryptionKeyID returns the id of the key used to encrypt new records
+func (c *recordCodec) EncryptionKeyID() string {
+	return c.encryptionKeyID
+}
+
+// RecordKeyID returns the id of the key used to encrypt the record, or an empty string if the record is not encrypted
+func (c *recordCodec) RecordKeyID(b []byte) string {
+	if len(b) < 3 || b[0] != recordMarker || b[1] != recordFormatEncrypted {
+		return ""
+	}
+
+	keyIDLen := int(b[2])
+	if len(b) < 3+keyIDLen {
+		return ""
+	}
+
+	return string(b[3 : 3+keyIDLen])
+}
+
+func (c *recordCodec) compress(b []byte, markRaw bool) ([]byte, error) {
+	switch c.compression {
+	case lib.StorageCompressionGzip:
+		buf := bytes.NewBuffer([]byte{recordMarker, recordFormatGzip})
+		w := gzip.NewWriter(buf)
+		_, err := w.Write(b)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to gzip record")
+		}
+		err = w.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to gzip record")
+		}
+		return buf.Bytes(), nil
+	case lib.StorageCompressionZstd:
+		return c.zstdEncoder.EncodeAll(b, []byte{recordMarker, recordFormatZstd}), nil
+	default:
+		if markRaw {
+			return append([]byte{recordMarker, recordFormatRaw}, b...), nil
+		}
+		return b, nil
+	}
+}
+
+func (c *recordCodec) decompress(b []byte) ([]byte, error) {
+	if len(b) < 2 || b[0] != recordMarker {
+		return b, nil
+	}
+
+	switch b[1] {
+	case recordFormatGzip:
+		r, err := gzip.NewReader(bytes.NewReader(b[2:]))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to init gzip reader")
+		}
+		defer r.Close()
+
+		decoded, err := io.ReadAll(r)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to gunzip record")
+		}
+		return decoded, nil
+	case recordFormatZstd:
+		decoded, err := c.zstdDecoder.DecodeAll(b[2:], nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode zstd record")
+		}
+		return decoded, nil
+	case recordFormatRaw:
+		return b[2:], nil
+	default:
+		return nil, fmt.Errorf("unexpected record format: %d", b[1])
+	}
+}
+
+func (c *recordCodec) encrypt(b []byte) ([]byte, error) {
+	aead := c.aeadsByKeyID[c.encryptionKeyID]
+	keyID := []byte(c.encryptionKeyID)
+
+	header := append([]byte{recordMarker, recordFormatEncrypted, byte(len(keyID))}, keyID...)
+
+	nonce := make([]byte, aead.NonceSize())
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to generate nonce")
+	}
+
+	out := append(header, nonce...)
+	// the key id is authenticated with the record
+	return aead.Seal(out, nonce, b, keyID), nil
+}
+
+func (c *recordCodec) decrypt(b []byte) ([]byte, error) {
+	keyID := c.RecordKeyID(b)
+	aead := c.aeadsByKeyID[keyID]
+	if aead == nil {
+		return nil, fmt.Errorf("encryption key not found: %s", keyID)
+	}
+
+	offset := 3 + len(keyID)
+	if len(b) < offset+aead.NonceSize() {
+		return nil, fmt.Errorf("invalid encrypted record")
+	}
+	nonce := b[offset : offset+aead.NonceSize()]
+
+	decrypted, err := aead.Open(nil, nonce, b[offset+aead.NonceSize():], []byte(keyID))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decrypt record")
+	}
+
+	return decrypted, nil
+}
+
+// parseEncryptionKeys parses keys formatted as <keyID>:<base64 key>. Keys must be 16, 24 or 32 bytes long.
+func parseEncryptionKeys(entries []string) (map[string]cipher.AEAD, error) {
+	aeadsByKeyID := map[string]cipher.AEAD{}
+
+	for _, entry := range entries {
+		keyID, encodedKey, found := strings.Cut(strings.TrimSpace(entry), ":")
+		if !found || keyID == "" || len(keyID) > 255 {
+			return nil, fmt.Errorf("invalid encryption key entry for key id %q, expected <keyID>:<base64 key>", keyID)
+		}
+
+		if _, ok := aeadsByKeyID[keyID]; ok {
+			return nil, fmt.Errorf("duplicate encryption key id: %s", keyID)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encodedKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 encryption key: %s", keyID)
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid encryption key: %s", keyID)
+		}
+
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to init gcm: %s", keyID)
+		}
+
+		aeadsByKeyID[keyID] = aead
+	}
+
+	return aeadsByKeyID, nil
+}
+
+// readEncryptionKeysFile reads a file containing one key per line. Empty lines and lines starting with # are ignored.
+func readEncryptionKeysFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries = append(entries, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/didil/inhooks/pkg/lib"
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordCodec(t *testing.T) {
+	record := []byte(`{"id":"a5b6e039-f368-46fd-b0ed-ec9c68932179","payload":"eyJpZCI6ImFiYyJ9"}`)
+
+	for _, compression := range []lib.StorageCompression{lib.StorageCompressionNone, lib.StorageCompressionGzip, lib.StorageCompressionZstd} {
+		codec, err := NewRecordCodec(&lib.StorageConfig{Compression: compression})
+		assert.NoError(t, err)
+
+		encoded, err := codec.Encode(record)
+		assert.NoError(t, err)
+
+		decoded, err := codec.Decode(encoded)
+		assert.NoError(t, err)
+		assert.Equal(t, record, decoded)
+
+		// legacy records are read as is
+		decoded, err = codec.Decode(record)
+		assert.NoError(t, err)
+		assert.Equal(t, record, decoded)
+	}
+
+	// records written with another compression can be read
+	gzipCodec, err := NewRecordCodec(&lib.StorageConfig{Compression: lib.StorageCompressionGzip})
+	assert.NoError(t, err)
+	zstdCodec, err := NewRecordCodec(&lib.StorageConfig{Compression: lib.StorageCompressionZstd})
+	assert.NoError(t, err)
+
+	encoded, err := gzipCodec.Encode(record)
+	assert.NoError(t, err)
+	decoded, err := zstdCodec.Decode(encoded)
+	assert.NoError(t, err)
+	assert.Equal(t, record, decoded)
+
+	_, err = zstdCodec.Decode([]byte{recordMarker, 'x', 1, 2})
+	assert.EqualError(t, err, "unexpected record format: 120")
+
+	_, err = NewRecordCodec(&lib.StorageConfig{Compression: "lz4"})
+	assert.EqualError(t, err, "invalid storage compression: lz4")
+}
+
+// BenchmarkRecordCodec reports the stored size of a verbose JSON message for each compression
+func BenchmarkRecordCodec(b *testing.B) {
+	items := []map[string]interface{}{}
+	for i := 0; i < 50; i++ {
+		items = append(items, map[string]interface{}{
+			"id":          fmt.Sprintf("item_%d", i),
+			"object":      "line_item",
+			"description": "Premium subscription (monthly)",
+			"quantity":    1,
+			"amount":      4900,
+			"currency":    "usd",
+			"metadata":    map[string]string{"customer_tier": "enterprise", "region": "us-east-1"},
+		})
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"id":      "evt_1NG8Du2eZvKYlo2CUI79vXWy",
+		"object":  "event",
+		"type":    "invoice.payment_succeeded",
+		"created": 1686089970,
+		"data":    map[string]interface{}{"object": map[string]interface{}{"id": "in_1NG8Dt2eZvKYlo2C", "lines": items}},
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	m := &models.Message{
+		ID:            "a5b6e039-f368-46fd-b0ed-ec9c68932179",
+		FlowID:        "flow-1",
+		SourceID:      "source-1",
+		IngestedReqID: "host/abc-000001",
+		SinkID:        "sink-1",
+		HttpMethod:    http.MethodPost,
+		HttpHeaders:   http.Header{"Content-Type": []string{"application/json"}, "User-Agent": []string{"Stripe/1.0 (+https://stripe.com/docs/webhooks)"}},
+		Payload:       payload,
+		DeliverAfter:  time.Date(2023, 05, 5, 8, 9, 12, 0, time.UTC),
+	}
+
+	for _, compression := range []lib.StorageCompression{lib.StorageCompressionNone, lib.StorageCompressionGzip, lib.StorageCompressionZstd} {
+		name := string(compression)
+		if name == "" {
+			name = "none"
+		}
+
+		b.Run(name, func(b *testing.B) {
+			codec, err := NewRecordCodec(&lib.StorageConfig{Compression: compression})
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			var encoded []byte
+			for i := 0; i < b.N; i++ {
+				encoded, err = encodeMessage(codec, m)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			b.ReportMetric(float64(len(encoded)), "stored_bytes")
+		})
+	}
+}
+
+func TestRecordCodec_EncodeBlob(t *testing.T) {
+	// binary payloads can start with the marker byte
+	blob := []byte{recordMarker, 'g', 0xff, 0x01, 0x02}
+	key := "key-1:" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+
+	storageConfs := []*lib.StorageConfig{}
+	for _, compression := range []lib.StorageCompression{lib.StorageCompressionNone, lib.StorageCompressionGzip, lib.StorageCompressionZstd} {
+		storageConfs = append(storageConfs,
+			&lib.StorageConfig{Compression: compression},
+			&lib.StorageConfig{Compression: compression, EncryptionKeys: []string{key}, EncryptionKeyID: "key-1"},
+		)
+	}
+
+	for _, storageConf := range storageConfs {
+		codec, err := NewRecordCodec(storageConf)
+		assert.NoError(t, err)
+
+		encoded, err := codec.EncodeBlob(blob)
+		assert.NoError(t, err)
+
+		decoded, err := codec.Decode(encoded)
+		assert.NoError(t, err)
+		assert.Equal(t, blob, decoded)
+	}
+}
+
+func TestRecordCodec_Encryption(t *testing.T) {
+	record := []byte(`{"id":"a5b6e039-f368-46fd-b0ed-ec9c68932179","payload":"eyJpZCI6ImFiYyJ9"}`)
+
+	key1 := "key-1:" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+	key2 := "key-2:" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, 32))
+
+	codec1, err := NewRecordCodec(&lib.StorageConfig{
+		Compression:     lib.StorageCompressionZstd,
+		EncryptionKeys:  []string{key1},
+		EncryptionKeyID: "key-1",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "key-1", codec1.EncryptionKeyID())
+
+	encrypted, err := codec1.Encode(record)
+	assert.NoError(t, err)
+	assert.Equal(t, "key-1", codec1.RecordKeyID(encrypted))
+	assert.NotContains(t, string(encrypted), "a5b6e039")
+
+	decoded, err := codec1.Decode(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, record, decoded)
+
+	// keys loaded from a file
+	keysFile := filepath.Join(t.TempDir(), "keys")
+	err = os.WriteFile(keysFile, []byte("# rotated keys\n"+key1+"\n"+key2+"\n"), 0600)
+	assert.NoError(t, err)
+
+	codec2, err := NewRecordCodec(&lib.StorageConfig{
+		EncryptionKeysFile: keysFile,
+		EncryptionKeyID:    "key-2",
+	})
+	assert.NoError(t, err)
+
+	// records encrypted with the previous key can still be read
+	decoded, err = codec2.Decode(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, record, decoded)
+
+	encrypted2, err := codec2.Encode(record)
+	assert.NoError(t, err)
+	assert.Equal(t, "key-2", codec2.RecordKeyID(encrypted2))
+
+	_, err = codec1.Decode(encrypted2)
+	assert.EqualError(t, err, "encryption key not found: key-2")
+
+	// tampered records are rejected
+	encrypted2[len(encrypted2)-1] ^= 0xff
+	_, err = codec2.Decode(encrypted2)
+	assert.ErrorContains(t, err, "failed to decrypt record")
+
+	// legacy records are read as is
+	decoded, err = codec2.Decode(record)
+	assert.NoError(t, err)
+	assert.Equal(t, record, decoded)
+	assert.Equal(t, "", codec2.RecordKeyID(record))
+}
+
+func TestNewRecordCodec_InvalidKeys(t *testing.T) {
+	_, err := NewRecordCodec(&lib.StorageConfig{EncryptionKeys: []string{"key-1"}})
+	assert.ErrorContains(t, err, "invalid encryption key entry")
+
+	_, err = NewRecordCodec(&lib.StorageConfig{EncryptionKeys: []string{"key-1:abc"}})
+	assert.ErrorContains(t, err, "invalid base64 encryption key: key-1")
+
+	_, err = NewRecordCodec(&lib.StorageConfig{EncryptionKeys: []string{"key-1:" + base64.StdEncoding.EncodeToString([]byte("short"))}})
+	assert.ErrorContains(t, err, "invalid encryption key: key-1")
+
+	_, err = NewRecordCodec(&lib.StorageConfig{EncryptionKeyID: "key-1"})
+	assert.EqualError(t, err, "encryption key not found: key-1")
+}
@@ -4,16 +4,24 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/didil/inhooks/pkg/models"
 	"github.com/pkg/errors"
 	"github.com/redis/go-redis/v9"
 )
 
 type RedisStore interface {
 	Get(ctx context.Context, messageKey string) ([]byte, error)
-	SetAndEnqueue(ctx context.Context, messageKey string, value []byte, queueKey string, messageID string) error
-	SetAndZAdd(ctx context.Context, messageKey string, value []byte, queueKey string, messageID string, score float64) error
+	SetAndEnqueueBatch(ctx context.Context, ingestEntries []*models.IngestEntry, entries []*models.EnqueueEntry) error
+	SetAndEnqueueBatchMarked(ctx context.Context, ingestEntries []*models.IngestEntry, entries []*models.EnqueueEntry, marker *models.EnqueueMarker) error
+	GetIngest(ctx context.Context, ingestKey string) ([]byte, error)
+	MGet(ctx context.Context, keys []string) ([][]byte, error)
+	ScanKeys(ctx context.Context, match string, fn func(keys []string) error) error
+	CompareAndSet(ctx context.Context, key string, oldValue []byte, newValue []byte) (bool, error)
+	CompareAndSetIngest(ctx context.Context, ingestKey string, oldValue []byte, newValue []byte) (bool, error)
+	CompareAndSetListItem(ctx context.Context, listKey string, oldValue []byte, newValue []byte) (bool, error)
 	SetAndMove(ctx context.Context, messageKey string, value []byte, sourceQueueKey, destQueueKey string, messageID string) error
 	SetLRemZAdd(ctx context.Context, messageKey string, value []byte, sourceQueueKey, destQueueKey string, messageID string, score float64) error
 	Enqueue(ctx context.Context, key string, value []byte) error
@@ -24,7 +32,20 @@ type RedisStore interface {
 	LRangeAll(ctx context.Context, queueKey string) ([]string, error)
 	LRemRPush(ctx context.Context, sourceQueueKey, destQueueKey string, messageIDs []string) error
 	ZRemRangeBelowScore(ctx context.Context, queueKey string, maxScore int) (int, error)
-	ZRemDel(ctx context.Context, queueKey string, messageIDs []string, messageKeys []string) error
+	ZRemDelReleaseIngests(ctx context.Context, queueKey string, messageIDs []string, messageKeys []string, ingestKeys []string) ([]bool, error)
+	LLenZCard(ctx context.Context, listKey string, zsetKey string) (int, error)
+	TakeToken(ctx context.Context, bucketKey string, capacity int, refillPerSecond float64, now time.Time) (bool, time.Duration, error)
+	Set(ctx context.Context, key string, value []byte) error
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+	Del(ctx context.Context, key string) error
+	LPushTrimExpire(ctx context.Context, key string, value []byte, maxLen int, ttl time.Duration) error
+	SetExZAddTrim(ctx context.Context, key string, value []byte, ttl time.Duration, zsetKey string, member string, score float64, minScore float64) error
+	ZRangeByScore(ctx context.Context, zsetKey string, minScore float64, maxScore float64) ([]string, error)
+	ZAdd(ctx context.Context, zsetKey string, member string, score float64) error
+	XGroupCreate(ctx context.Context, stream string, group string) error
+	XReadGroup(ctx context.Context, stream string, group string, consumer string, id string, count int, block time.Duration) ([]*models.StreamEntry, error)
+	XAck(ctx context.Context, stream string, group string, ids []string) error
+	XAutoClaim(ctx context.Context, stream string, group string, consumer string, minIdle time.Duration, count int) ([]*models.StreamEntry, error)
 }
 
 type redisStore struct {
@@ -60,39 +81,39 @@ func (s *redisStore) Get(ctx context.Context, messageKey string) ([]byte, error)
 	return []byte(res), nil
 }
 
-func (s *redisStore) SetAndEnqueue(ctx context.Context, messageKey string, value []byte, queueKey string, messageID string) error {
-	pipe := s.client.TxPipeline()
-
-	messageKeyWithPrefix := s.keyWithPrefix(messageKey)
-	pipe.Set(ctx, messageKeyWithPrefix, value, 0)
+// SetAndEnqueueBatch stores the ingested requests and stores and enqueues all the entries in a single transaction, so that either all or none of the messages are enqueued
+func (s *redisStore) SetAndEnqueueBatch(ctx context.Context, ingestEntries []*models.IngestEntry, entries []*models.EnqueueEntry) error {
+	return s.SetAndEnqueueBatchMarked(ctx, ingestEntries, entries, nil)
+}
 
-	queueKeyWithPrefix := s.keyWithPrefix(queueKey)
-	pipe.RPush(ctx, queueKeyWithPrefix, messageID)
+// SetAndEnqueueBatchMarked is SetAndEnqueueBatch, also setting the marker key in the transaction if marker is not nil
+func (s *redisStore) SetAndEnqueueBatchMarked(ctx context.Context, ingestEntries []*models.IngestEntry, entries []*models.EnqueueEntry, marker *models.EnqueueMarker) error {
+	pipe := s.client.TxPipeline()
 
-	_, err := pipe.Exec(ctx)
-	if err != nil {
-		return err
+	if marker != nil {
+		pipe.Set(ctx, s.keyWithPrefix(marker.Key), marker.Value, marker.TTL)
 	}
 
-	return nil
-}
-
-func (s *redisStore) SetAndZAdd(ctx context.Context, messageKey string, value []byte, queueKey string, messageID string, score float64) error {
-	pipe := s.client.TxPipeline()
+	for _, ingestEntry := range ingestEntries {
+		ingestKeyWithPrefix := s.keyWithPrefix(ingestEntry.IngestKey)
+		pipe.HSet(ctx, ingestKeyWithPrefix, "data", ingestEntry.Value, "refs", ingestEntry.Refs)
+	}
 
-	messageKeyWithPrefix := s.keyWithPrefix(messageKey)
-	pipe.Set(ctx, messageKeyWithPrefix, value, 0)
+	for _, entry := range entries {
+		messageKeyWithPrefix := s.keyWithPrefix(entry.MessageKey)
+		pipe.Set(ctx, messageKeyWithPrefix, entry.Value, 0)
 
-	queueKeyWithPrefix := s.keyWithPrefix(queueKey)
-	z := redis.Z{
-		Score:  score,
-		Member: messageID,
+		queueKeyWithPrefix := s.keyWithPrefix(entry.QueueKey)
+		if entry.Score == nil {
+			pipe.RPush(ctx, queueKeyWithPrefix, entry.MessageID)
+		} else {
+			pipe.ZAdd(ctx, queueKeyWithPrefix, redis.Z{Score: *entry.Score, Member: entry.MessageID})
+		}
 	}
-	pipe.ZAdd(ctx, queueKeyWithPrefix, z)
 
 	_, err := pipe.Exec(ctx)
 	if err != nil {
-		return err
+		return errors.Wrapf(err, "failed to set and enqueue batch of %d messages", len(entries))
 	}
 
 	return nil
@@ -209,6 +230,36 @@ func (s *redisStore) ZRangeBelowScore(ctx context.Context, queueKey string, maxS
 	return vals, nil
 }
 
+// ZRangeByScore returns the members with a score between minScore and maxScore included, ordered by score
+func (s *redisStore) ZRangeByScore(ctx context.Context, zsetKey string, minScore float64, maxScore float64) ([]string, error) {
+	zsetKeyWithPrefix := s.keyWithPrefix(zsetKey)
+
+	args := redis.ZRangeArgs{
+		Key:     zsetKeyWithPrefix,
+		Start:   minScore,
+		Stop:    maxScore,
+		ByScore: true,
+	}
+
+	vals, err := s.client.ZRangeArgs(ctx, args).Result()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to zrange. zsetKey: %s", zsetKeyWithPrefix)
+	}
+
+	return vals, nil
+}
+
+func (s *redisStore) ZAdd(ctx context.Context, zsetKey string, member string, score float64) error {
+	zsetKeyWithPrefix := s.keyWithPrefix(zsetKey)
+
+	err := s.client.ZAdd(ctx, zsetKeyWithPrefix, redis.Z{Score: score, Member: member}).Err()
+	if err != nil {
+		return errors.Wrapf(err, "failed to zadd. zsetKey: %s", zsetKeyWithPrefix)
+	}
+
+	return nil
+}
+
 func (s *redisStore) ZRemRpush(ctx context.Context, messageIDs []string, sourceQueueKey string, destQueueKey string) error {
 	pipe := s.client.TxPipeline()
 
@@ -267,24 +318,428 @@ func (s *redisStore) ZRemRangeBelowScore(ctx context.Context, queueKey string, m
 	return int(count), nil
 }
 
-func (s *redisStore) ZRemDel(ctx context.Context, queueKey string, messageIDs []string, messageKeys []string) error {
+// LLenZCard returns the sum of the lengths of a list and a sorted set
+func (s *redisStore) LLenZCard(ctx context.Context, listKey string, zsetKey string) (int, error) {
+	pipe := s.client.Pipeline()
+
+	listKeyWithPrefix := s.keyWithPrefix(listKey)
+	lLenCmd := pipe.LLen(ctx, listKeyWithPrefix)
+
+	zsetKeyWithPrefix := s.keyWithPrefix(zsetKey)
+	zCardCmd := pipe.ZCard(ctx, zsetKeyWithPrefix)
+
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to llen zcard. listKey: %s zsetKey: %s", listKeyWithPrefix, zsetKeyWithPrefix)
+	}
+
+	return int(lLenCmd.Val() + zCardCmd.Val()), nil
+}
+
+// token bucket stored in a hash. tokens are refilled based on the time elapsed since the last update.
+// returns whether a token was taken and the number of milliseconds to wait for the next token otherwise.
+var takeTokenScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local refillPerMs = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil then
+	tokens = capacity
+	ts = now
+end
+
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * refillPerMs)
+
+local allowed = 0
+local waitMs = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	waitMs = math.ceil((1 - tokens) / refillPerMs)
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", tostring(now))
+redis.call("PEXPIRE", KEYS[1], math.ceil(capacity / refillPerMs) + 1000)
+
+return {allowed, waitMs}
+`)
+
+// TakeToken takes a token from a token bucket. If no token is available, the duration to wait for the next token is returned.
+func (s *redisStore) TakeToken(ctx context.Context, bucketKey string, capacity int, refillPerSecond float64, now time.Time) (bool, time.Duration, error) {
+	bucketKeyWithPrefix := s.keyWithPrefix(bucketKey)
+
+	refillPerMs := strconv.FormatFloat(refillPerSecond/1000, 'f', -1, 64)
+	res, err := takeTokenScript.Run(ctx, s.client, []string{bucketKeyWithPrefix}, capacity, refillPerMs, now.UnixMilli()).Int64Slice()
+	if err != nil {
+		return false, 0, errors.Wrapf(err, "failed to take token. bucketKey: %s", bucketKeyWithPrefix)
+	}
+	if len(res) != 2 {
+		return false, 0, fmt.Errorf("take token results should contain 2 elements. bucketKey: %s", bucketKeyWithPrefix)
+	}
+
+	return res[0] == 1, time.Duration(res[1]) * time.Millisecond, nil
+}
+
+// Set sets the key without ttl
+func (s *redisStore) Set(ctx context.Context, key string, value []byte) error {
+	keyWithPrefix := s.keyWithPrefix(key)
+
+	err := s.client.Set(ctx, keyWithPrefix, value, 0).Err()
+	if err != nil {
+		return errors.Wrapf(err, "failed to set. key: %s", keyWithPrefix)
+	}
+
+	return nil
+}
+
+// SetNX sets the key with a ttl if it does not exist. Returns true if the key was set.
+func (s *redisStore) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	keyWithPrefix := s.keyWithPrefix(key)
+
+	ok, err := s.client.SetNX(ctx, keyWithPrefix, value, ttl).Result()
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to setnx. key: %s", keyWithPrefix)
+	}
+
+	return ok, nil
+}
+
+func (s *redisStore) Del(ctx context.Context, key string) error {
+	keyWithPrefix := s.keyWithPrefix(key)
+
+	err := s.client.Del(ctx, keyWithPrefix).Err()
+	if err != nil {
+		return errors.Wrapf(err, "failed to del. key: %s", keyWithPrefix)
+	}
+
+	return nil
+}
+
+// LPushTrimExpire pushes the value at the head of a list capped to maxLen values, and resets the list ttl
+func (s *redisStore) LPushTrimExpire(ctx context.Context, key string, value []byte, maxLen int, ttl time.Duration) error {
+	keyWithPrefix := s.keyWithPrefix(key)
+
+	pipe := s.client.TxPipeline()
+	pipe.LPush(ctx, keyWithPrefix, value)
+	pipe.LTrim(ctx, keyWithPrefix, 0, int64(maxLen-1))
+	pipe.Expire(ctx, keyWithPrefix, ttl)
+
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "failed to lpush trim expire. key: %s", keyWithPrefix)
+	}
+
+	return nil
+}
+
+// SetExZAddTrim sets the key with a ttl and adds the member to a sorted set, removing the members with a score below minScore.
+// The sorted set expires with the last key added.
+func (s *redisStore) SetExZAddTrim(ctx context.Context, key string, value []byte, ttl time.Duration, zsetKey string, member string, score float64, minScore float64) error {
+	keyWithPrefix := s.keyWithPrefix(key)
+	zsetKeyWithPrefix := s.keyWithPrefix(zsetKey)
+
 	pipe := s.client.TxPipeline()
+	pipe.Set(ctx, keyWithPrefix, value, ttl)
+	pipe.ZAdd(ctx, zsetKeyWithPrefix, redis.Z{Score: score, Member: member})
+	pipe.ZRemRangeByScore(ctx, zsetKeyWithPrefix, "-inf", fmt.Sprintf("(%s", strconv.FormatFloat(minScore, 'f', -1, 64)))
+	pipe.Expire(ctx, zsetKeyWithPrefix, ttl)
+
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "failed to set zadd trim. key: %s, zsetKey: %s", keyWithPrefix, zsetKeyWithPrefix)
+	}
+
+	return nil
+}
+
+// GetIngest returns the data of an ingested request, or nil if it does not exist
+func (s *redisStore) GetIngest(ctx context.Context, ingestKey string) ([]byte, error) {
+	ingestKeyWithPrefix := s.keyWithPrefix(ingestKey)
+	res, err := s.client.HGet(ctx, ingestKeyWithPrefix, "data").Result()
+	if err != nil {
+		if err == redis.Nil {
+			// no values
+			return nil, nil
+		}
+
+		return nil, errors.Wrapf(err, "failed to hget. ingestKey: %s", ingestKeyWithPrefix)
+	}
+
+	return []byte(res), nil
+}
+
+// MGet returns the values of the keys, with nil values for missing keys
+func (s *redisStore) MGet(ctx context.Context, keys []string) ([][]byte, error) {
+	keysWithPrefix := make([]string, 0, len(keys))
+	for _, key := range keys {
+		keysWithPrefix = append(keysWithPrefix, s.keyWithPrefix(key))
+	}
+
+	res, err := s.client.MGet(ctx, keysWithPrefix...).Result()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to mget")
+	}
+
+	values := make([][]byte, 0, len(res))
+	for _, v := range res {
+		str, ok := v.(string)
+		if !ok {
+			values = append(values, nil)
+			continue
+		}
+		values = append(values, []byte(str))
+	}
+
+	return values, nil
+}
+
+// removes messages from a sorted set queue and deletes them.
+// the refs of the ingested request of each removed message are decremented, and the ingested request is deleted when no message references it anymore.
+// KEYS: queue key, message keys. ARGV: message id and ingest key pairs, with an empty ingest key for messages not referencing an ingested request.
+// returns 1 for each message whose request data is not referenced anymore, 0 otherwise.
+var zRemDelReleaseIngestsScript = redis.NewScript(`
+local released = {}
+for i = 2, #KEYS do
+	local j = (i - 2) * 2
+	local removed = redis.call("ZREM", KEYS[1], ARGV[j + 1])
+	redis.call("DEL", KEYS[i])
+
+	released[i - 1] = 0
+	local ingestKey = ARGV[j + 2]
+	if removed == 1 then
+		if ingestKey == "" then
+			released[i - 1] = 1
+		elseif redis.call("HINCRBY", ingestKey, "refs", -1) <= 0 then
+			redis.call("DEL", ingestKey)
+			released[i - 1] = 1
+		end
+	end
+end
+
+return released
+`)
+
+// ZRemDelReleaseIngests removes messages from a sorted set queue, deletes them and releases their ingested requests.
+// It returns for each message whether its request data was released, i.e. the message was removed and no other message references its ingested request.
+func (s *redisStore) ZRemDelReleaseIngests(ctx context.Context, queueKey string, messageIDs []string, messageKeys []string, ingestKeys []string) ([]bool, error) {
+	if len(messageIDs) != len(messageKeys) || len(messageIDs) != len(ingestKeys) {
+		return nil, fmt.Errorf("message ids, message keys and ingest keys should have the same length")
+	}
 
 	queueKeyWithPrefix := s.keyWithPrefix(queueKey)
-	pipe.ZRem(ctx, queueKeyWithPrefix, messageIDs)
+	keys := []string{queueKeyWithPrefix}
+	args := make([]interface{}, 0, 2*len(messageIDs))
+	for i := range messageIDs {
+		keys = append(keys, s.keyWithPrefix(messageKeys[i]))
+
+		ingestKeyWithPrefix := ""
+		if ingestKeys[i] != "" {
+			ingestKeyWithPrefix = s.keyWithPrefix(ingestKeys[i])
+		}
+		args = append(args, messageIDs[i], ingestKeyWithPrefix)
+	}
 
-	messageKeysWithPrefix := []string{}
-	for _, messageKey := range messageKeys {
-		messageKeyWithPrefix := s.keyWithPrefix(messageKey)
-		messageKeysWithPrefix = append(messageKeysWithPrefix, messageKeyWithPrefix)
+	res, err := zRemDelReleaseIngestsScript.Run(ctx, s.client, keys, args...).Int64Slice()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to zremdel and release ingests. queueKey: %s", queueKeyWithPrefix)
 	}
 
-	pipe.Del(ctx, messageKeysWithPrefix...)
+	released := make([]bool, 0, len(res))
+	for _, r := range res {
+		released = append(released, r == 1)
+	}
 
-	_, err := pipe.Exec(ctx)
+	return released, nil
+}
+
+// ScanKeys iterates over the keys matching the pattern and calls fn with each batch of keys, without the prefix
+func (s *redisStore) ScanKeys(ctx context.Context, match string, fn func(keys []string) error) error {
+	prefix := s.keyWithPrefix("")
+	matchWithPrefix := s.keyWithPrefix(match)
+
+	var cursor uint64
+	for {
+		keysWithPrefix, nextCursor, err := s.client.Scan(ctx, cursor, matchWithPrefix, 100).Result()
+		if err != nil {
+			return errors.Wrapf(err, "failed to scan. match: %s", matchWithPrefix)
+		}
+
+		if len(keysWithPrefix) > 0 {
+			keys := make([]string, 0, len(keysWithPrefix))
+			for _, key := range keysWithPrefix {
+				keys = append(keys, strings.TrimPrefix(key, prefix))
+			}
+
+			err = fn(keys)
+			if err != nil {
+				return err
+			}
+		}
+
+		cursor = nextCursor
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// sets a string key or a hash field to a new value only if it still holds the old value
+// KEYS: key. ARGV: old value, new value, hash field (empty for string keys)
+var compareAndSetScript = redis.NewScript(`
+local current
+if ARGV[3] == "" then
+	current = redis.call("GET", KEYS[1])
+else
+	current = redis.call("HGET", KEYS[1], ARGV[3])
+end
+
+if current ~= ARGV[1] then
+	return 0
+end
+
+if ARGV[3] == "" then
+	redis.call("SET", KEYS[1], ARGV[2], "KEEPTTL")
+else
+	redis.call("HSET", KEYS[1], ARGV[3], ARGV[2])
+end
+
+return 1
+`)
+
+// CompareAndSet sets the key to the new value if it was not modified since the old value was read. Returns true if the key was set.
+func (s *redisStore) CompareAndSet(ctx context.Context, key string, oldValue []byte, newValue []byte) (bool, error) {
+	keyWithPrefix := s.keyWithPrefix(key)
+
+	res, err := compareAndSetScript.Run(ctx, s.client, []string{keyWithPrefix}, oldValue, newValue, "").Int()
 	if err != nil {
-		return err
+		return false, errors.Wrapf(err, "failed to compare and set. key: %s", keyWithPrefix)
+	}
+
+	return res == 1, nil
+}
+
+// CompareAndSetIngest sets the data of an ingested request if it was not modified since the old value was read. Returns true if the data was set.
+func (s *redisStore) CompareAndSetIngest(ctx context.Context, ingestKey string, oldValue []byte, newValue []byte) (bool, error) {
+	ingestKeyWithPrefix := s.keyWithPrefix(ingestKey)
+
+	res, err := compareAndSetScript.Run(ctx, s.client, []string{ingestKeyWithPrefix}, oldValue, newValue, "data").Int()
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to compare and set ingest. ingestKey: %s", ingestKeyWithPrefix)
+	}
+
+	return res == 1, nil
+}
+
+// replaces the first list item holding the old value, keeping the list ttl
+// KEYS: list key. ARGV: old value, new value
+var compareAndSetListItemScript = redis.NewScript(`
+local values = redis.call("LRANGE", KEYS[1], 0, -1)
+for i, value in ipairs(values) do
+	if value == ARGV[1] then
+		redis.call("LSET", KEYS[1], i - 1, ARGV[2])
+		return 1
+	end
+end
+
+return 0
+`)
+
+// CompareAndSetListItem replaces a list item if it is still in the list. Returns true if the item was replaced.
+func (s *redisStore) CompareAndSetListItem(ctx context.Context, listKey string, oldValue []byte, newValue []byte) (bool, error) {
+	listKeyWithPrefix := s.keyWithPrefix(listKey)
+
+	res, err := compareAndSetListItemScript.Run(ctx, s.client, []string{listKeyWithPrefix}, oldValue, newValue).Int()
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to compare and set list item. listKey: %s", listKeyWithPrefix)
+	}
+
+	return res == 1, nil
+}
+
+// XGroupCreate creates the consumer group reading the stream from its start, and the stream if it does not exist.
+// Creating a group that already exists is not an error.
+// The stream keys are not prefixed as the streams are written by other services.
+func (s *redisStore) XGroupCreate(ctx context.Context, stream string, group string) error {
+	err := s.client.XGroupCreateMkStream(ctx, stream, group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return errors.Wrapf(err, "failed to create consumer group. stream: %s, group: %s", stream, group)
 	}
 
 	return nil
 }
+
+// XReadGroup reads up to count entries of the stream for the consumer, starting after id.
+// The id ">" reads new entries, waiting up to block for entries to be added, other ids read the entries pending for the consumer.
+func (s *redisStore) XReadGroup(ctx context.Context, stream string, group string, consumer string, id string, count int, block time.Duration) ([]*models.StreamEntry, error) {
+	args := &redis.XReadGroupArgs{
+		Group:    group,
+		Consumer: consumer,
+		Streams:  []string{stream, id},
+		Count:    int64(count),
+		Block:    block,
+	}
+	if id != ">" {
+		// pending entries are returned immediately
+		args.Block = -1
+	}
+
+	res, err := s.client.XReadGroup(ctx, args).Result()
+	if err != nil {
+		if err == redis.Nil {
+			// no entries
+			return []*models.StreamEntry{}, nil
+		}
+
+		return nil, errors.Wrapf(err, "failed to xreadgroup. stream: %s, group: %s", stream, group)
+	}
+
+	entries := []*models.StreamEntry{}
+	for _, xStream := range res {
+		entries = append(entries, streamEntries(xStream.Messages)...)
+	}
+
+	return entries, nil
+}
+
+func (s *redisStore) XAck(ctx context.Context, stream string, group string, ids []string) error {
+	err := s.client.XAck(ctx, stream, group, ids...).Err()
+	if err != nil {
+		return errors.Wrapf(err, "failed to xack. stream: %s, group: %s", stream, group)
+	}
+
+	return nil
+}
+
+// XAutoClaim transfers to the consumer up to count entries pending for other consumers of the group for at least minIdle, and returns them
+func (s *redisStore) XAutoClaim(ctx context.Context, stream string, group string, consumer string, minIdle time.Duration, count int) ([]*models.StreamEntry, error) {
+	xMessages, _, err := s.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   stream,
+		Group:    group,
+		Consumer: consumer,
+		MinIdle:  minIdle,
+		Start:    "0-0",
+		Count:    int64(count),
+	}).Result()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to xautoclaim. stream: %s, group: %s", stream, group)
+	}
+
+	return streamEntries(xMessages), nil
+}
+
+func streamEntries(xMessages []redis.XMessage) []*models.StreamEntry {
+	entries := make([]*models.StreamEntry, 0, len(xMessages))
+	for _, xMessage := range xMessages {
+		entry := &models.StreamEntry{ID: xMessage.ID, Values: map[string]string{}}
+		for field, value := range xMessage.Values {
+			entry.Values[field] = fmt.Sprint(value)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
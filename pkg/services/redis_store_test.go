@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/didil/inhooks/pkg/lib"
+	"github.com/didil/inhooks/pkg/models"
 	"github.com/didil/inhooks/pkg/testsupport"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/suite"
@@ -142,7 +143,7 @@ func (s *RedisStoreSuite) TestBLMove() {
 	s.Nil(noVal)
 }
 
-func (s *RedisStoreSuite) TestSetAndEnqueue_SetAndMove() {
+func (s *RedisStoreSuite) TestSetAndMove() {
 	ctx := context.Background()
 	prefix := fmt.Sprintf("inhooks:%s", s.appConf.Redis.InhooksDBName)
 	defer func() {
@@ -162,7 +163,7 @@ func (s *RedisStoreSuite) TestSetAndEnqueue_SetAndMove() {
 	messageID3 := "xyz789"
 	messageKey3 := "messages:xyz789"
 
-	err := s.redisStore.SetAndEnqueue(ctx, messageKey1, value1, queueKeyProcessing, messageID1)
+	err := s.redisStore.SetAndEnqueueBatch(ctx, nil, []*models.EnqueueEntry{{MessageKey: messageKey1, Value: value1, QueueKey: queueKeyProcessing, MessageID: messageID1}})
 	s.NoError(err)
 
 	queueResults, err := s.client.LRange(ctx, fmt.Sprintf("%s:%s", prefix, queueKeyProcessing), 0, -1).Result()
@@ -173,10 +174,10 @@ func (s *RedisStoreSuite) TestSetAndEnqueue_SetAndMove() {
 	s.NoError(err)
 	s.Equal(value1, val)
 
-	err = s.redisStore.SetAndEnqueue(ctx, messageKey2, value2, queueKeyProcessing, messageID2)
+	err = s.redisStore.SetAndEnqueueBatch(ctx, nil, []*models.EnqueueEntry{{MessageKey: messageKey2, Value: value2, QueueKey: queueKeyProcessing, MessageID: messageID2}})
 	s.NoError(err)
 
-	err = s.redisStore.SetAndEnqueue(ctx, messageKey3, value3, queueKeyProcessing, messageID3)
+	err = s.redisStore.SetAndEnqueueBatch(ctx, nil, []*models.EnqueueEntry{{MessageKey: messageKey3, Value: value3, QueueKey: queueKeyProcessing, MessageID: messageID3}})
 	s.NoError(err)
 
 	queueResults, err = s.client.LRange(ctx, fmt.Sprintf("%s:%s", prefix, queueKeyProcessing), 0, -1).Result()
@@ -203,43 +204,6 @@ func (s *RedisStoreSuite) TestSetAndEnqueue_SetAndMove() {
 
 }
 
-func (s *RedisStoreSuite) TestSetAndZAdd() {
-	ctx := context.Background()
-	prefix := fmt.Sprintf("inhooks:%s", s.appConf.Redis.InhooksDBName)
-	defer func() {
-		err := testsupport.DeleteAllRedisKeys(ctx, s.client, prefix)
-		s.NoError(err)
-	}()
-
-	value1 := []byte(`{"id": 123}`)
-	deliverAfter := time.Date(2023, 05, 5, 8, 9, 24, 0, time.UTC).Unix()
-
-	setKey := "scheduled"
-	messageID := "abc123"
-	messageKey := "messages:abc123"
-
-	err := s.redisStore.SetAndZAdd(ctx, messageKey, value1, setKey, messageID, float64(deliverAfter))
-	s.NoError(err)
-
-	setKeyWithPrefix := fmt.Sprintf("%s:%s", prefix, setKey)
-
-	prevDate := time.Date(2023, 05, 4, 8, 9, 24, 0, time.UTC).Unix()
-	otherID := "my-id"
-
-	_, err = s.client.ZAdd(ctx, setKeyWithPrefix, redis.Z{Score: float64(prevDate), Member: otherID}).Result()
-	s.NoError(err)
-
-	queueResults, err := s.client.ZRange(ctx, setKeyWithPrefix, 0, -1).Result()
-	s.NoError(err)
-
-	s.Equal([]string{"my-id", "abc123"}, queueResults)
-
-	val, err := s.client.Get(ctx, fmt.Sprintf("%s:%s", prefix, messageKey)).Result()
-	s.NoError(err)
-
-	s.Equal(string(value1), val)
-}
-
 func (s *RedisStoreSuite) TestSetLRemZAdd() {
 	ctx := context.Background()
 	prefix := fmt.Sprintf("inhooks:%s", s.appConf.Redis.InhooksDBName)
@@ -261,9 +225,9 @@ func (s *RedisStoreSuite) TestSetLRemZAdd() {
 	messageID2 := "def456"
 	messageKey2 := "messages:def456"
 
-	err := s.redisStore.SetAndEnqueue(ctx, messageKey1, value1, queueKeyProcessing, messageID1)
+	err := s.redisStore.SetAndEnqueueBatch(ctx, nil, []*models.EnqueueEntry{{MessageKey: messageKey1, Value: value1, QueueKey: queueKeyProcessing, MessageID: messageID1}})
 	s.NoError(err)
-	err = s.redisStore.SetAndEnqueue(ctx, messageKey2, value2, queueKeyProcessing, messageID2)
+	err = s.redisStore.SetAndEnqueueBatch(ctx, nil, []*models.EnqueueEntry{{MessageKey: messageKey2, Value: value2, QueueKey: queueKeyProcessing, MessageID: messageID2}})
 	s.NoError(err)
 
 	queueResults, err := s.client.LRange(ctx, fmt.Sprintf("%s:%s", prefix, queueKeyProcessing), 0, -1).Result()
@@ -483,7 +447,7 @@ func (s *RedisStoreSuite) TestZRemRangeBelowScore() {
 	s.Equal([]string{"message-2", "message-4"}, queueResults)
 }
 
-func (s *RedisStoreSuite) TestZRemDel() {
+func (s *RedisStoreSuite) TestLLenZCard() {
 	ctx := context.Background()
 	prefix := fmt.Sprintf("inhooks:%s", s.appConf.Redis.InhooksDBName)
 	defer func() {
@@ -491,37 +455,430 @@ func (s *RedisStoreSuite) TestZRemDel() {
 		s.NoError(err)
 	}()
 
-	now := time.Date(2023, 05, 5, 8, 9, 24, 0, time.UTC)
+	listKey := "q:ready"
+	zsetKey := "q:scheduled"
 
-	queueKey := "q:scheduled"
-	queueKeyWithPrefix := fmt.Sprintf("%s:%s", prefix, queueKey)
+	count, err := s.redisStore.LLenZCard(ctx, listKey, zsetKey)
+	s.NoError(err)
+	s.Equal(0, count)
 
-	m1ID := "message-1"
-	m2ID := "message-2"
-	m3ID := "message-3"
-	m4ID := "message-4"
+	err = s.client.RPush(ctx, fmt.Sprintf("%s:%s", prefix, listKey), "message-1", "message-2").Err()
+	s.NoError(err)
 
-	messageIDs := []string{m1ID, m3ID}
-	messageKeys := []string{fmt.Sprintf("m:%s", m1ID), fmt.Sprintf("m:%s", m3ID)}
+	err = s.client.ZAdd(ctx, fmt.Sprintf("%s:%s", prefix, zsetKey), redis.Z{Score: 1, Member: "message-3"}).Err()
+	s.NoError(err)
 
-	_, err := s.client.ZAdd(ctx, queueKeyWithPrefix, redis.Z{Score: float64(now.Unix()), Member: m1ID}).Result()
+	count, err = s.redisStore.LLenZCard(ctx, listKey, zsetKey)
 	s.NoError(err)
+	s.Equal(3, count)
+}
 
-	_, err = s.client.ZAdd(ctx, queueKeyWithPrefix, redis.Z{Score: float64(now.Add(5 * time.Minute).Unix()), Member: m2ID}).Result()
+func (s *RedisStoreSuite) TestTakeToken() {
+	ctx := context.Background()
+	prefix := fmt.Sprintf("inhooks:%s", s.appConf.Redis.InhooksDBName)
+	defer func() {
+		err := testsupport.DeleteAllRedisKeys(ctx, s.client, prefix)
+		s.NoError(err)
+	}()
+
+	bucketKey := "src:source-1:rl"
+	now := time.Date(2023, 05, 5, 8, 9, 12, 0, time.UTC)
+
+	// capacity 2, 1 token every 2 seconds
+	for i := 0; i < 2; i++ {
+		allowed, retryAfter, err := s.redisStore.TakeToken(ctx, bucketKey, 2, 0.5, now)
+		s.NoError(err)
+		s.True(allowed)
+		s.Equal(time.Duration(0), retryAfter)
+	}
+
+	allowed, retryAfter, err := s.redisStore.TakeToken(ctx, bucketKey, 2, 0.5, now.Add(500*time.Millisecond))
 	s.NoError(err)
+	s.False(allowed)
+	s.Equal(1500*time.Millisecond, retryAfter)
 
-	_, err = s.client.ZAdd(ctx, queueKeyWithPrefix, redis.Z{Score: float64(now.Add(-5 * time.Minute).Unix()), Member: m3ID}).Result()
+	allowed, _, err = s.redisStore.TakeToken(ctx, bucketKey, 2, 0.5, now.Add(2*time.Second))
 	s.NoError(err)
+	s.True(allowed)
 
-	_, err = s.client.ZAdd(ctx, queueKeyWithPrefix, redis.Z{Score: float64(now.Add(20 * time.Minute).Unix()), Member: m4ID}).Result()
+	ttl, err := s.client.PTTL(ctx, fmt.Sprintf("%s:%s", prefix, bucketKey)).Result()
 	s.NoError(err)
+	s.Greater(ttl, time.Duration(0))
+}
 
-	err = s.redisStore.ZRemDel(ctx, queueKey, messageIDs, messageKeys)
+func (s *RedisStoreSuite) TestSetNX_Del() {
+	ctx := context.Background()
+	prefix := fmt.Sprintf("inhooks:%s", s.appConf.Redis.InhooksDBName)
+	defer func() {
+		err := testsupport.DeleteAllRedisKeys(ctx, s.client, prefix)
+		s.NoError(err)
+	}()
+
+	key := "src:source-1:idem:key-1"
+
+	ok, err := s.redisStore.SetNX(ctx, key, []byte("req-1"), time.Hour)
 	s.NoError(err)
+	s.True(ok)
 
-	queueResults, err := s.client.ZRange(ctx, queueKeyWithPrefix, 0, -1).Result()
+	ok, err = s.redisStore.SetNX(ctx, key, []byte("req-2"), time.Hour)
 	s.NoError(err)
+	s.False(ok)
 
-	s.Equal([]string{"message-2", "message-4"}, queueResults)
+	val, err := s.client.Get(ctx, fmt.Sprintf("%s:%s", prefix, key)).Result()
+	s.NoError(err)
+	s.Equal("req-1", val)
+
+	ttl, err := s.client.PTTL(ctx, fmt.Sprintf("%s:%s", prefix, key)).Result()
+	s.NoError(err)
+	s.Greater(ttl, time.Duration(0))
+
+	err = s.redisStore.Del(ctx, key)
+	s.NoError(err)
+
+	ok, err = s.redisStore.SetNX(ctx, key, []byte("req-3"), time.Hour)
+	s.NoError(err)
+	s.True(ok)
+}
+
+func (s *RedisStoreSuite) TestLPushTrimExpire() {
+	ctx := context.Background()
+	prefix := fmt.Sprintf("inhooks:%s", s.appConf.Redis.InhooksDBName)
+	defer func() {
+		err := testsupport.DeleteAllRedisKeys(ctx, s.client, prefix)
+		s.NoError(err)
+	}()
+
+	key := "f:flow-1:captures"
+
+	for _, value := range []string{"req-1", "req-2", "req-3"} {
+		err := s.redisStore.LPushTrimExpire(ctx, key, []byte(value), 2, time.Hour)
+		s.NoError(err)
+	}
+
+	// the most recent values are kept
+	values, err := s.redisStore.LRangeAll(ctx, key)
+	s.NoError(err)
+	s.Equal([]string{"req-3", "req-2"}, values)
+
+	ttl, err := s.client.PTTL(ctx, fmt.Sprintf("%s:%s", prefix, key)).Result()
+	s.NoError(err)
+	s.Greater(ttl, time.Duration(0))
+}
+
+func (s *RedisStoreSuite) TestSetExZAddTrim() {
+	ctx := context.Background()
+	prefix := fmt.Sprintf("inhooks:%s", s.appConf.Redis.InhooksDBName)
+	defer func() {
+		err := testsupport.DeleteAllRedisKeys(ctx, s.client, prefix)
+		s.NoError(err)
+	}()
+
+	zsetKey := "src:source-1:log"
 
+	err := s.redisStore.SetExZAddTrim(ctx, "src:source-1:log:r:req-1", []byte("entry-1"), time.Hour, zsetKey, "req-1", 100, 0)
+	s.NoError(err)
+	err = s.redisStore.SetExZAddTrim(ctx, "src:source-1:log:r:req-2", []byte("entry-2"), time.Hour, zsetKey, "req-2", 200, 0)
+	s.NoError(err)
+	// members below the min score are removed
+	err = s.redisStore.SetExZAddTrim(ctx, "src:source-1:log:r:req-3", []byte("entry-3"), time.Hour, zsetKey, "req-3", 300, 150)
+	s.NoError(err)
+
+	members, err := s.redisStore.ZRangeByScore(ctx, zsetKey, 0, 1000)
+	s.NoError(err)
+	s.Equal([]string{"req-2", "req-3"}, members)
+
+	members, err = s.redisStore.ZRangeByScore(ctx, zsetKey, 250, 300)
+	s.NoError(err)
+	s.Equal([]string{"req-3"}, members)
+
+	value, err := s.redisStore.Get(ctx, "src:source-1:log:r:req-3")
+	s.NoError(err)
+	s.Equal([]byte("entry-3"), value)
+
+	for _, key := range []string{"src:source-1:log:r:req-3", zsetKey} {
+		ttl, err := s.client.PTTL(ctx, fmt.Sprintf("%s:%s", prefix, key)).Result()
+		s.NoError(err)
+		s.Greater(ttl, time.Duration(0))
+	}
+}
+
+func (s *RedisStoreSuite) TestZAdd() {
+	ctx := context.Background()
+	prefix := fmt.Sprintf("inhooks:%s", s.appConf.Redis.InhooksDBName)
+	defer func() {
+		err := testsupport.DeleteAllRedisKeys(ctx, s.client, prefix)
+		s.NoError(err)
+	}()
+
+	zsetKey := "src:source-1:log:bodies"
+
+	err := s.redisStore.ZAdd(ctx, zsetKey, "req-2", 200)
+	s.NoError(err)
+	err = s.redisStore.ZAdd(ctx, zsetKey, "req-1", 100)
+	s.NoError(err)
+
+	members, err := s.redisStore.ZRangeBelowScore(ctx, zsetKey, 150)
+	s.NoError(err)
+	s.Equal([]string{"req-1"}, members)
+}
+
+func (s *RedisStoreSuite) TestXReadGroup_XAck() {
+	ctx := context.Background()
+	prefix := fmt.Sprintf("inhooks:%s", s.appConf.Redis.InhooksDBName)
+	defer func() {
+		err := testsupport.DeleteAllRedisKeys(ctx, s.client, prefix)
+		s.NoError(err)
+	}()
+
+	// the stream key is not prefixed by the store
+	stream := fmt.Sprintf("%s:events", prefix)
+	group := "inhooks"
+
+	err := s.redisStore.XGroupCreate(ctx, stream, group)
+	s.NoError(err)
+	// the group already exists
+	err = s.redisStore.XGroupCreate(ctx, stream, group)
+	s.NoError(err)
+
+	id1, err := s.client.XAdd(ctx, &redis.XAddArgs{Stream: stream, Values: map[string]interface{}{"payload": `{"id": 1}`, "X-Event": "created"}}).Result()
+	s.NoError(err)
+	id2, err := s.client.XAdd(ctx, &redis.XAddArgs{Stream: stream, Values: map[string]interface{}{"payload": `{"id": 2}`}}).Result()
+	s.NoError(err)
+
+	entries, err := s.redisStore.XReadGroup(ctx, stream, group, "consumer-1", ">", 10, 10*time.Millisecond)
+	s.NoError(err)
+	s.Equal([]*models.StreamEntry{
+		{ID: id1, Values: map[string]string{"payload": `{"id": 1}`, "X-Event": "created"}},
+		{ID: id2, Values: map[string]string{"payload": `{"id": 2}`}},
+	}, entries)
+
+	// no new entries
+	entries, err = s.redisStore.XReadGroup(ctx, stream, group, "consumer-1", ">", 10, 10*time.Millisecond)
+	s.NoError(err)
+	s.Empty(entries)
+
+	err = s.redisStore.XAck(ctx, stream, group, []string{id1})
+	s.NoError(err)
+
+	// the entries not acked are pending for the consumer
+	entries, err = s.redisStore.XReadGroup(ctx, stream, group, "consumer-1", "0", 10, 0)
+	s.NoError(err)
+	s.Len(entries, 1)
+	s.Equal(id2, entries[0].ID)
+
+	entries, err = s.redisStore.XReadGroup(ctx, stream, group, "consumer-2", "0", 10, 0)
+	s.NoError(err)
+	s.Empty(entries)
+
+	// the entries pending for other consumers are claimed once idle
+	entries, err = s.redisStore.XAutoClaim(ctx, stream, group, "consumer-2", time.Hour, 10)
+	s.NoError(err)
+	s.Empty(entries)
+
+	entries, err = s.redisStore.XAutoClaim(ctx, stream, group, "consumer-2", 0, 10)
+	s.NoError(err)
+	s.Equal([]*models.StreamEntry{{ID: id2, Values: map[string]string{"payload": `{"id": 2}`}}}, entries)
+
+	entries, err = s.redisStore.XReadGroup(ctx, stream, group, "consumer-2", "0", 10, 0)
+	s.NoError(err)
+	s.Len(entries, 1)
+	s.Equal(id2, entries[0].ID)
+}
+
+func (s *RedisStoreSuite) TestSetAndEnqueueBatch() {
+	ctx := context.Background()
+	prefix := fmt.Sprintf("inhooks:%s", s.appConf.Redis.InhooksDBName)
+	defer func() {
+		err := testsupport.DeleteAllRedisKeys(ctx, s.client, prefix)
+		s.NoError(err)
+	}()
+
+	score := float64(time.Date(2023, 05, 5, 8, 9, 24, 0, time.UTC).Unix())
+	entries := []*models.EnqueueEntry{
+		{MessageKey: "f:flow-1:s:sink-1:m:message-1", Value: []byte(`{"id": 1}`), QueueKey: "f:flow-1:s:sink-1:q:ready", MessageID: "message-1"},
+		{MessageKey: "f:flow-1:s:sink-2:m:message-2", Value: []byte(`{"id": 2}`), QueueKey: "f:flow-1:s:sink-2:q:scheduled", MessageID: "message-2", Score: &score},
+	}
+
+	// nothing is written when the transaction fails
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	err := s.redisStore.SetAndEnqueueBatch(cancelledCtx, nil, entries)
+	s.Error(err)
+
+	keys, err := s.client.Keys(ctx, fmt.Sprintf("%s:*", prefix)).Result()
+	s.NoError(err)
+	s.Empty(keys)
+
+	err = s.redisStore.SetAndEnqueueBatch(ctx, nil, entries)
+	s.NoError(err)
+
+	readyResults, err := s.client.LRange(ctx, fmt.Sprintf("%s:f:flow-1:s:sink-1:q:ready", prefix), 0, -1).Result()
+	s.NoError(err)
+	s.Equal([]string{"message-1"}, readyResults)
+
+	scheduledResults, err := s.client.ZRangeWithScores(ctx, fmt.Sprintf("%s:f:flow-1:s:sink-2:q:scheduled", prefix), 0, -1).Result()
+	s.NoError(err)
+	s.Equal([]redis.Z{{Score: score, Member: "message-2"}}, scheduledResults)
+
+	for _, entry := range entries {
+		val, err := s.client.Get(ctx, fmt.Sprintf("%s:%s", prefix, entry.MessageKey)).Result()
+		s.NoError(err)
+		s.Equal(string(entry.Value), val)
+	}
+
+	// the marker is set with the messages
+	marker := &models.EnqueueMarker{Key: "src:source-1:cron:1683252000:done", Value: []byte("2023-05-05T02:00:00Z"), TTL: time.Hour}
+	err = s.redisStore.SetAndEnqueueBatchMarked(ctx, nil, entries[:1], marker)
+	s.NoError(err)
+
+	val, err := s.client.Get(ctx, fmt.Sprintf("%s:%s", prefix, marker.Key)).Result()
+	s.NoError(err)
+	s.Equal("2023-05-05T02:00:00Z", val)
+
+	ttl, err := s.client.TTL(ctx, fmt.Sprintf("%s:%s", prefix, marker.Key)).Result()
+	s.NoError(err)
+	s.Equal(time.Hour, ttl)
+}
+
+func (s *RedisStoreSuite) TestSetAndEnqueueBatch_IngestedRequests() {
+	ctx := context.Background()
+	prefix := fmt.Sprintf("inhooks:%s", s.appConf.Redis.InhooksDBName)
+	defer func() {
+		err := testsupport.DeleteAllRedisKeys(ctx, s.client, prefix)
+		s.NoError(err)
+	}()
+
+	ingestKey := "f:flow-1:i:ingest-1"
+	ingestEntries := []*models.IngestEntry{
+		{IngestKey: ingestKey, Value: []byte(`{"payload": "abc"}`), Refs: 2},
+	}
+
+	doneQueueKey := "f:flow-1:s:sink-1:q:done"
+	entries := []*models.EnqueueEntry{
+		{MessageKey: "f:flow-1:s:sink-1:m:message-1", Value: []byte(`{"id": 1}`), QueueKey: doneQueueKey, MessageID: "message-1", Score: new(float64)},
+		{MessageKey: "f:flow-1:s:sink-1:m:message-2", Value: []byte(`{"id": 2}`), QueueKey: doneQueueKey, MessageID: "message-2", Score: new(float64)},
+	}
+
+	err := s.redisStore.SetAndEnqueueBatch(ctx, ingestEntries, entries)
+	s.NoError(err)
+
+	val, err := s.redisStore.GetIngest(ctx, ingestKey)
+	s.NoError(err)
+	s.Equal([]byte(`{"payload": "abc"}`), val)
+
+	values, err := s.redisStore.MGet(ctx, []string{entries[0].MessageKey, "f:flow-1:s:sink-1:m:other", entries[1].MessageKey})
+	s.NoError(err)
+	s.Equal([][]byte{entries[0].Value, nil, entries[1].Value}, values)
+
+	// releasing the first message keeps the ingested request
+	released, err := s.redisStore.ZRemDelReleaseIngests(ctx, doneQueueKey, []string{"message-1"}, []string{entries[0].MessageKey}, []string{ingestKey})
+	s.NoError(err)
+	s.Equal([]bool{false}, released)
+
+	val, err = s.redisStore.GetIngest(ctx, ingestKey)
+	s.NoError(err)
+	s.NotNil(val)
+
+	// releasing an already removed message is a no-op
+	released, err = s.redisStore.ZRemDelReleaseIngests(ctx, doneQueueKey, []string{"message-1"}, []string{entries[0].MessageKey}, []string{ingestKey})
+	s.NoError(err)
+	s.Equal([]bool{false}, released)
+
+	val, err = s.redisStore.GetIngest(ctx, ingestKey)
+	s.NoError(err)
+	s.NotNil(val)
+
+	// releasing the last message deletes the ingested request
+	released, err = s.redisStore.ZRemDelReleaseIngests(ctx, doneQueueKey, []string{"message-2"}, []string{entries[1].MessageKey}, []string{ingestKey})
+	s.NoError(err)
+	s.Equal([]bool{true}, released)
+
+	val, err = s.redisStore.GetIngest(ctx, ingestKey)
+	s.NoError(err)
+	s.Nil(val)
+
+	keys, err := s.client.Keys(ctx, fmt.Sprintf("%s:*", prefix)).Result()
+	s.NoError(err)
+	s.Empty(keys)
+}
+
+func (s *RedisStoreSuite) TestScanKeys_CompareAndSet() {
+	ctx := context.Background()
+	prefix := fmt.Sprintf("inhooks:%s", s.appConf.Redis.InhooksDBName)
+	defer func() {
+		err := testsupport.DeleteAllRedisKeys(ctx, s.client, prefix)
+		s.NoError(err)
+	}()
+
+	messageKeys := []string{}
+	for i := 0; i < 150; i++ {
+		messageKey := fmt.Sprintf("f:flow-1:s:sink-1:m:message-%d", i)
+		messageKeys = append(messageKeys, messageKey)
+		err := s.client.Set(ctx, fmt.Sprintf("%s:%s", prefix, messageKey), "v1", 0).Err()
+		s.NoError(err)
+	}
+	err := s.client.RPush(ctx, fmt.Sprintf("%s:f:flow-1:s:sink-1:q:ready", prefix), "message-1").Err()
+	s.NoError(err)
+
+	scannedKeys := []string{}
+	err = s.redisStore.ScanKeys(ctx, "f:*:s:*:m:*", func(keys []string) error {
+		scannedKeys = append(scannedKeys, keys...)
+		return nil
+	})
+	s.NoError(err)
+	s.ElementsMatch(messageKeys, scannedKeys)
+
+	ok, err := s.redisStore.CompareAndSet(ctx, messageKeys[0], []byte("v1"), []byte("v2"))
+	s.NoError(err)
+	s.True(ok)
+
+	ok, err = s.redisStore.CompareAndSet(ctx, messageKeys[0], []byte("v1"), []byte("v3"))
+	s.NoError(err)
+	s.False(ok)
+
+	val, err := s.client.Get(ctx, fmt.Sprintf("%s:%s", prefix, messageKeys[0])).Result()
+	s.NoError(err)
+	s.Equal("v2", val)
+
+	ingestKey := "f:flow-1:i:ingest-1"
+	err = s.redisStore.SetAndEnqueueBatch(ctx, []*models.IngestEntry{{IngestKey: ingestKey, Value: []byte("d1"), Refs: 1}}, nil)
+	s.NoError(err)
+
+	ok, err = s.redisStore.CompareAndSetIngest(ctx, ingestKey, []byte("d1"), []byte("d2"))
+	s.NoError(err)
+	s.True(ok)
+
+	ok, err = s.redisStore.CompareAndSetIngest(ctx, ingestKey, []byte("d1"), []byte("d3"))
+	s.NoError(err)
+	s.False(ok)
+
+	data, err := s.redisStore.GetIngest(ctx, ingestKey)
+	s.NoError(err)
+	s.Equal([]byte("d2"), data)
+
+	refs, err := s.client.HGet(ctx, fmt.Sprintf("%s:%s", prefix, ingestKey), "refs").Result()
+	s.NoError(err)
+	s.Equal("1", refs)
+
+	listKey := "f:flow-1:captures"
+	for _, value := range []string{"c1", "c2"} {
+		err = s.redisStore.LPushTrimExpire(ctx, listKey, []byte(value), 10, time.Hour)
+		s.NoError(err)
+	}
+
+	ok, err = s.redisStore.CompareAndSetListItem(ctx, listKey, []byte("c1"), []byte("c1-new"))
+	s.NoError(err)
+	s.True(ok)
+
+	ok, err = s.redisStore.CompareAndSetListItem(ctx, listKey, []byte("c1"), []byte("c1-other"))
+	s.NoError(err)
+	s.False(ok)
+
+	values, err := s.redisStore.LRangeAll(ctx, listKey)
+	s.NoError(err)
+	s.Equal([]string{"c2", "c1-new"}, values)
+
+	ttl, err := s.client.TTL(ctx, fmt.Sprintf("%s:%s", prefix, listKey)).Result()
+	s.NoError(err)
+	s.Greater(ttl, time.Duration(0))
 }
@@ -0,0 +1,291 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/pkg/errors"
+)
+
+type ReencryptionService interface {
+	Reencrypt(ctx context.Context) (int, error)
+}
+
+type reencryptionService struct {
+	redisStore   RedisStore
+	codec        RecordCodec
+	payloadStore PayloadStore
+}
+
+func NewReencryptionService(redisStore RedisStore, codec RecordCodec, payloadStore PayloadStore) ReencryptionService {
+	return &reencryptionService{
+		redisStore:   redisStore,
+		codec:        codec,
+		payloadStore: payloadStore,
+	}
+}
+
+// Reencrypt re-encrypts the stored messages, offloaded payloads, ingested requests, ingest log entries and bodies and captured requests that are not encrypted with the current encryption key. Returns the number of re-encrypted records.
+func (s *reencryptionService) Reencrypt(ctx context.Context) (int, error) {
+	if s.codec.EncryptionKeyID() == "" {
+		return 0, fmt.Errorf("encryption key id not set")
+	}
+
+	count := 0
+	// payloads shared by the messages of an ingested request are re-encrypted once
+	payloadRefs := map[string]bool{}
+
+	err := s.redisStore.ScanKeys(ctx, "f:*:s:*:m:*", func(keys []string) error {
+		mKeys := []string{}
+		for _, key := range keys {
+			if isMessageKey(key) {
+				mKeys = append(mKeys, key)
+			}
+		}
+		if len(mKeys) == 0 {
+			return nil
+		}
+
+		values, err := s.redisStore.MGet(ctx, mKeys)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get messages")
+		}
+
+		for i, b := range values {
+			reencrypted, err := s.reencryptRecord(b, func(oldValue, newValue []byte) (bool, error) {
+				return s.redisStore.CompareAndSet(ctx, mKeys[i], oldValue, newValue)
+			})
+			if err != nil {
+				return errors.Wrapf(err, "failed to reencrypt message %s", mKeys[i])
+			}
+			if reencrypted {
+				count++
+			}
+
+			if b == nil {
+				continue
+			}
+			m, err := decodeMessage(s.codec, b)
+			if err != nil {
+				return errors.Wrapf(err, "failed to decode message %s", mKeys[i])
+			}
+			if m.PayloadRef == "" || payloadRefs[m.PayloadRef] {
+				continue
+			}
+			payloadRefs[m.PayloadRef] = true
+
+			reencrypted, err = s.reencryptPayload(ctx, m.PayloadRef)
+			if err != nil {
+				return errors.Wrapf(err, "failed to reencrypt payload %s", m.PayloadRef)
+			}
+			if reencrypted {
+				count++
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+
+	err = s.redisStore.ScanKeys(ctx, "f:*:i:*", func(keys []string) error {
+		for _, key := range keys {
+			if !isIngestKey(key) {
+				continue
+			}
+
+			b, err := s.redisStore.GetIngest(ctx, key)
+			if err != nil {
+				return errors.Wrapf(err, "failed to get ingested request %s", key)
+			}
+
+			reencrypted, err := s.reencryptRecord(b, func(oldValue, newValue []byte) (bool, error) {
+				return s.redisStore.CompareAndSetIngest(ctx, key, oldValue, newValue)
+			})
+			if err != nil {
+				return errors.Wrapf(err, "failed to reencrypt ingested request %s", key)
+			}
+			if reencrypted {
+				count++
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+
+	err = s.redisStore.ScanKeys(ctx, "src:*:log:r:*", func(keys []string) error {
+		logKeys := []string{}
+		for _, key := range keys {
+			if isIngestLogKey(key) {
+				logKeys = append(logKeys, key)
+			}
+		}
+		if len(logKeys) == 0 {
+			return nil
+		}
+
+		values, err := s.redisStore.MGet(ctx, logKeys)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get ingest log entries")
+		}
+
+		for i, b := range values {
+			reencrypted, err := s.reencryptRecord(b, func(oldValue, newValue []byte) (bool, error) {
+				return s.redisStore.CompareAndSet(ctx, logKeys[i], oldValue, newValue)
+			})
+			if err != nil {
+				return errors.Wrapf(err, "failed to reencrypt ingest log entry %s", logKeys[i])
+			}
+			if reencrypted {
+				count++
+			}
+
+			if b == nil {
+				continue
+			}
+			decoded, err := s.codec.Decode(b)
+			if err != nil {
+				return errors.Wrapf(err, "failed to decode ingest log entry %s", logKeys[i])
+			}
+			entry := &models.IngestLogEntry{}
+			err = json.Unmarshal(decoded, entry)
+			if err != nil {
+				return errors.Wrapf(err, "failed to unmarshal ingest log entry %s", logKeys[i])
+			}
+			if entry.BodyRef == "" {
+				continue
+			}
+
+			reencrypted, err = s.reencryptPayload(ctx, entry.BodyRef)
+			if err != nil {
+				return errors.Wrapf(err, "failed to reencrypt ingest log body %s", entry.BodyRef)
+			}
+			if reencrypted {
+				count++
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+
+	err = s.redisStore.ScanKeys(ctx, "f:*:captures", func(keys []string) error {
+		for _, key := range keys {
+			if !isCaptureKey(key) {
+				continue
+			}
+
+			values, err := s.redisStore.LRangeAll(ctx, key)
+			if err != nil {
+				return errors.Wrapf(err, "failed to get captured requests %s", key)
+			}
+
+			for _, value := range values {
+				reencrypted, err := s.reencryptRecord([]byte(value), func(oldValue, newValue []byte) (bool, error) {
+					return s.redisStore.CompareAndSetListItem(ctx, key, oldValue, newValue)
+				})
+				if err != nil {
+					return errors.Wrapf(err, "failed to reencrypt captured request %s", key)
+				}
+				if reencrypted {
+					count++
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+
+	return count, nil
+}
+
+// reencryptRecord encodes the record with the current key. Records modified concurrently are skipped as they are written with the current key.
+func (s *reencryptionService) reencryptRecord(b []byte, compareAndSet func(oldValue, newValue []byte) (bool, error)) (bool, error) {
+	if b == nil || s.codec.RecordKeyID(b) == s.codec.EncryptionKeyID() {
+		return false, nil
+	}
+
+	decoded, err := s.codec.Decode(b)
+	if err != nil {
+		return false, err
+	}
+
+	encoded, err := s.codec.Encode(decoded)
+	if err != nil {
+		return false, err
+	}
+
+	return compareAndSet(b, encoded)
+}
+
+// reencryptPayload encodes an offloaded payload with the current key. Payloads deleted since the message was read are skipped.
+// Payloads are never updated once stored, so they are rewritten without a compare and set.
+func (s *reencryptionService) reencryptPayload(ctx context.Context, payloadRef string) (bool, error) {
+	if s.payloadStore == nil {
+		return false, fmt.Errorf("payload store not configured")
+	}
+
+	b, err := s.payloadStore.Get(ctx, payloadRef)
+	if errors.Is(err, ErrPayloadNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if s.codec.RecordKeyID(b) == s.codec.EncryptionKeyID() {
+		return false, nil
+	}
+
+	decoded, err := s.codec.Decode(b)
+	if err != nil {
+		return false, err
+	}
+
+	encoded, err := s.codec.EncodeBlob(decoded)
+	if err != nil {
+		return false, err
+	}
+
+	err = s.payloadStore.Put(ctx, payloadRef, encoded)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// isMessageKey checks that the key has the f:<flowID>:s:<sinkID>:m:<messageID> format
+func isMessageKey(key string) bool {
+	parts := strings.Split(key, ":")
+	return len(parts) == 6 && parts[0] == "f" && parts[2] == "s" && parts[4] == "m"
+}
+
+// isIngestKey checks that the key has the f:<flowID>:i:<ingestID> format
+func isIngestKey(key string) bool {
+	parts := strings.Split(key, ":")
+	return len(parts) == 4 && parts[0] == "f" && parts[2] == "i"
+}
+
+// isIngestLogKey checks that the key has the src:<sourceID>:log:r:<reqID> format
+func isIngestLogKey(key string) bool {
+	parts := strings.Split(key, ":")
+	return len(parts) == 5 && parts[0] == "src" && parts[2] == "log" && parts[3] == "r"
+}
+
+// isCaptureKey checks that the key has the f:<flowID>:captures format
+func isCaptureKey(key string) bool {
+	parts := strings.Split(key, ":")
+	return len(parts) == 3 && parts[0] == "f" && parts[2] == "captures"
+}
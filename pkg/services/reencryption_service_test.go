@@ -0,0 +1,194 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/didil/inhooks/pkg/lib"
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/didil/inhooks/pkg/testsupport/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReencryptionService_Reencrypt(t *testing.T) {
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	redisStore := mocks.NewMockRedisStore(ctrl)
+
+	key1 := "key-1:" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+	key2 := "key-2:" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, 32))
+
+	oldCodec, err := NewRecordCodec(&lib.StorageConfig{EncryptionKeys: []string{key1}, EncryptionKeyID: "key-1"})
+	assert.NoError(t, err)
+	codec, err := NewRecordCodec(&lib.StorageConfig{EncryptionKeys: []string{key1, key2}, EncryptionKeyID: "key-2"})
+	assert.NoError(t, err)
+
+	message1 := []byte(`{"id":"message-1"}`)
+	message1Old, err := oldCodec.Encode(message1)
+	assert.NoError(t, err)
+	message2Current, err := codec.Encode([]byte(`{"id":"message-2"}`))
+	assert.NoError(t, err)
+	ingest1Legacy := []byte(`{"payload":"eyJpZCI6ImFiYyJ9"}`)
+
+	messageKeys := []string{"f:flow-1:s:sink-1:m:message-1", "f:flow-1:s:sink-1:m:message-2"}
+
+	redisStore.EXPECT().ScanKeys(ctx, "f:*:s:*:m:*", gomock.Any()).DoAndReturn(func(ctx context.Context, match string, fn func(keys []string) error) error {
+		return fn(append(messageKeys, "f:flow-1:s:sink-1:q:ready"))
+	})
+	redisStore.EXPECT().MGet(ctx, messageKeys).Return([][]byte{message1Old, message2Current}, nil)
+	redisStore.EXPECT().CompareAndSet(ctx, messageKeys[0], message1Old, gomock.Any()).DoAndReturn(func(ctx context.Context, key string, oldValue []byte, newValue []byte) (bool, error) {
+		assert.Equal(t, "key-2", codec.RecordKeyID(newValue))
+		decoded, err := codec.Decode(newValue)
+		assert.NoError(t, err)
+		assert.Equal(t, message1, decoded)
+		return true, nil
+	})
+
+	redisStore.EXPECT().ScanKeys(ctx, "f:*:i:*", gomock.Any()).DoAndReturn(func(ctx context.Context, match string, fn func(keys []string) error) error {
+		// sink ids can match the ingest keys pattern
+		return fn([]string{"f:flow-1:i:ingest-1", "f:flow-1:s:i:m:message-3"})
+	})
+	redisStore.EXPECT().GetIngest(ctx, "f:flow-1:i:ingest-1").Return(ingest1Legacy, nil)
+	redisStore.EXPECT().CompareAndSetIngest(ctx, "f:flow-1:i:ingest-1", ingest1Legacy, gomock.Any()).Return(true, nil)
+
+	logEntry1 := []byte(`{"ingestedReqID":"req-1"}`)
+	logEntry1Old, err := oldCodec.Encode(logEntry1)
+	assert.NoError(t, err)
+	logKeys := []string{"src:source-1:log:r:req-1", "src:source-1:log:r:req-2"}
+
+	redisStore.EXPECT().ScanKeys(ctx, "src:*:log:r:*", gomock.Any()).DoAndReturn(func(ctx context.Context, match string, fn func(keys []string) error) error {
+		return fn(logKeys)
+	})
+	// entries expired since the scan are skipped
+	redisStore.EXPECT().MGet(ctx, logKeys).Return([][]byte{logEntry1Old, nil}, nil)
+	redisStore.EXPECT().CompareAndSet(ctx, logKeys[0], logEntry1Old, gomock.Any()).DoAndReturn(func(ctx context.Context, key string, oldValue []byte, newValue []byte) (bool, error) {
+		assert.Equal(t, "key-2", codec.RecordKeyID(newValue))
+		decoded, err := codec.Decode(newValue)
+		assert.NoError(t, err)
+		assert.Equal(t, logEntry1, decoded)
+		return true, nil
+	})
+
+	capture1 := []byte(`{"reqID":"req-1"}`)
+	capture1Old, err := oldCodec.Encode(capture1)
+	assert.NoError(t, err)
+	capture2Current, err := codec.Encode([]byte(`{"reqID":"req-2"}`))
+	assert.NoError(t, err)
+
+	redisStore.EXPECT().ScanKeys(ctx, "f:*:captures", gomock.Any()).DoAndReturn(func(ctx context.Context, match string, fn func(keys []string) error) error {
+		// message ids can match the captures keys pattern
+		return fn([]string{"f:flow-1:captures", "f:flow-1:s:sink-1:m:captures"})
+	})
+	redisStore.EXPECT().LRangeAll(ctx, "f:flow-1:captures").Return([]string{string(capture2Current), string(capture1Old)}, nil)
+	redisStore.EXPECT().CompareAndSetListItem(ctx, "f:flow-1:captures", capture1Old, gomock.Any()).DoAndReturn(func(ctx context.Context, key string, oldValue []byte, newValue []byte) (bool, error) {
+		assert.Equal(t, "key-2", codec.RecordKeyID(newValue))
+		decoded, err := codec.Decode(newValue)
+		assert.NoError(t, err)
+		assert.Equal(t, capture1, decoded)
+		return true, nil
+	})
+
+	s := NewReencryptionService(redisStore, codec, nil)
+	count, err := s.Reencrypt(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, count)
+}
+
+func TestReencryptionService_Reencrypt_OffloadedPayloads(t *testing.T) {
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	redisStore := mocks.NewMockRedisStore(ctrl)
+
+	payloadStore, err := NewLocalPayloadStore(t.TempDir())
+	assert.NoError(t, err)
+
+	key1 := "key-1:" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+	key2 := "key-2:" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, 32))
+
+	oldCodec, err := NewRecordCodec(&lib.StorageConfig{EncryptionKeys: []string{key1}, EncryptionKeyID: "key-1"})
+	assert.NoError(t, err)
+	codec, err := NewRecordCodec(&lib.StorageConfig{EncryptionKeys: []string{key1, key2}, EncryptionKeyID: "key-2"})
+	assert.NoError(t, err)
+	// codec once the old key is removed
+	rotatedCodec, err := NewRecordCodec(&lib.StorageConfig{EncryptionKeys: []string{key2}, EncryptionKeyID: "key-2"})
+	assert.NoError(t, err)
+
+	payload := []byte(`{"id":"abc"}`)
+	blob, err := oldCodec.EncodeBlob(payload)
+	assert.NoError(t, err)
+	err = payloadStore.Put(ctx, "flow-1/i/ingest-1", blob)
+	assert.NoError(t, err)
+
+	// both messages of the ingested request reference the same payload
+	messages := []*models.Message{
+		{ID: "message-1", FlowID: "flow-1", SinkID: "sink-1", PayloadRef: "flow-1/i/ingest-1"},
+		{ID: "message-2", FlowID: "flow-1", SinkID: "sink-2", PayloadRef: "flow-1/i/ingest-1"},
+	}
+	values := [][]byte{}
+	for _, m := range messages {
+		b, err := encodeMessage(codec, m)
+		assert.NoError(t, err)
+		values = append(values, b)
+	}
+	messageKeys := []string{"f:flow-1:s:sink-1:m:message-1", "f:flow-1:s:sink-2:m:message-2"}
+
+	redisStore.EXPECT().ScanKeys(ctx, "f:*:s:*:m:*", gomock.Any()).DoAndReturn(func(ctx context.Context, match string, fn func(keys []string) error) error {
+		return fn(messageKeys)
+	})
+	redisStore.EXPECT().MGet(ctx, messageKeys).Return(values, nil)
+	redisStore.EXPECT().ScanKeys(ctx, "f:*:i:*", gomock.Any()).Return(nil)
+
+	// ingest log entry with an offloaded body
+	logBody := []byte(`{"id":"def"}`)
+	logBodyRef := ingestLogBodyKey("source-1", "req-1")
+	logBlob, err := oldCodec.EncodeBlob(logBody)
+	assert.NoError(t, err)
+	err = payloadStore.Put(ctx, logBodyRef, logBlob)
+	assert.NoError(t, err)
+	logEntry, err := json.Marshal(&models.IngestLogEntry{IngestedReqID: "req-1", BodyRef: logBodyRef})
+	assert.NoError(t, err)
+	logEntry, err = codec.Encode(logEntry)
+	assert.NoError(t, err)
+
+	redisStore.EXPECT().ScanKeys(ctx, "src:*:log:r:*", gomock.Any()).DoAndReturn(func(ctx context.Context, match string, fn func(keys []string) error) error {
+		return fn([]string{"src:source-1:log:r:req-1"})
+	})
+	redisStore.EXPECT().MGet(ctx, []string{"src:source-1:log:r:req-1"}).Return([][]byte{logEntry}, nil)
+	redisStore.EXPECT().ScanKeys(ctx, "f:*:captures", gomock.Any()).Return(nil)
+
+	s := NewReencryptionService(redisStore, codec, payloadStore)
+	count, err := s.Reencrypt(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	m, err := decodeMessage(rotatedCodec, values[0])
+	assert.NoError(t, err)
+	err = loadPayload(ctx, payloadStore, rotatedCodec, m)
+	assert.NoError(t, err)
+	assert.Equal(t, payload, m.Payload)
+
+	b, err := payloadStore.Get(ctx, logBodyRef)
+	assert.NoError(t, err)
+	decoded, err := rotatedCodec.Decode(b)
+	assert.NoError(t, err)
+	assert.Equal(t, logBody, decoded)
+}
+
+func TestReencryptionService_NoEncryptionKey(t *testing.T) {
+	codec, err := NewRecordCodec(&lib.StorageConfig{})
+	assert.NoError(t, err)
+
+	s := NewReencryptionService(nil, codec, nil)
+	_, err = s.Reencrypt(context.Background())
+	assert.EqualError(t, err, "encryption key id not set")
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/didil/inhooks/pkg/lib"
+	"github.com/pkg/errors"
+)
+
+// NewS3PayloadStore returns a payload store backed by an S3 compatible object storage, e.g. AWS S3 or MinIO
+func NewS3PayloadStore(conf *lib.S3Config, httpClient *http.Client) (PayloadStore, error) {
+	if conf.Endpoint == "" {
+		return nil, fmt.Errorf("s3 endpoint is required")
+	}
+	if conf.Bucket == "" {
+		return nil, fmt.Errorf("s3 bucket is required")
+	}
+
+	endpoint, err := url.Parse(conf.Endpoint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid s3 endpoint")
+	}
+	if endpoint.Scheme != "http" && endpoint.Scheme != "https" {
+		return nil, fmt.Errorf("invalid s3 endpoint scheme: %s", endpoint.Scheme)
+	}
+
+	return &s3PayloadStore{
+		conf:       conf,
+		endpoint:   endpoint,
+		httpClient: httpClient,
+		now:        time.Now,
+	}, nil
+}
+
+type s3PayloadStore struct {
+	conf       *lib.S3Config
+	endpoint   *url.URL
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+func (s *s3PayloadStore) Put(ctx context.Context, key string, payload []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, key, payload)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return s.statusErr(http.MethodPut, resp)
+	}
+
+	return nil
+}
+
+func (s *s3PayloadStore) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrPayloadNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, s.statusErr(http.MethodGet, resp)
+	}
+
+	payload, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read s3 object")
+	}
+
+	return payload, nil
+}
+
+func (s *s3PayloadStore) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return s.statusErr(http.MethodDelete, resp)
+	}
+
+	return nil
+}
+
+func (s *s3PayloadStore) do(ctx context.Context, method string, key string, payload []byte) (*http.Response, error) {
+	objectURL := *s.endpoint
+	objectURL.Path = strings.TrimSuffix(objectURL.Path, "/") + "/" + s.conf.Bucket + "/" + s.conf.Prefix + key
+	objectURL.RawPath = s3URIEncode(objectURL.Path, false)
+
+	req, err := http.NewRequestWithContext(ctx, method, objectURL.String(), bytes.NewReader(payload))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to build s3 request")
+	}
+	if payload == nil {
+		req.Body = http.NoBody
+		req.ContentLength = 0
+	}
+
+	signS3Request(req, payload, s.conf, s.now())
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to send s3 request")
+	}
+
+	return resp, nil
+}
+
+func (s *s3PayloadStore) statusErr(method string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("s3 %s request failed with status %d: %s", method, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// signS3Request adds the AWS Signature Version 4 headers to an S3 request. All the headers set on the request are signed.
+func signS3Request(req *http.Request, payload []byte, conf *lib.S3Config, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	payloadHash := sha256.Sum256(payload)
+	payloadHashHex := hex.EncodeToString(payloadHash[:])
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHashHex)
+
+	headerNames := []string{"host"}
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lowerName := strings.ToLower(name)
+		headerNames = append(headerNames, lowerName)
+		headers[lowerName] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	sort.Strings(headerNames)
+
+	canonicalHeaders := &strings.Builder{}
+	for _, name := range headerNames {
+		fmt.Fprintf(canonicalHeaders, "%s:%s\n", name, headers[name])
+	}
+	signedHeaders := strings.Join(headerNames, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3URIEncode(req.URL.Path, false),
+		s3CanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHashHex,
+	}, "\n")
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", date, conf.Region)
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(canonicalRequestHash[:]),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+conf.SecretAccessKey), []byte(date))
+	signingKey = hmacSHA256(signingKey, []byte(conf.Region))
+	signingKey = hmacSHA256(signingKey, []byte("s3"))
+	signingKey = hmacSHA256(signingKey, []byte("aws4_request"))
+	signature := hex.EncodeToString(hmacSHA256(signingKey, []byte(stringToSign)))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", conf.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := []string{}
+	for _, k := range keys {
+		values := query[k]
+		sort.Strings(values)
+		for _, v := range values {
+			pairs = append(pairs, s3URIEncode(k, true)+"="+s3URIEncode(v, true))
+		}
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+// s3URIEncode encodes all the bytes except the unreserved characters, and slashes if encodeSlash is false
+func s3URIEncode(s string, encodeSlash bool) string {
+	b := &strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !encodeSlash) {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(b, "%%%02X", c)
+	}
+
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/didil/inhooks/pkg/lib"
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+var ErrInvalidStreamEntry = errors.New("invalid stream entry")
+
+type StreamConsumer interface {
+	// Init creates the consumer group of the flow source stream
+	Init(ctx context.Context, flow *models.Flow) error
+	// Consume reads a batch of entries of the flow source stream, enqueues a message for each sink and acks the entries.
+	// Reads the entries pending for the consumer if pending is true, then the entries left pending by other consumers for the claim min idle duration.
+	// Reads new entries otherwise. Returns the number of entries read.
+	Consume(ctx context.Context, flow *models.Flow, consumer string, pending bool) (int, error)
+}
+
+type streamConsumer struct {
+	logger          *zap.Logger
+	redisStore      RedisStore
+	messageBuilder  MessageBuilder
+	messageEnqueuer MessageEnqueuer
+	appConf         *lib.AppConfig
+}
+
+func NewStreamConsumer(logger *zap.Logger, redisStore RedisStore, messageBuilder MessageBuilder, messageEnqueuer MessageEnqueuer, appConf *lib.AppConfig) StreamConsumer {
+	return &streamConsumer{
+		logger:          logger,
+		redisStore:      redisStore,
+		messageBuilder:  messageBuilder,
+		messageEnqueuer: messageEnqueuer,
+		appConf:         appConf,
+	}
+}
+
+func (s *streamConsumer) Init(ctx context.Context, flow *models.Flow) error {
+	stream := flow.Source.Stream
+
+	return s.redisStore.XGroupCreate(ctx, stream.Key, stream.Group)
+}
+
+func (s *streamConsumer) Consume(ctx context.Context, flow *models.Flow, consumer string, pending bool) (int, error) {
+	stream := flow.Source.Stream
+
+	id := ">"
+	if pending {
+		id = "0"
+	}
+
+	entries, err := s.redisStore.XReadGroup(ctx, stream.Key, stream.Group, consumer, id, s.appConf.Supervisor.StreamBatchSize, s.appConf.Supervisor.StreamBlockTime)
+	if err != nil {
+		return 0, err
+	}
+
+	if pending && len(entries) == 0 {
+		// claim the entries of consumers that stopped, e.g. crashed instances or renamed hosts
+		entries, err = s.redisStore.XAutoClaim(ctx, stream.Key, stream.Group, consumer, s.appConf.Supervisor.StreamClaimMinIdle, s.appConf.Supervisor.StreamBatchSize)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	messages := []*models.Message{}
+	entryIDs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		entryIDs = append(entryIDs, entry.ID)
+
+		entryMessages, err := s.buildMessages(flow, entry)
+		if err != nil {
+			if isInvalidStreamEntryErr(err) {
+				// invalid entries would be read again and again, they are dropped
+				s.logger.Error("dropping invalid stream entry", zap.String("flowID", flow.ID), zap.String("entryID", entry.ID), zap.Error(err))
+				continue
+			}
+
+			return 0, errors.Wrapf(err, "failed to build messages for stream entry %s", entry.ID)
+		}
+
+		messages = append(messages, entryMessages...)
+	}
+
+	if len(messages) > 0 {
+		_, err = s.messageEnqueuer.Enqueue(ctx, messages)
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to enqueue messages")
+		}
+	}
+
+	// the entries are acked once their messages are enqueued, they are read again from the pending entries otherwise
+	err = s.redisStore.XAck(ctx, stream.Key, stream.Group, entryIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(entries), nil
+}
+
+// buildMessages builds the messages of a stream entry as if its payload field was posted to the ingest endpoint, with the other fields as headers
+func (s *streamConsumer) buildMessages(flow *models.Flow, entry *models.StreamEntry) ([]*models.Message, error) {
+	payloadField := flow.Source.Stream.PayloadField
+
+	payload, ok := entry.Values[payloadField]
+	if !ok {
+		return nil, errors.Wrapf(ErrInvalidStreamEntry, "missing field %s", payloadField)
+	}
+
+	headers := http.Header{}
+	for field, value := range entry.Values {
+		if field == payloadField {
+			continue
+		}
+		headers.Set(field, value)
+	}
+
+	return s.messageBuilder.FromPayload(flow, []byte(payload), headers, entry.ID)
+}
+
+// isInvalidStreamEntryErr returns true if the error is caused by the entry content, and not by a transient failure
+func isInvalidStreamEntryErr(err error) bool {
+	return errors.Is(err, ErrInvalidStreamEntry) ||
+		errors.Is(err, ErrInvalidSplitPayload) ||
+		errors.Is(err, ErrUnsupportedContentEncoding) ||
+		errors.Is(err, ErrDecompressedBodyTooLarge)
+}
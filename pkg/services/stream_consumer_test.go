@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/didil/inhooks/pkg/lib"
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/didil/inhooks/pkg/testsupport"
+	"github.com/didil/inhooks/pkg/testsupport/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestStreamConsumer(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	appConf, err := testsupport.InitAppConfig(ctx)
+	assert.NoError(t, err)
+	appConf.Supervisor.StreamBlockTime = 10 * time.Millisecond
+
+	client, err := lib.InitRedisClient(appConf)
+	assert.NoError(t, err)
+	redisStore, err := NewRedisStore(client, appConf.Redis.InhooksDBName)
+	assert.NoError(t, err)
+
+	prefix := fmt.Sprintf("inhooks:%s", appConf.Redis.InhooksDBName)
+	defer func() {
+		err := testsupport.DeleteAllRedisKeys(ctx, client, prefix)
+		assert.NoError(t, err)
+	}()
+
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	timeSvc := mocks.NewMockTimeService(ctrl)
+	messageEnqueuer := mocks.NewMockMessageEnqueuer(ctrl)
+	s := NewStreamConsumer(logger, redisStore, NewMessageBuilder(timeSvc), messageEnqueuer, appConf)
+
+	stream := fmt.Sprintf("%s:events", prefix)
+	flow := &models.Flow{
+		ID: "flow-1",
+		Source: &models.Source{
+			ID:     "source-1",
+			Type:   models.SourceTypeRedisStream,
+			Stream: &models.RedisStream{Key: stream, Group: "inhooks", PayloadField: "payload"},
+		},
+		Sinks: []*models.Sink{{ID: "sink-1"}, {ID: "sink-2"}},
+	}
+
+	err = s.Init(ctx, flow)
+	assert.NoError(t, err)
+
+	id1, err := client.XAdd(ctx, &redis.XAddArgs{Stream: stream, Values: map[string]interface{}{"payload": `{"id": 1}`, "x-event": "created"}}).Result()
+	assert.NoError(t, err)
+	// invalid entries are dropped
+	_, err = client.XAdd(ctx, &redis.XAddArgs{Stream: stream, Values: map[string]interface{}{"body": `{"id": 2}`}}).Result()
+	assert.NoError(t, err)
+
+	now := time.Date(2023, 05, 5, 8, 9, 12, 0, time.UTC)
+	timeSvc.EXPECT().Now().Return(now)
+
+	// the entries are not acked when the enqueue fails
+	messageEnqueuer.EXPECT().Enqueue(ctx, gomock.Any()).Return(nil, fmt.Errorf("enqueue failed"))
+
+	_, err = s.Consume(ctx, flow, "consumer-1", false)
+	assert.ErrorContains(t, err, "enqueue failed")
+
+	// the pending entries are read again
+	timeSvc.EXPECT().Now().Return(now)
+	var messages []*models.Message
+	messageEnqueuer.EXPECT().Enqueue(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, ms []*models.Message) ([]*models.QueuedInfo, error) {
+		messages = ms
+		return nil, nil
+	})
+
+	count, err := s.Consume(ctx, flow, "consumer-1", true)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	assert.Len(t, messages, 2)
+	for i, m := range messages {
+		assert.Equal(t, "flow-1", m.FlowID)
+		assert.Equal(t, flow.Sinks[i].ID, m.SinkID)
+		assert.Equal(t, id1, m.IngestedReqID)
+		assert.Equal(t, []byte(`{"id": 1}`), m.Payload)
+		assert.Equal(t, "created", m.HttpHeaders.Get("X-Event"))
+		assert.Equal(t, now, m.ReceivedAt)
+		assert.Equal(t, "", m.HttpPath)
+	}
+
+	// all the entries are acked
+	count, err = s.Consume(ctx, flow, "consumer-1", true)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	count, err = s.Consume(ctx, flow, "consumer-1", false)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	// the entries left pending by another consumer are claimed once idle
+	id3, err := client.XAdd(ctx, &redis.XAddArgs{Stream: stream, Values: map[string]interface{}{"payload": `{"id": 3}`}}).Result()
+	assert.NoError(t, err)
+
+	timeSvc.EXPECT().Now().Return(now)
+	messageEnqueuer.EXPECT().Enqueue(ctx, gomock.Any()).Return(nil, fmt.Errorf("enqueue failed"))
+
+	_, err = s.Consume(ctx, flow, "consumer-2", false)
+	assert.ErrorContains(t, err, "enqueue failed")
+
+	count, err = s.Consume(ctx, flow, "consumer-1", true)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	appConf.Supervisor.StreamClaimMinIdle = 0
+	timeSvc.EXPECT().Now().Return(now)
+	messageEnqueuer.EXPECT().Enqueue(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, ms []*models.Message) ([]*models.QueuedInfo, error) {
+		messages = ms
+		return nil, nil
+	})
+
+	count, err = s.Consume(ctx, flow, "consumer-1", true)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Len(t, messages, 2)
+	assert.Equal(t, id3, messages[0].IngestedReqID)
+
+	count, err = s.Consume(ctx, flow, "consumer-2", true)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/pkg/errors"
+)
+
+type SyncDeliveryService interface {
+	// Deliver transforms and delivers a message enqueued in the processing queue inline, and returns the sink response.
+	// The message is then moved to the done queue, or retried or moved to the dead queue following the sink settings, like asynchronous deliveries.
+	// The sink response is returned along with the error if the message could not be moved after a successful delivery.
+	Deliver(ctx context.Context, sink *models.Sink, m *models.Message) (*models.SinkResponse, error)
+}
+
+func NewSyncDeliveryService(inhooksConfigSvc InhooksConfigService, messageTransformer MessageTransformer, messageProcessor MessageProcessor, processingResultsSvc ProcessingResultsService) SyncDeliveryService {
+	return &syncDeliveryService{
+		inhooksConfigSvc:     inhooksConfigSvc,
+		messageTransformer:   messageTransformer,
+		messageProcessor:     messageProcessor,
+		processingResultsSvc: processingResultsSvc,
+	}
+}
+
+type syncDeliveryService struct {
+	inhooksConfigSvc     InhooksConfigService
+	messageTransformer   MessageTransformer
+	messageProcessor     MessageProcessor
+	processingResultsSvc ProcessingResultsService
+}
+
+func (s *syncDeliveryService) Deliver(ctx context.Context, sink *models.Sink, m *models.Message) (*models.SinkResponse, error) {
+	resp, err := s.deliver(ctx, sink, m)
+	if err != nil {
+		// the results are stored even if the delivery timed out
+		_, handleErr := s.processingResultsSvc.HandleFailed(context.WithoutCancel(ctx), sink, m, err)
+		if handleErr != nil {
+			return nil, errors.Wrapf(handleErr, "failed to handle failed delivery: %s", err)
+		}
+
+		return nil, err
+	}
+
+	err = s.processingResultsSvc.HandleOK(context.WithoutCancel(ctx), m)
+	if err != nil {
+		return resp, errors.Wrapf(err, "failed to handle delivered message")
+	}
+
+	return resp, nil
+}
+
+// deliver transforms and processes a copy of the message, so that the stored message keeps the original request data
+func (s *syncDeliveryService) deliver(ctx context.Context, sink *models.Sink, m *models.Message) (*models.SinkResponse, error) {
+	delivered := *m
+	delivered.HttpHeaders = m.HttpHeaders.Clone()
+
+	if sink.Transform != nil {
+		transformDefinition := s.inhooksConfigSvc.GetTransformDefinition(sink.Transform.ID)
+		if transformDefinition == nil {
+			return nil, fmt.Errorf("transform definition not found: %s", sink.Transform.ID)
+		}
+
+		err := s.messageTransformer.Transform(ctx, transformDefinition, &delivered)
+		if err != nil {
+			return nil, fmt.Errorf("failed to transform payload: %w", err)
+		}
+	}
+
+	return s.messageProcessor.ProcessWithResponse(ctx, sink, &delivered)
+}
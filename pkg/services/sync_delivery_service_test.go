@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/didil/inhooks/pkg/lib"
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/didil/inhooks/pkg/testsupport/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncDeliveryService_Deliver(t *testing.T) {
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inhooksConfigSvc := mocks.NewMockInhooksConfigService(ctrl)
+	messageTransformer := mocks.NewMockMessageTransformer(ctrl)
+	messageProcessor := mocks.NewMockMessageProcessor(ctrl)
+	processingResultsSvc := mocks.NewMockProcessingResultsService(ctrl)
+
+	s := NewSyncDeliveryService(inhooksConfigSvc, messageTransformer, messageProcessor, processingResultsSvc)
+
+	transformDefinition := &models.TransformDefinition{ID: "transform-1"}
+	sink := &models.Sink{ID: "sink-1", Type: "http", Transform: &models.Transform{ID: "transform-1"}}
+
+	m := &models.Message{
+		ID:          "message-1",
+		SinkID:      "sink-1",
+		HttpHeaders: http.Header{"Content-Type": []string{"application/json"}},
+		Payload:     []byte(`{"id":"abc"}`),
+	}
+
+	sinkResp := &models.SinkResponse{StatusCode: http.StatusOK, Body: []byte(`{"text":"ok"}`)}
+
+	inhooksConfigSvc.EXPECT().GetTransformDefinition("transform-1").Return(transformDefinition)
+	messageTransformer.EXPECT().Transform(ctx, transformDefinition, gomock.Any()).DoAndReturn(func(ctx context.Context, transformDefinition *models.TransformDefinition, m *models.Message) error {
+		m.Payload = []byte(`{"transformed":true}`)
+		m.HttpHeaders.Set("X-Transformed", "true")
+		return nil
+	})
+	messageProcessor.EXPECT().ProcessWithResponse(ctx, sink, gomock.Any()).DoAndReturn(func(ctx context.Context, sink *models.Sink, delivered *models.Message) (*models.SinkResponse, error) {
+		assert.Equal(t, []byte(`{"transformed":true}`), delivered.Payload)
+		return sinkResp, nil
+	})
+	processingResultsSvc.EXPECT().HandleOK(gomock.Any(), m).DoAndReturn(func(ctx context.Context, m *models.Message) error {
+		// the stored message keeps the original request data
+		assert.Equal(t, []byte(`{"id":"abc"}`), m.Payload)
+		assert.Equal(t, http.Header{"Content-Type": []string{"application/json"}}, m.HttpHeaders)
+		return nil
+	})
+
+	resp, err := s.Deliver(ctx, sink, m)
+	assert.NoError(t, err)
+	assert.Equal(t, sinkResp, resp)
+}
+
+func TestSyncDeliveryService_DeliverFailed(t *testing.T) {
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	messageProcessor := mocks.NewMockMessageProcessor(ctrl)
+	redisStore := mocks.NewMockRedisStore(ctrl)
+	timeSvc := mocks.NewMockTimeService(ctrl)
+
+	now := time.Date(2023, 05, 5, 8, 9, 12, 0, time.UTC)
+	timeSvc.EXPECT().Now().AnyTimes().Return(now)
+
+	codec, err := NewRecordCodec(&lib.StorageConfig{})
+	assert.NoError(t, err)
+
+	processingResultsSvc := NewProcessingResultsService(timeSvc, redisStore, NewRetryCalculator(), codec)
+	s := NewSyncDeliveryService(nil, nil, messageProcessor, processingResultsSvc)
+
+	maxAttempts := 1
+	sink := &models.Sink{ID: "sink-1", Type: "http", MaxAttempts: &maxAttempts}
+	m := &models.Message{ID: "message-1", FlowID: "flow-1", SinkID: "sink-1", HttpHeaders: http.Header{}, DeliverAfter: now}
+
+	messageProcessor.EXPECT().ProcessWithResponse(ctx, sink, gomock.Any()).Return(nil, fmt.Errorf("failed to send http request: connection refused"))
+	// the sink max attempts is reached, the message is not retried
+	redisStore.EXPECT().SetAndMove(gomock.Any(), "f:flow-1:s:sink-1:m:message-1", gomock.Any(), "f:flow-1:s:sink-1:q:processing", "f:flow-1:s:sink-1:q:dead", "message-1").Return(nil)
+
+	resp, err := s.Deliver(ctx, sink, m)
+	assert.EqualError(t, err, "failed to send http request: connection refused")
+	assert.Nil(t, resp)
+	assert.Equal(t, []*models.DeliveryAttempt{{At: now, Status: models.DeliveryAttemptStatusFailed, Error: "failed to send http request: connection refused"}}, m.DeliveryAttempts)
+}
+
+func TestSyncDeliveryService_DeliverHandleOKFailed(t *testing.T) {
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	messageProcessor := mocks.NewMockMessageProcessor(ctrl)
+	processingResultsSvc := mocks.NewMockProcessingResultsService(ctrl)
+
+	s := NewSyncDeliveryService(nil, nil, messageProcessor, processingResultsSvc)
+
+	sink := &models.Sink{ID: "sink-1", Type: "http"}
+	m := &models.Message{ID: "message-1", SinkID: "sink-1", HttpHeaders: http.Header{}}
+
+	sinkResp := &models.SinkResponse{StatusCode: http.StatusOK}
+
+	messageProcessor.EXPECT().ProcessWithResponse(ctx, sink, gomock.Any()).Return(sinkResp, nil)
+	processingResultsSvc.EXPECT().HandleOK(gomock.Any(), m).Return(fmt.Errorf("redis down"))
+
+	// the sink response is still returned
+	resp, err := s.Deliver(ctx, sink, m)
+	assert.EqualError(t, err, "failed to handle delivered message: redis down")
+	assert.Equal(t, sinkResp, resp)
+}
@@ -0,0 +1,67 @@
+package supervisor
+
+import (
+	"time"
+
+	"github.com/didil/inhooks/pkg/models"
+	"go.uber.org/zap"
+)
+
+// emit the ticks of a cron source, each tick is emitted by a single inhooks instance
+func (s *Supervisor) HandleCronSource(f *models.Flow) {
+	logger := s.logger.With(zap.String("flowID", f.ID), zap.String("sourceID", f.Source.ID))
+	cron := f.Source.Cron
+
+	tick := cron.Next(s.timeSvc.Now())
+	for {
+		if tick.IsZero() {
+			logger.Error("cron schedule has no next tick")
+			return
+		}
+
+		// wait for the tick
+		timer := time.NewTimer(tick.Sub(s.timeSvc.Now()))
+
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		next := cron.Next(tick)
+		for {
+			emitted, err := s.cronEmitter.Emit(s.ctx, f, tick)
+			if err == nil {
+				if emitted {
+					logger.Info("cron tick emitted", zap.Time("tick", tick))
+				}
+				break
+			}
+
+			logger.Error("failed to emit cron tick", zap.Time("tick", tick), zap.Error(err))
+
+			// the tick is retried until the next one is due
+			if !s.timeSvc.Now().Before(next) {
+				logger.Error("skipping cron tick", zap.Time("tick", tick))
+				break
+			}
+
+			// wait before retrying
+			timer := time.NewTimer(s.appConf.Supervisor.ErrSleepTime)
+
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-timer.C:
+			}
+		}
+
+		// ticks missed while retrying are skipped
+		now := s.timeSvc.Now()
+		if next.Before(now) {
+			next = cron.Next(now)
+		}
+		tick = next
+	}
+}
@@ -0,0 +1,71 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/didil/inhooks/pkg/testsupport"
+	"github.com/didil/inhooks/pkg/testsupport/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestSupervisor_HandleCronSource(t *testing.T) {
+	appConf, err := testsupport.InitAppConfig(context.Background())
+	assert.NoError(t, err)
+
+	appConf.Supervisor.ErrSleepTime = 0
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cron := &models.Cron{Schedule: "* * * * *"}
+	assert.NoError(t, cron.Compile())
+
+	flow1 := &models.Flow{
+		ID:     "flow-1",
+		Source: &models.Source{ID: "source-1", Type: models.SourceTypeCron, Cron: cron},
+	}
+
+	cronEmitter := mocks.NewMockCronEmitter(ctrl)
+	timeSvc := mocks.NewMockTimeService(ctrl)
+
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	s := NewSupervisor(
+		WithCronEmitter(cronEmitter),
+		WithTimeService(timeSvc),
+		WithAppConfig(appConf),
+		WithLogger(logger),
+	)
+
+	tick1 := time.Date(2023, 05, 5, 8, 10, 0, 0, time.UTC)
+	tick2 := tick1.Add(time.Minute)
+
+	gomock.InOrder(
+		// first tick computed and awaited
+		timeSvc.EXPECT().Now().Return(tick1.Add(-time.Millisecond)),
+		timeSvc.EXPECT().Now().Return(tick1.Add(-time.Millisecond)),
+		// the tick is retried on failure
+		cronEmitter.EXPECT().Emit(gomock.Any(), flow1, tick1).Return(false, fmt.Errorf("redis down")),
+		timeSvc.EXPECT().Now().Return(tick1.Add(time.Second)),
+		cronEmitter.EXPECT().Emit(gomock.Any(), flow1, tick1).Return(true, nil),
+		// the next tick is awaited, emitted by another instance
+		timeSvc.EXPECT().Now().Return(tick1.Add(2*time.Second)),
+		timeSvc.EXPECT().Now().Return(tick2.Add(-time.Millisecond)),
+		cronEmitter.EXPECT().Emit(gomock.Any(), flow1, tick2).
+			DoAndReturn(func(ctx context.Context, f *models.Flow, tick time.Time) (bool, error) {
+				s.Shutdown()
+				return false, nil
+			}),
+		timeSvc.EXPECT().Now().Return(tick2.Add(time.Second)),
+		timeSvc.EXPECT().Now().Return(tick2.Add(time.Second)),
+	)
+
+	s.HandleCronSource(flow1)
+}
@@ -0,0 +1,26 @@
+package supervisor
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// reload the sources allowed CIDRs files periodically
+func (s *Supervisor) HandleIPAllowlistReload() {
+	for {
+		// wait before next reload, the allowlists are loaded on startup
+		timer := time.NewTimer(s.appConf.Supervisor.IPAllowlistReloadInterval)
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		err := s.ipAllowlistSvc.Reload()
+		if err != nil {
+			s.logger.Error("failed to reload ip allowlists", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package supervisor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/didil/inhooks/pkg/testsupport"
+	"github.com/didil/inhooks/pkg/testsupport/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestSupervisor_HandleIPAllowlistReload(t *testing.T) {
+	appConf, err := testsupport.InitAppConfig(context.Background())
+	assert.NoError(t, err)
+
+	appConf.Supervisor.IPAllowlistReloadInterval = 10 * time.Millisecond
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ipAllowlistSvc := mocks.NewMockIPAllowlistService(ctrl)
+
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	s := NewSupervisor(
+		WithIPAllowlistService(ipAllowlistSvc),
+		WithAppConfig(appConf),
+		WithLogger(logger),
+	)
+
+	ipAllowlistSvc.EXPECT().
+		Reload().
+		DoAndReturn(func() error {
+			s.Shutdown()
+
+			return nil
+		})
+
+	s.HandleIPAllowlistReload()
+}
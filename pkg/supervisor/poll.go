@@ -0,0 +1,44 @@
+package supervisor
+
+import (
+	"time"
+
+	"github.com/didil/inhooks/pkg/models"
+	"go.uber.org/zap"
+)
+
+// poll the url of a poll source on its interval and enqueue the new items for the flow sinks
+func (s *Supervisor) HandlePollSource(f *models.Flow) {
+	logger := s.logger.With(zap.String("flowID", f.ID), zap.String("sourceID", f.Source.ID))
+	poll := f.Source.Poll
+
+	for {
+		wait := poll.Interval
+
+		count, err := s.poller.Poll(s.ctx, f)
+		if err != nil {
+			if s.ctx.Err() != nil {
+				return
+			}
+
+			logger.Error("failed to poll source", zap.Error(err))
+			wait = s.appConf.Supervisor.ErrSleepTime
+		} else if count > 0 {
+			logger.Info("polled new items", zap.Int("count", count))
+
+			// the next page is polled right away
+			if poll.Cursor != nil {
+				wait = 0
+			}
+		}
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/didil/inhooks/pkg/testsupport"
+	"github.com/didil/inhooks/pkg/testsupport/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestSupervisor_HandlePollSource(t *testing.T) {
+	appConf, err := testsupport.InitAppConfig(context.Background())
+	assert.NoError(t, err)
+
+	appConf.Supervisor.ErrSleepTime = 0
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	flow1 := &models.Flow{
+		ID: "flow-1",
+		Source: &models.Source{
+			ID:   "source-1",
+			Type: models.SourceTypePoll,
+			Poll: &models.Poll{Interval: time.Hour, Cursor: &models.PollCursor{QueryParam: "after"}},
+		},
+	}
+
+	poller := mocks.NewMockPoller(ctrl)
+
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	s := NewSupervisor(
+		WithPoller(poller),
+		WithAppConfig(appConf),
+		WithLogger(logger),
+	)
+
+	gomock.InOrder(
+		// retried after the error sleep time
+		poller.EXPECT().Poll(gomock.Any(), flow1).Return(0, fmt.Errorf("vendor api down")),
+		// the next page is polled right away
+		poller.EXPECT().Poll(gomock.Any(), flow1).Return(2, nil),
+		poller.EXPECT().Poll(gomock.Any(), flow1).
+			DoAndReturn(func(ctx context.Context, f *models.Flow) (int, error) {
+				s.Shutdown()
+				return 0, nil
+			}),
+	)
+
+	s.HandlePollSource(flow1)
+}
@@ -78,6 +78,9 @@ func (s *Supervisor) startReadyProcessor(ctx context.Context, f *models.Flow, si
 				zap.String("messageID", m.ID),
 				zap.String("ingestedReqID", m.IngestedReqID),
 			)
+			if m.SplitIndex != nil {
+				logger = logger.With(zap.Int("splitIndex", *m.SplitIndex))
+			}
 
 			if sink.Transform != nil {
 				transformDefinition := s.inhooksConfigSvc.GetTransformDefinition(sink.Transform.ID)
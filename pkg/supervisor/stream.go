@@ -0,0 +1,75 @@
+package supervisor
+
+import (
+	"time"
+
+	"github.com/didil/inhooks/pkg/models"
+	"go.uber.org/zap"
+)
+
+// read the entries of a redis-stream source and enqueue them for the flow sinks
+func (s *Supervisor) HandleStreamSource(f *models.Flow) {
+	logger := s.logger.With(zap.String("flowID", f.ID), zap.String("sourceID", f.Source.ID))
+	consumer := s.appConf.Supervisor.StreamConsumerName
+
+	for {
+		err := s.streamConsumer.Init(s.ctx, f)
+		if err == nil {
+			break
+		}
+
+		logger.Error("failed to init stream consumer", zap.Error(err))
+
+		// wait before retrying
+		timer := time.NewTimer(s.appConf.Supervisor.ErrSleepTime)
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-timer.C:
+		}
+	}
+
+	// the entries left pending by a previous run or by other consumers are read first
+	pending := true
+	lastPendingRead := s.timeSvc.Now()
+	for {
+		if !pending && s.timeSvc.Now().Sub(lastPendingRead) >= s.appConf.Supervisor.StreamClaimMinIdle {
+			// claim the entries of the consumers that stopped since the last pending read
+			pending = true
+		}
+
+		count, err := s.streamConsumer.Consume(s.ctx, f, consumer, pending)
+		if err != nil {
+			if s.ctx.Err() != nil {
+				return
+			}
+
+			logger.Error("failed to consume stream entries", zap.Error(err))
+
+			// the entries read before the failure are pending
+			pending = true
+
+			// wait before retrying
+			timer := time.NewTimer(s.appConf.Supervisor.ErrSleepTime)
+
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-timer.C:
+				continue
+			}
+		}
+
+		if pending && count == 0 {
+			pending = false
+			lastPendingRead = s.timeSvc.Now()
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return
+		default:
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/didil/inhooks/pkg/models"
+	"github.com/didil/inhooks/pkg/testsupport"
+	"github.com/didil/inhooks/pkg/testsupport/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestSupervisor_HandleStreamSource(t *testing.T) {
+	appConf, err := testsupport.InitAppConfig(context.Background())
+	assert.NoError(t, err)
+
+	appConf.Supervisor.ErrSleepTime = 0
+	appConf.Supervisor.StreamConsumerName = "consumer-1"
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	flow1 := &models.Flow{
+		ID: "flow-1",
+		Source: &models.Source{
+			ID:     "source-1",
+			Type:   models.SourceTypeRedisStream,
+			Stream: &models.RedisStream{Key: "events", Group: "inhooks", PayloadField: "payload"},
+		},
+	}
+
+	streamConsumer := mocks.NewMockStreamConsumer(ctrl)
+	timeSvc := mocks.NewMockTimeService(ctrl)
+	now := time.Date(2023, 05, 5, 8, 9, 12, 0, time.UTC)
+
+	logger, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+
+	s := NewSupervisor(
+		WithStreamConsumer(streamConsumer),
+		WithTimeService(timeSvc),
+		WithAppConfig(appConf),
+		WithLogger(logger),
+	)
+
+	gomock.InOrder(
+		streamConsumer.EXPECT().Init(gomock.Any(), flow1).Return(fmt.Errorf("redis down")),
+		streamConsumer.EXPECT().Init(gomock.Any(), flow1).Return(nil),
+		timeSvc.EXPECT().Now().Return(now),
+		// the pending entries are read until there are none left
+		streamConsumer.EXPECT().Consume(gomock.Any(), flow1, "consumer-1", true).Return(2, nil),
+		streamConsumer.EXPECT().Consume(gomock.Any(), flow1, "consumer-1", true).Return(0, nil),
+		timeSvc.EXPECT().Now().Return(now),
+		timeSvc.EXPECT().Now().Return(now.Add(time.Minute)),
+		// the pending entries are read again after a failure
+		streamConsumer.EXPECT().Consume(gomock.Any(), flow1, "consumer-1", false).Return(0, fmt.Errorf("redis down")),
+		streamConsumer.EXPECT().Consume(gomock.Any(), flow1, "consumer-1", true).Return(0, nil),
+		timeSvc.EXPECT().Now().Return(now.Add(time.Minute)),
+		timeSvc.EXPECT().Now().Return(now.Add(2*time.Minute)),
+		streamConsumer.EXPECT().Consume(gomock.Any(), flow1, "consumer-1", false).Return(1, nil),
+		// the entries of stopped consumers are claimed periodically
+		timeSvc.EXPECT().Now().Return(now.Add(time.Minute).Add(appConf.Supervisor.StreamClaimMinIdle)),
+		streamConsumer.EXPECT().Consume(gomock.Any(), flow1, "consumer-1", true).
+			DoAndReturn(func(ctx context.Context, f *models.Flow, consumer string, pending bool) (int, error) {
+				s.Shutdown()
+				return 1, nil
+			}),
+	)
+
+	s.HandleStreamSource(flow1)
+}
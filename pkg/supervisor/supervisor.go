@@ -5,6 +5,7 @@ import (
 	"sync"
 
 	"github.com/didil/inhooks/pkg/lib"
+	"github.com/didil/inhooks/pkg/models"
 	"github.com/didil/inhooks/pkg/services"
 	"go.uber.org/zap"
 )
@@ -22,6 +23,11 @@ type Supervisor struct {
 	processingRecoverySvc services.ProcessingRecoveryService
 	cleanupSvc            services.CleanupService
 	messageTransformer    services.MessageTransformer
+	ipAllowlistSvc        services.IPAllowlistService
+	streamConsumer        services.StreamConsumer
+	cronEmitter           services.CronEmitter
+	poller                services.Poller
+	timeSvc               services.TimeService
 }
 
 type SupervisorOpt func(s *Supervisor)
@@ -100,12 +106,78 @@ func WithMessageTransformer(messageTransformer services.MessageTransformer) Supe
 	}
 }
 
+func WithIPAllowlistService(ipAllowlistSvc services.IPAllowlistService) SupervisorOpt {
+	return func(s *Supervisor) {
+		s.ipAllowlistSvc = ipAllowlistSvc
+	}
+}
+
+func WithStreamConsumer(streamConsumer services.StreamConsumer) SupervisorOpt {
+	return func(s *Supervisor) {
+		s.streamConsumer = streamConsumer
+	}
+}
+
+func WithCronEmitter(cronEmitter services.CronEmitter) SupervisorOpt {
+	return func(s *Supervisor) {
+		s.cronEmitter = cronEmitter
+	}
+}
+
+func WithPoller(poller services.Poller) SupervisorOpt {
+	return func(s *Supervisor) {
+		s.poller = poller
+	}
+}
+
+func WithTimeService(timeSvc services.TimeService) SupervisorOpt {
+	return func(s *Supervisor) {
+		s.timeSvc = timeSvc
+	}
+}
+
 func (s *Supervisor) Start() {
 	wg := &sync.WaitGroup{}
+
+	if s.ipAllowlistSvc != nil {
+		wg.Add(1)
+		go func() {
+			s.HandleIPAllowlistReload()
+			s.logger.Info("ip allowlist reload handler shutdown")
+			wg.Done()
+		}()
+	}
 	flows := s.inhooksConfigSvc.GetFlows()
 	for id := range flows {
 		f := flows[id]
 
+		if f.Source.Type == models.SourceTypeRedisStream {
+			wg.Add(1)
+			go func() {
+				s.HandleStreamSource(f)
+				s.logger.Info("stream source handler shutdown", zap.String("flowID", f.ID))
+				wg.Done()
+			}()
+		}
+
+		if f.Source.Type == models.SourceTypeCron {
+			wg.Add(1)
+			go func() {
+				s.HandleCronSource(f)
+				s.logger.Info("cron source handler shutdown", zap.String("flowID", f.ID))
+				wg.Done()
+			}()
+		}
+
+		if f.Source.Type == models.SourceTypePoll {
+			wg.Add(1)
+			go func() {
+				s.HandlePollSource(f)
+				s.logger.Info("poll source handler shutdown", zap.String("flowID", f.ID))
+				wg.Done()
+			}()
+		}
+
 		for j := 0; j < len(f.Sinks); j++ {
 			sink := f.Sinks[j]
 			logger := s.logger.With(zap.String("flowID", f.ID), zap.String("sinkID", sink.ID))
@@ -15,6 +15,21 @@ services=(
     "cleanup_service"
     "message_verifier"
     "message_transformer"
+    "ip_allowlist_service"
+    "ingest_limiter"
+    "challenge_responder"
+    "message_deduplicator"
+    "record_codec"
+    "reencryption_service"
+    "payload_store"
+    "sync_delivery_service"
+    "payload_validator"
+    "capture_service"
+    "ingest_log_service"
+    "backfill_service"
+    "stream_consumer"
+    "cron_emitter"
+    "poller"
 )
 
 for service in ${services[@]}
@@ -0,0 +1,52 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/services/backfill_service.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	models "github.com/didil/inhooks/pkg/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockBackfillService is a mock of BackfillService interface.
+type MockBackfillService struct {
+	ctrl     *gomock.Controller
+	recorder *MockBackfillServiceMockRecorder
+}
+
+// MockBackfillServiceMockRecorder is the mock recorder for MockBackfillService.
+type MockBackfillServiceMockRecorder struct {
+	mock *MockBackfillService
+}
+
+// NewMockBackfillService creates a new mock instance.
+func NewMockBackfillService(ctrl *gomock.Controller) *MockBackfillService {
+	mock := &MockBackfillService{ctrl: ctrl}
+	mock.recorder = &MockBackfillServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBackfillService) EXPECT() *MockBackfillServiceMockRecorder {
+	return m.recorder
+}
+
+// Backfill mocks base method.
+func (m *MockBackfillService) Backfill(ctx context.Context, flow *models.Flow, sinkID string, from, to time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Backfill", ctx, flow, sinkID, from, to)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Backfill indicates an expected call of Backfill.
+func (mr *MockBackfillServiceMockRecorder) Backfill(ctx, flow, sinkID, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Backfill", reflect.TypeOf((*MockBackfillService)(nil).Backfill), ctx, flow, sinkID, from, to)
+}
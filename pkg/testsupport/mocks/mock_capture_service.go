@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/services/capture_service.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "github.com/didil/inhooks/pkg/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockCaptureService is a mock of CaptureService interface.
+type MockCaptureService struct {
+	ctrl     *gomock.Controller
+	recorder *MockCaptureServiceMockRecorder
+}
+
+// MockCaptureServiceMockRecorder is the mock recorder for MockCaptureService.
+type MockCaptureServiceMockRecorder struct {
+	mock *MockCaptureService
+}
+
+// NewMockCaptureService creates a new mock instance.
+func NewMockCaptureService(ctrl *gomock.Controller) *MockCaptureService {
+	mock := &MockCaptureService{ctrl: ctrl}
+	mock.recorder = &MockCaptureServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCaptureService) EXPECT() *MockCaptureServiceMockRecorder {
+	return m.recorder
+}
+
+// Capture mocks base method.
+func (m *MockCaptureService) Capture(ctx context.Context, flow *models.Flow, c *models.CapturedRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Capture", ctx, flow, c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Capture indicates an expected call of Capture.
+func (mr *MockCaptureServiceMockRecorder) Capture(ctx, flow, c interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Capture", reflect.TypeOf((*MockCaptureService)(nil).Capture), ctx, flow, c)
+}
+
+// List mocks base method.
+func (m *MockCaptureService) List(ctx context.Context, flow *models.Flow) ([]*models.CapturedRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, flow)
+	ret0, _ := ret[0].([]*models.CapturedRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockCaptureServiceMockRecorder) List(ctx, flow interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockCaptureService)(nil).List), ctx, flow)
+}
@@ -0,0 +1,51 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/services/challenge_responder.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	http "net/http"
+	reflect "reflect"
+
+	models "github.com/didil/inhooks/pkg/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockChallengeResponder is a mock of ChallengeResponder interface.
+type MockChallengeResponder struct {
+	ctrl     *gomock.Controller
+	recorder *MockChallengeResponderMockRecorder
+}
+
+// MockChallengeResponderMockRecorder is the mock recorder for MockChallengeResponder.
+type MockChallengeResponderMockRecorder struct {
+	mock *MockChallengeResponder
+}
+
+// NewMockChallengeResponder creates a new mock instance.
+func NewMockChallengeResponder(ctrl *gomock.Controller) *MockChallengeResponder {
+	mock := &MockChallengeResponder{ctrl: ctrl}
+	mock.recorder = &MockChallengeResponderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockChallengeResponder) EXPECT() *MockChallengeResponderMockRecorder {
+	return m.recorder
+}
+
+// Respond mocks base method.
+func (m *MockChallengeResponder) Respond(flow *models.Flow, r *http.Request) (*models.ChallengeResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Respond", flow, r)
+	ret0, _ := ret[0].(*models.ChallengeResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Respond indicates an expected call of Respond.
+func (mr *MockChallengeResponderMockRecorder) Respond(flow, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Respond", reflect.TypeOf((*MockChallengeResponder)(nil).Respond), flow, r)
+}
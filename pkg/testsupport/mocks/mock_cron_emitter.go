@@ -0,0 +1,52 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/services/cron_emitter.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	models "github.com/didil/inhooks/pkg/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockCronEmitter is a mock of CronEmitter interface.
+type MockCronEmitter struct {
+	ctrl     *gomock.Controller
+	recorder *MockCronEmitterMockRecorder
+}
+
+// MockCronEmitterMockRecorder is the mock recorder for MockCronEmitter.
+type MockCronEmitterMockRecorder struct {
+	mock *MockCronEmitter
+}
+
+// NewMockCronEmitter creates a new mock instance.
+func NewMockCronEmitter(ctrl *gomock.Controller) *MockCronEmitter {
+	mock := &MockCronEmitter{ctrl: ctrl}
+	mock.recorder = &MockCronEmitterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCronEmitter) EXPECT() *MockCronEmitterMockRecorder {
+	return m.recorder
+}
+
+// Emit mocks base method.
+func (m *MockCronEmitter) Emit(ctx context.Context, flow *models.Flow, tick time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Emit", ctx, flow, tick)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Emit indicates an expected call of Emit.
+func (mr *MockCronEmitterMockRecorder) Emit(ctx, flow, tick interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Emit", reflect.TypeOf((*MockCronEmitter)(nil).Emit), ctx, flow, tick)
+}
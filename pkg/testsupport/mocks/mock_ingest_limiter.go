@@ -0,0 +1,68 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/services/ingest_limiter.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	models "github.com/didil/inhooks/pkg/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIngestLimiter is a mock of IngestLimiter interface.
+type MockIngestLimiter struct {
+	ctrl     *gomock.Controller
+	recorder *MockIngestLimiterMockRecorder
+}
+
+// MockIngestLimiterMockRecorder is the mock recorder for MockIngestLimiter.
+type MockIngestLimiterMockRecorder struct {
+	mock *MockIngestLimiter
+}
+
+// NewMockIngestLimiter creates a new mock instance.
+func NewMockIngestLimiter(ctrl *gomock.Controller) *MockIngestLimiter {
+	mock := &MockIngestLimiter{ctrl: ctrl}
+	mock.recorder = &MockIngestLimiterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIngestLimiter) EXPECT() *MockIngestLimiterMockRecorder {
+	return m.recorder
+}
+
+// CheckQueueDepth mocks base method.
+func (m *MockIngestLimiter) CheckQueueDepth(ctx context.Context, flow *models.Flow) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckQueueDepth", ctx, flow)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckQueueDepth indicates an expected call of CheckQueueDepth.
+func (mr *MockIngestLimiterMockRecorder) CheckQueueDepth(ctx, flow interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckQueueDepth", reflect.TypeOf((*MockIngestLimiter)(nil).CheckQueueDepth), ctx, flow)
+}
+
+// CheckRateLimit mocks base method.
+func (m *MockIngestLimiter) CheckRateLimit(ctx context.Context, source *models.Source) (bool, time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckRateLimit", ctx, source)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(time.Duration)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CheckRateLimit indicates an expected call of CheckRateLimit.
+func (mr *MockIngestLimiterMockRecorder) CheckRateLimit(ctx, source interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckRateLimit", reflect.TypeOf((*MockIngestLimiter)(nil).CheckRateLimit), ctx, source)
+}
@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/services/ingest_log_service.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	models "github.com/didil/inhooks/pkg/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIngestLogService is a mock of IngestLogService interface.
+type MockIngestLogService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIngestLogServiceMockRecorder
+}
+
+// MockIngestLogServiceMockRecorder is the mock recorder for MockIngestLogService.
+type MockIngestLogServiceMockRecorder struct {
+	mock *MockIngestLogService
+}
+
+// NewMockIngestLogService creates a new mock instance.
+func NewMockIngestLogService(ctrl *gomock.Controller) *MockIngestLogService {
+	mock := &MockIngestLogService{ctrl: ctrl}
+	mock.recorder = &MockIngestLogServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIngestLogService) EXPECT() *MockIngestLogServiceMockRecorder {
+	return m.recorder
+}
+
+// Append mocks base method.
+func (m *MockIngestLogService) Append(ctx context.Context, flow *models.Flow, entry *models.IngestLogEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Append", ctx, flow, entry)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Append indicates an expected call of Append.
+func (mr *MockIngestLogServiceMockRecorder) Append(ctx, flow, entry interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Append", reflect.TypeOf((*MockIngestLogService)(nil).Append), ctx, flow, entry)
+}
+
+// Scan mocks base method.
+func (m *MockIngestLogService) Scan(ctx context.Context, flow *models.Flow, from, to time.Time, fn func([]*models.IngestLogEntry) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Scan", ctx, flow, from, to, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Scan indicates an expected call of Scan.
+func (mr *MockIngestLogServiceMockRecorder) Scan(ctx, flow, from, to, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockIngestLogService)(nil).Scan), ctx, flow, from, to, fn)
+}
@@ -90,6 +90,20 @@ func (mr *MockInhooksConfigServiceMockRecorder) GetTransformDefinition(transform
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransformDefinition", reflect.TypeOf((*MockInhooksConfigService)(nil).GetTransformDefinition), transformID)
 }
 
+// GetUnknownSourceResponse mocks base method.
+func (m *MockInhooksConfigService) GetUnknownSourceResponse() *models.IngestResponse {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUnknownSourceResponse")
+	ret0, _ := ret[0].(*models.IngestResponse)
+	return ret0
+}
+
+// GetUnknownSourceResponse indicates an expected call of GetUnknownSourceResponse.
+func (mr *MockInhooksConfigServiceMockRecorder) GetUnknownSourceResponse() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUnknownSourceResponse", reflect.TypeOf((*MockInhooksConfigService)(nil).GetUnknownSourceResponse))
+}
+
 // Load mocks base method.
 func (m *MockInhooksConfigService) Load(path string) error {
 	m.ctrl.T.Helper()
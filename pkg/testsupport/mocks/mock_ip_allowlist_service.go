@@ -0,0 +1,80 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/services/ip_allowlist_service.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	http "net/http"
+	netip "net/netip"
+	reflect "reflect"
+
+	models "github.com/didil/inhooks/pkg/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIPAllowlistService is a mock of IPAllowlistService interface.
+type MockIPAllowlistService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIPAllowlistServiceMockRecorder
+}
+
+// MockIPAllowlistServiceMockRecorder is the mock recorder for MockIPAllowlistService.
+type MockIPAllowlistServiceMockRecorder struct {
+	mock *MockIPAllowlistService
+}
+
+// NewMockIPAllowlistService creates a new mock instance.
+func NewMockIPAllowlistService(ctrl *gomock.Controller) *MockIPAllowlistService {
+	mock := &MockIPAllowlistService{ctrl: ctrl}
+	mock.recorder = &MockIPAllowlistServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIPAllowlistService) EXPECT() *MockIPAllowlistServiceMockRecorder {
+	return m.recorder
+}
+
+// ClientIP mocks base method.
+func (m *MockIPAllowlistService) ClientIP(r *http.Request) (netip.Addr, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClientIP", r)
+	ret0, _ := ret[0].(netip.Addr)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClientIP indicates an expected call of ClientIP.
+func (mr *MockIPAllowlistServiceMockRecorder) ClientIP(r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientIP", reflect.TypeOf((*MockIPAllowlistService)(nil).ClientIP), r)
+}
+
+// IsAllowed mocks base method.
+func (m *MockIPAllowlistService) IsAllowed(source *models.Source, ip netip.Addr) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsAllowed", source, ip)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsAllowed indicates an expected call of IsAllowed.
+func (mr *MockIPAllowlistServiceMockRecorder) IsAllowed(source, ip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsAllowed", reflect.TypeOf((*MockIPAllowlistService)(nil).IsAllowed), source, ip)
+}
+
+// Reload mocks base method.
+func (m *MockIPAllowlistService) Reload() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reload")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reload indicates an expected call of Reload.
+func (mr *MockIPAllowlistServiceMockRecorder) Reload() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reload", reflect.TypeOf((*MockIPAllowlistService)(nil).Reload))
+}
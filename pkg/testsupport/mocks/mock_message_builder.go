@@ -49,3 +49,18 @@ func (mr *MockMessageBuilderMockRecorder) FromHttp(flow, r, reqID interface{}) *
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FromHttp", reflect.TypeOf((*MockMessageBuilder)(nil).FromHttp), flow, r, reqID)
 }
+
+// FromPayload mocks base method.
+func (m *MockMessageBuilder) FromPayload(flow *models.Flow, payload []byte, headers http.Header, reqID string) ([]*models.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FromPayload", flow, payload, headers, reqID)
+	ret0, _ := ret[0].([]*models.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FromPayload indicates an expected call of FromPayload.
+func (mr *MockMessageBuilderMockRecorder) FromPayload(flow, payload, headers, reqID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FromPayload", reflect.TypeOf((*MockMessageBuilder)(nil).FromPayload), flow, payload, headers, reqID)
+}
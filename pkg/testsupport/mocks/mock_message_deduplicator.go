@@ -0,0 +1,80 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/services/message_deduplicator.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "github.com/didil/inhooks/pkg/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockMessageDeduplicator is a mock of MessageDeduplicator interface.
+type MockMessageDeduplicator struct {
+	ctrl     *gomock.Controller
+	recorder *MockMessageDeduplicatorMockRecorder
+}
+
+// MockMessageDeduplicatorMockRecorder is the mock recorder for MockMessageDeduplicator.
+type MockMessageDeduplicatorMockRecorder struct {
+	mock *MockMessageDeduplicator
+}
+
+// NewMockMessageDeduplicator creates a new mock instance.
+func NewMockMessageDeduplicator(ctrl *gomock.Controller) *MockMessageDeduplicator {
+	mock := &MockMessageDeduplicator{ctrl: ctrl}
+	mock.recorder = &MockMessageDeduplicatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMessageDeduplicator) EXPECT() *MockMessageDeduplicatorMockRecorder {
+	return m.recorder
+}
+
+// Claim mocks base method.
+func (m *MockMessageDeduplicator) Claim(ctx context.Context, flow *models.Flow, key, reqID string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Claim", ctx, flow, key, reqID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Claim indicates an expected call of Claim.
+func (mr *MockMessageDeduplicatorMockRecorder) Claim(ctx, flow, key, reqID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Claim", reflect.TypeOf((*MockMessageDeduplicator)(nil).Claim), ctx, flow, key, reqID)
+}
+
+// IdempotencyKey mocks base method.
+func (m_2 *MockMessageDeduplicator) IdempotencyKey(flow *models.Flow, m *models.Message) (string, error) {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "IdempotencyKey", flow, m)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IdempotencyKey indicates an expected call of IdempotencyKey.
+func (mr *MockMessageDeduplicatorMockRecorder) IdempotencyKey(flow, m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IdempotencyKey", reflect.TypeOf((*MockMessageDeduplicator)(nil).IdempotencyKey), flow, m)
+}
+
+// Release mocks base method.
+func (m *MockMessageDeduplicator) Release(ctx context.Context, flow *models.Flow, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", ctx, flow, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockMessageDeduplicatorMockRecorder) Release(ctx, flow, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockMessageDeduplicator)(nil).Release), ctx, flow, key)
+}
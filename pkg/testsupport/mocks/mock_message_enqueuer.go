@@ -49,3 +49,18 @@ func (mr *MockMessageEnqueuerMockRecorder) Enqueue(ctx, messages interface{}) *g
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enqueue", reflect.TypeOf((*MockMessageEnqueuer)(nil).Enqueue), ctx, messages)
 }
+
+// EnqueueMarked mocks base method.
+func (m *MockMessageEnqueuer) EnqueueMarked(ctx context.Context, messages []*models.Message, marker *models.EnqueueMarker) ([]*models.QueuedInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnqueueMarked", ctx, messages, marker)
+	ret0, _ := ret[0].([]*models.QueuedInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnqueueMarked indicates an expected call of EnqueueMarked.
+func (mr *MockMessageEnqueuerMockRecorder) EnqueueMarked(ctx, messages, marker interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnqueueMarked", reflect.TypeOf((*MockMessageEnqueuer)(nil).EnqueueMarked), ctx, messages, marker)
+}
@@ -48,3 +48,18 @@ func (mr *MockMessageProcessorMockRecorder) Process(ctx, sink, m interface{}) *g
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Process", reflect.TypeOf((*MockMessageProcessor)(nil).Process), ctx, sink, m)
 }
+
+// ProcessWithResponse mocks base method.
+func (m_2 *MockMessageProcessor) ProcessWithResponse(ctx context.Context, sink *models.Sink, m *models.Message) (*models.SinkResponse, error) {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "ProcessWithResponse", ctx, sink, m)
+	ret0, _ := ret[0].(*models.SinkResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProcessWithResponse indicates an expected call of ProcessWithResponse.
+func (mr *MockMessageProcessorMockRecorder) ProcessWithResponse(ctx, sink, m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessWithResponse", reflect.TypeOf((*MockMessageProcessor)(nil).ProcessWithResponse), ctx, sink, m)
+}
@@ -34,6 +34,18 @@ func (m *MockMessageVerifier) EXPECT() *MockMessageVerifierMockRecorder {
 	return m.recorder
 }
 
+// StripCredentials mocks base method.
+func (m_2 *MockMessageVerifier) StripCredentials(flow *models.Flow, m *models.Message) {
+	m_2.ctrl.T.Helper()
+	m_2.ctrl.Call(m_2, "StripCredentials", flow, m)
+}
+
+// StripCredentials indicates an expected call of StripCredentials.
+func (mr *MockMessageVerifierMockRecorder) StripCredentials(flow, m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StripCredentials", reflect.TypeOf((*MockMessageVerifier)(nil).StripCredentials), flow, m)
+}
+
 // Verify mocks base method.
 func (m_2 *MockMessageVerifier) Verify(flow *models.Flow, m *models.Message) error {
 	m_2.ctrl.T.Helper()
@@ -0,0 +1,78 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/services/payload_store.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockPayloadStore is a mock of PayloadStore interface.
+type MockPayloadStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockPayloadStoreMockRecorder
+}
+
+// MockPayloadStoreMockRecorder is the mock recorder for MockPayloadStore.
+type MockPayloadStoreMockRecorder struct {
+	mock *MockPayloadStore
+}
+
+// NewMockPayloadStore creates a new mock instance.
+func NewMockPayloadStore(ctrl *gomock.Controller) *MockPayloadStore {
+	mock := &MockPayloadStore{ctrl: ctrl}
+	mock.recorder = &MockPayloadStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPayloadStore) EXPECT() *MockPayloadStoreMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockPayloadStore) Delete(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockPayloadStoreMockRecorder) Delete(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockPayloadStore)(nil).Delete), ctx, key)
+}
+
+// Get mocks base method.
+func (m *MockPayloadStore) Get(ctx context.Context, key string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, key)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockPayloadStoreMockRecorder) Get(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockPayloadStore)(nil).Get), ctx, key)
+}
+
+// Put mocks base method.
+func (m *MockPayloadStore) Put(ctx context.Context, key string, payload []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Put", ctx, key, payload)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Put indicates an expected call of Put.
+func (mr *MockPayloadStoreMockRecorder) Put(ctx, key, payload interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockPayloadStore)(nil).Put), ctx, key, payload)
+}
@@ -0,0 +1,49 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/services/payload_validator.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	models "github.com/didil/inhooks/pkg/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockPayloadValidator is a mock of PayloadValidator interface.
+type MockPayloadValidator struct {
+	ctrl     *gomock.Controller
+	recorder *MockPayloadValidatorMockRecorder
+}
+
+// MockPayloadValidatorMockRecorder is the mock recorder for MockPayloadValidator.
+type MockPayloadValidatorMockRecorder struct {
+	mock *MockPayloadValidator
+}
+
+// NewMockPayloadValidator creates a new mock instance.
+func NewMockPayloadValidator(ctrl *gomock.Controller) *MockPayloadValidator {
+	mock := &MockPayloadValidator{ctrl: ctrl}
+	mock.recorder = &MockPayloadValidatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPayloadValidator) EXPECT() *MockPayloadValidatorMockRecorder {
+	return m.recorder
+}
+
+// Validate mocks base method.
+func (m_2 *MockPayloadValidator) Validate(source *models.Source, m *models.Message) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "Validate", source, m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Validate indicates an expected call of Validate.
+func (mr *MockPayloadValidatorMockRecorder) Validate(source, m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Validate", reflect.TypeOf((*MockPayloadValidator)(nil).Validate), source, m)
+}
@@ -0,0 +1,51 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/services/poller.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "github.com/didil/inhooks/pkg/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockPoller is a mock of Poller interface.
+type MockPoller struct {
+	ctrl     *gomock.Controller
+	recorder *MockPollerMockRecorder
+}
+
+// MockPollerMockRecorder is the mock recorder for MockPoller.
+type MockPollerMockRecorder struct {
+	mock *MockPoller
+}
+
+// NewMockPoller creates a new mock instance.
+func NewMockPoller(ctrl *gomock.Controller) *MockPoller {
+	mock := &MockPoller{ctrl: ctrl}
+	mock.recorder = &MockPollerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPoller) EXPECT() *MockPollerMockRecorder {
+	return m.recorder
+}
+
+// Poll mocks base method.
+func (m *MockPoller) Poll(ctx context.Context, flow *models.Flow) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Poll", ctx, flow)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Poll indicates an expected call of Poll.
+func (mr *MockPollerMockRecorder) Poll(ctx, flow interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Poll", reflect.TypeOf((*MockPoller)(nil).Poll), ctx, flow)
+}
@@ -0,0 +1,107 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/services/record_codec.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockRecordCodec is a mock of RecordCodec interface.
+type MockRecordCodec struct {
+	ctrl     *gomock.Controller
+	recorder *MockRecordCodecMockRecorder
+}
+
+// MockRecordCodecMockRecorder is the mock recorder for MockRecordCodec.
+type MockRecordCodecMockRecorder struct {
+	mock *MockRecordCodec
+}
+
+// NewMockRecordCodec creates a new mock instance.
+func NewMockRecordCodec(ctrl *gomock.Controller) *MockRecordCodec {
+	mock := &MockRecordCodec{ctrl: ctrl}
+	mock.recorder = &MockRecordCodecMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRecordCodec) EXPECT() *MockRecordCodecMockRecorder {
+	return m.recorder
+}
+
+// Decode mocks base method.
+func (m *MockRecordCodec) Decode(b []byte) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Decode", b)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Decode indicates an expected call of Decode.
+func (mr *MockRecordCodecMockRecorder) Decode(b interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Decode", reflect.TypeOf((*MockRecordCodec)(nil).Decode), b)
+}
+
+// Encode mocks base method.
+func (m *MockRecordCodec) Encode(b []byte) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Encode", b)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Encode indicates an expected call of Encode.
+func (mr *MockRecordCodecMockRecorder) Encode(b interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Encode", reflect.TypeOf((*MockRecordCodec)(nil).Encode), b)
+}
+
+// EncodeBlob mocks base method.
+func (m *MockRecordCodec) EncodeBlob(b []byte) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EncodeBlob", b)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EncodeBlob indicates an expected call of EncodeBlob.
+func (mr *MockRecordCodecMockRecorder) EncodeBlob(b interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EncodeBlob", reflect.TypeOf((*MockRecordCodec)(nil).EncodeBlob), b)
+}
+
+// EncryptionKeyID mocks base method.
+func (m *MockRecordCodec) EncryptionKeyID() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EncryptionKeyID")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// EncryptionKeyID indicates an expected call of EncryptionKeyID.
+func (mr *MockRecordCodecMockRecorder) EncryptionKeyID() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EncryptionKeyID", reflect.TypeOf((*MockRecordCodec)(nil).EncryptionKeyID))
+}
+
+// RecordKeyID mocks base method.
+func (m *MockRecordCodec) RecordKeyID(b []byte) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordKeyID", b)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// RecordKeyID indicates an expected call of RecordKeyID.
+func (mr *MockRecordCodecMockRecorder) RecordKeyID(b interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordKeyID", reflect.TypeOf((*MockRecordCodec)(nil).RecordKeyID), b)
+}
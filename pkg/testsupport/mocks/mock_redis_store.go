@@ -9,6 +9,7 @@ import (
 	reflect "reflect"
 	time "time"
 
+	models "github.com/didil/inhooks/pkg/models"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -50,6 +51,65 @@ func (mr *MockRedisStoreMockRecorder) BLMove(ctx, timeout, sourceQueueKey, destQ
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BLMove", reflect.TypeOf((*MockRedisStore)(nil).BLMove), ctx, timeout, sourceQueueKey, destQueueKey)
 }
 
+// CompareAndSet mocks base method.
+func (m *MockRedisStore) CompareAndSet(ctx context.Context, key string, oldValue, newValue []byte) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompareAndSet", ctx, key, oldValue, newValue)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompareAndSet indicates an expected call of CompareAndSet.
+func (mr *MockRedisStoreMockRecorder) CompareAndSet(ctx, key, oldValue, newValue interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompareAndSet", reflect.TypeOf((*MockRedisStore)(nil).CompareAndSet), ctx, key, oldValue, newValue)
+}
+
+// CompareAndSetIngest mocks base method.
+func (m *MockRedisStore) CompareAndSetIngest(ctx context.Context, ingestKey string, oldValue, newValue []byte) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompareAndSetIngest", ctx, ingestKey, oldValue, newValue)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompareAndSetIngest indicates an expected call of CompareAndSetIngest.
+func (mr *MockRedisStoreMockRecorder) CompareAndSetIngest(ctx, ingestKey, oldValue, newValue interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompareAndSetIngest", reflect.TypeOf((*MockRedisStore)(nil).CompareAndSetIngest), ctx, ingestKey, oldValue, newValue)
+}
+
+// CompareAndSetListItem mocks base method.
+func (m *MockRedisStore) CompareAndSetListItem(ctx context.Context, listKey string, oldValue, newValue []byte) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompareAndSetListItem", ctx, listKey, oldValue, newValue)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompareAndSetListItem indicates an expected call of CompareAndSetListItem.
+func (mr *MockRedisStoreMockRecorder) CompareAndSetListItem(ctx, listKey, oldValue, newValue interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompareAndSetListItem", reflect.TypeOf((*MockRedisStore)(nil).CompareAndSetListItem), ctx, listKey, oldValue, newValue)
+}
+
+// Del mocks base method.
+func (m *MockRedisStore) Del(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Del", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Del indicates an expected call of Del.
+func (mr *MockRedisStoreMockRecorder) Del(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Del", reflect.TypeOf((*MockRedisStore)(nil).Del), ctx, key)
+}
+
 // Dequeue mocks base method.
 func (m *MockRedisStore) Dequeue(ctx context.Context, timeout time.Duration, key string) ([]byte, error) {
 	m.ctrl.T.Helper()
@@ -94,6 +154,50 @@ func (mr *MockRedisStoreMockRecorder) Get(ctx, messageKey interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockRedisStore)(nil).Get), ctx, messageKey)
 }
 
+// GetIngest mocks base method.
+func (m *MockRedisStore) GetIngest(ctx context.Context, ingestKey string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIngest", ctx, ingestKey)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIngest indicates an expected call of GetIngest.
+func (mr *MockRedisStoreMockRecorder) GetIngest(ctx, ingestKey interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIngest", reflect.TypeOf((*MockRedisStore)(nil).GetIngest), ctx, ingestKey)
+}
+
+// LLenZCard mocks base method.
+func (m *MockRedisStore) LLenZCard(ctx context.Context, listKey, zsetKey string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LLenZCard", ctx, listKey, zsetKey)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LLenZCard indicates an expected call of LLenZCard.
+func (mr *MockRedisStoreMockRecorder) LLenZCard(ctx, listKey, zsetKey interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LLenZCard", reflect.TypeOf((*MockRedisStore)(nil).LLenZCard), ctx, listKey, zsetKey)
+}
+
+// LPushTrimExpire mocks base method.
+func (m *MockRedisStore) LPushTrimExpire(ctx context.Context, key string, value []byte, maxLen int, ttl time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LPushTrimExpire", ctx, key, value, maxLen, ttl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LPushTrimExpire indicates an expected call of LPushTrimExpire.
+func (mr *MockRedisStoreMockRecorder) LPushTrimExpire(ctx, key, value, maxLen, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LPushTrimExpire", reflect.TypeOf((*MockRedisStore)(nil).LPushTrimExpire), ctx, key, value, maxLen, ttl)
+}
+
 // LRangeAll mocks base method.
 func (m *MockRedisStore) LRangeAll(ctx context.Context, queueKey string) ([]string, error) {
 	m.ctrl.T.Helper()
@@ -123,18 +227,75 @@ func (mr *MockRedisStoreMockRecorder) LRemRPush(ctx, sourceQueueKey, destQueueKe
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LRemRPush", reflect.TypeOf((*MockRedisStore)(nil).LRemRPush), ctx, sourceQueueKey, destQueueKey, messageIDs)
 }
 
-// SetAndEnqueue mocks base method.
-func (m *MockRedisStore) SetAndEnqueue(ctx context.Context, messageKey string, value []byte, queueKey, messageID string) error {
+// MGet mocks base method.
+func (m *MockRedisStore) MGet(ctx context.Context, keys []string) ([][]byte, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetAndEnqueue", ctx, messageKey, value, queueKey, messageID)
+	ret := m.ctrl.Call(m, "MGet", ctx, keys)
+	ret0, _ := ret[0].([][]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MGet indicates an expected call of MGet.
+func (mr *MockRedisStoreMockRecorder) MGet(ctx, keys interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MGet", reflect.TypeOf((*MockRedisStore)(nil).MGet), ctx, keys)
+}
+
+// ScanKeys mocks base method.
+func (m *MockRedisStore) ScanKeys(ctx context.Context, match string, fn func([]string) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScanKeys", ctx, match, fn)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// SetAndEnqueue indicates an expected call of SetAndEnqueue.
-func (mr *MockRedisStoreMockRecorder) SetAndEnqueue(ctx, messageKey, value, queueKey, messageID interface{}) *gomock.Call {
+// ScanKeys indicates an expected call of ScanKeys.
+func (mr *MockRedisStoreMockRecorder) ScanKeys(ctx, match, fn interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAndEnqueue", reflect.TypeOf((*MockRedisStore)(nil).SetAndEnqueue), ctx, messageKey, value, queueKey, messageID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScanKeys", reflect.TypeOf((*MockRedisStore)(nil).ScanKeys), ctx, match, fn)
+}
+
+// Set mocks base method.
+func (m *MockRedisStore) Set(ctx context.Context, key string, value []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Set", ctx, key, value)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Set indicates an expected call of Set.
+func (mr *MockRedisStoreMockRecorder) Set(ctx, key, value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockRedisStore)(nil).Set), ctx, key, value)
+}
+
+// SetAndEnqueueBatch mocks base method.
+func (m *MockRedisStore) SetAndEnqueueBatch(ctx context.Context, ingestEntries []*models.IngestEntry, entries []*models.EnqueueEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAndEnqueueBatch", ctx, ingestEntries, entries)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetAndEnqueueBatch indicates an expected call of SetAndEnqueueBatch.
+func (mr *MockRedisStoreMockRecorder) SetAndEnqueueBatch(ctx, ingestEntries, entries interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAndEnqueueBatch", reflect.TypeOf((*MockRedisStore)(nil).SetAndEnqueueBatch), ctx, ingestEntries, entries)
+}
+
+// SetAndEnqueueBatchMarked mocks base method.
+func (m *MockRedisStore) SetAndEnqueueBatchMarked(ctx context.Context, ingestEntries []*models.IngestEntry, entries []*models.EnqueueEntry, marker *models.EnqueueMarker) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAndEnqueueBatchMarked", ctx, ingestEntries, entries, marker)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetAndEnqueueBatchMarked indicates an expected call of SetAndEnqueueBatchMarked.
+func (mr *MockRedisStoreMockRecorder) SetAndEnqueueBatchMarked(ctx, ingestEntries, entries, marker interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAndEnqueueBatchMarked", reflect.TypeOf((*MockRedisStore)(nil).SetAndEnqueueBatchMarked), ctx, ingestEntries, entries, marker)
 }
 
 // SetAndMove mocks base method.
@@ -151,18 +312,18 @@ func (mr *MockRedisStoreMockRecorder) SetAndMove(ctx, messageKey, value, sourceQ
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAndMove", reflect.TypeOf((*MockRedisStore)(nil).SetAndMove), ctx, messageKey, value, sourceQueueKey, destQueueKey, messageID)
 }
 
-// SetAndZAdd mocks base method.
-func (m *MockRedisStore) SetAndZAdd(ctx context.Context, messageKey string, value []byte, queueKey, messageID string, score float64) error {
+// SetExZAddTrim mocks base method.
+func (m *MockRedisStore) SetExZAddTrim(ctx context.Context, key string, value []byte, ttl time.Duration, zsetKey, member string, score, minScore float64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetAndZAdd", ctx, messageKey, value, queueKey, messageID, score)
+	ret := m.ctrl.Call(m, "SetExZAddTrim", ctx, key, value, ttl, zsetKey, member, score, minScore)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// SetAndZAdd indicates an expected call of SetAndZAdd.
-func (mr *MockRedisStoreMockRecorder) SetAndZAdd(ctx, messageKey, value, queueKey, messageID, score interface{}) *gomock.Call {
+// SetExZAddTrim indicates an expected call of SetExZAddTrim.
+func (mr *MockRedisStoreMockRecorder) SetExZAddTrim(ctx, key, value, ttl, zsetKey, member, score, minScore interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAndZAdd", reflect.TypeOf((*MockRedisStore)(nil).SetAndZAdd), ctx, messageKey, value, queueKey, messageID, score)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetExZAddTrim", reflect.TypeOf((*MockRedisStore)(nil).SetExZAddTrim), ctx, key, value, ttl, zsetKey, member, score, minScore)
 }
 
 // SetLRemZAdd mocks base method.
@@ -179,6 +340,109 @@ func (mr *MockRedisStoreMockRecorder) SetLRemZAdd(ctx, messageKey, value, source
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLRemZAdd", reflect.TypeOf((*MockRedisStore)(nil).SetLRemZAdd), ctx, messageKey, value, sourceQueueKey, destQueueKey, messageID, score)
 }
 
+// SetNX mocks base method.
+func (m *MockRedisStore) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetNX", ctx, key, value, ttl)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetNX indicates an expected call of SetNX.
+func (mr *MockRedisStoreMockRecorder) SetNX(ctx, key, value, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNX", reflect.TypeOf((*MockRedisStore)(nil).SetNX), ctx, key, value, ttl)
+}
+
+// TakeToken mocks base method.
+func (m *MockRedisStore) TakeToken(ctx context.Context, bucketKey string, capacity int, refillPerSecond float64, now time.Time) (bool, time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeToken", ctx, bucketKey, capacity, refillPerSecond, now)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(time.Duration)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// TakeToken indicates an expected call of TakeToken.
+func (mr *MockRedisStoreMockRecorder) TakeToken(ctx, bucketKey, capacity, refillPerSecond, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeToken", reflect.TypeOf((*MockRedisStore)(nil).TakeToken), ctx, bucketKey, capacity, refillPerSecond, now)
+}
+
+// XAck mocks base method.
+func (m *MockRedisStore) XAck(ctx context.Context, stream, group string, ids []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "XAck", ctx, stream, group, ids)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// XAck indicates an expected call of XAck.
+func (mr *MockRedisStoreMockRecorder) XAck(ctx, stream, group, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "XAck", reflect.TypeOf((*MockRedisStore)(nil).XAck), ctx, stream, group, ids)
+}
+
+// XAutoClaim mocks base method.
+func (m *MockRedisStore) XAutoClaim(ctx context.Context, stream, group, consumer string, minIdle time.Duration, count int) ([]*models.StreamEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "XAutoClaim", ctx, stream, group, consumer, minIdle, count)
+	ret0, _ := ret[0].([]*models.StreamEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// XAutoClaim indicates an expected call of XAutoClaim.
+func (mr *MockRedisStoreMockRecorder) XAutoClaim(ctx, stream, group, consumer, minIdle, count interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "XAutoClaim", reflect.TypeOf((*MockRedisStore)(nil).XAutoClaim), ctx, stream, group, consumer, minIdle, count)
+}
+
+// XGroupCreate mocks base method.
+func (m *MockRedisStore) XGroupCreate(ctx context.Context, stream, group string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "XGroupCreate", ctx, stream, group)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// XGroupCreate indicates an expected call of XGroupCreate.
+func (mr *MockRedisStoreMockRecorder) XGroupCreate(ctx, stream, group interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "XGroupCreate", reflect.TypeOf((*MockRedisStore)(nil).XGroupCreate), ctx, stream, group)
+}
+
+// XReadGroup mocks base method.
+func (m *MockRedisStore) XReadGroup(ctx context.Context, stream, group, consumer, id string, count int, block time.Duration) ([]*models.StreamEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "XReadGroup", ctx, stream, group, consumer, id, count, block)
+	ret0, _ := ret[0].([]*models.StreamEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// XReadGroup indicates an expected call of XReadGroup.
+func (mr *MockRedisStoreMockRecorder) XReadGroup(ctx, stream, group, consumer, id, count, block interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "XReadGroup", reflect.TypeOf((*MockRedisStore)(nil).XReadGroup), ctx, stream, group, consumer, id, count, block)
+}
+
+// ZAdd mocks base method.
+func (m *MockRedisStore) ZAdd(ctx context.Context, zsetKey, member string, score float64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ZAdd", ctx, zsetKey, member, score)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ZAdd indicates an expected call of ZAdd.
+func (mr *MockRedisStoreMockRecorder) ZAdd(ctx, zsetKey, member, score interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ZAdd", reflect.TypeOf((*MockRedisStore)(nil).ZAdd), ctx, zsetKey, member, score)
+}
+
 // ZRangeBelowScore mocks base method.
 func (m *MockRedisStore) ZRangeBelowScore(ctx context.Context, queueKey string, score float64) ([]string, error) {
 	m.ctrl.T.Helper()
@@ -194,18 +458,34 @@ func (mr *MockRedisStoreMockRecorder) ZRangeBelowScore(ctx, queueKey, score inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ZRangeBelowScore", reflect.TypeOf((*MockRedisStore)(nil).ZRangeBelowScore), ctx, queueKey, score)
 }
 
-// ZRemDel mocks base method.
-func (m *MockRedisStore) ZRemDel(ctx context.Context, queueKey string, messageIDs, messageKeys []string) error {
+// ZRangeByScore mocks base method.
+func (m *MockRedisStore) ZRangeByScore(ctx context.Context, zsetKey string, minScore, maxScore float64) ([]string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ZRemDel", ctx, queueKey, messageIDs, messageKeys)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "ZRangeByScore", ctx, zsetKey, minScore, maxScore)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ZRangeByScore indicates an expected call of ZRangeByScore.
+func (mr *MockRedisStoreMockRecorder) ZRangeByScore(ctx, zsetKey, minScore, maxScore interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ZRangeByScore", reflect.TypeOf((*MockRedisStore)(nil).ZRangeByScore), ctx, zsetKey, minScore, maxScore)
+}
+
+// ZRemDelReleaseIngests mocks base method.
+func (m *MockRedisStore) ZRemDelReleaseIngests(ctx context.Context, queueKey string, messageIDs, messageKeys, ingestKeys []string) ([]bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ZRemDelReleaseIngests", ctx, queueKey, messageIDs, messageKeys, ingestKeys)
+	ret0, _ := ret[0].([]bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// ZRemDel indicates an expected call of ZRemDel.
-func (mr *MockRedisStoreMockRecorder) ZRemDel(ctx, queueKey, messageIDs, messageKeys interface{}) *gomock.Call {
+// ZRemDelReleaseIngests indicates an expected call of ZRemDelReleaseIngests.
+func (mr *MockRedisStoreMockRecorder) ZRemDelReleaseIngests(ctx, queueKey, messageIDs, messageKeys, ingestKeys interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ZRemDel", reflect.TypeOf((*MockRedisStore)(nil).ZRemDel), ctx, queueKey, messageIDs, messageKeys)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ZRemDelReleaseIngests", reflect.TypeOf((*MockRedisStore)(nil).ZRemDelReleaseIngests), ctx, queueKey, messageIDs, messageKeys, ingestKeys)
 }
 
 // ZRemRangeBelowScore mocks base method.
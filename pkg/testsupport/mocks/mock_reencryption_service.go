@@ -0,0 +1,50 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/services/reencryption_service.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockReencryptionService is a mock of ReencryptionService interface.
+type MockReencryptionService struct {
+	ctrl     *gomock.Controller
+	recorder *MockReencryptionServiceMockRecorder
+}
+
+// MockReencryptionServiceMockRecorder is the mock recorder for MockReencryptionService.
+type MockReencryptionServiceMockRecorder struct {
+	mock *MockReencryptionService
+}
+
+// NewMockReencryptionService creates a new mock instance.
+func NewMockReencryptionService(ctrl *gomock.Controller) *MockReencryptionService {
+	mock := &MockReencryptionService{ctrl: ctrl}
+	mock.recorder = &MockReencryptionServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReencryptionService) EXPECT() *MockReencryptionServiceMockRecorder {
+	return m.recorder
+}
+
+// Reencrypt mocks base method.
+func (m *MockReencryptionService) Reencrypt(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reencrypt", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reencrypt indicates an expected call of Reencrypt.
+func (mr *MockReencryptionServiceMockRecorder) Reencrypt(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reencrypt", reflect.TypeOf((*MockReencryptionService)(nil).Reencrypt), ctx)
+}
@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/services/stream_consumer.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "github.com/didil/inhooks/pkg/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockStreamConsumer is a mock of StreamConsumer interface.
+type MockStreamConsumer struct {
+	ctrl     *gomock.Controller
+	recorder *MockStreamConsumerMockRecorder
+}
+
+// MockStreamConsumerMockRecorder is the mock recorder for MockStreamConsumer.
+type MockStreamConsumerMockRecorder struct {
+	mock *MockStreamConsumer
+}
+
+// NewMockStreamConsumer creates a new mock instance.
+func NewMockStreamConsumer(ctrl *gomock.Controller) *MockStreamConsumer {
+	mock := &MockStreamConsumer{ctrl: ctrl}
+	mock.recorder = &MockStreamConsumerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStreamConsumer) EXPECT() *MockStreamConsumerMockRecorder {
+	return m.recorder
+}
+
+// Consume mocks base method.
+func (m *MockStreamConsumer) Consume(ctx context.Context, flow *models.Flow, consumer string, pending bool) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Consume", ctx, flow, consumer, pending)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Consume indicates an expected call of Consume.
+func (mr *MockStreamConsumerMockRecorder) Consume(ctx, flow, consumer, pending interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Consume", reflect.TypeOf((*MockStreamConsumer)(nil).Consume), ctx, flow, consumer, pending)
+}
+
+// Init mocks base method.
+func (m *MockStreamConsumer) Init(ctx context.Context, flow *models.Flow) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Init", ctx, flow)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Init indicates an expected call of Init.
+func (mr *MockStreamConsumerMockRecorder) Init(ctx, flow interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Init", reflect.TypeOf((*MockStreamConsumer)(nil).Init), ctx, flow)
+}
@@ -0,0 +1,51 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/services/sync_delivery_service.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "github.com/didil/inhooks/pkg/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockSyncDeliveryService is a mock of SyncDeliveryService interface.
+type MockSyncDeliveryService struct {
+	ctrl     *gomock.Controller
+	recorder *MockSyncDeliveryServiceMockRecorder
+}
+
+// MockSyncDeliveryServiceMockRecorder is the mock recorder for MockSyncDeliveryService.
+type MockSyncDeliveryServiceMockRecorder struct {
+	mock *MockSyncDeliveryService
+}
+
+// NewMockSyncDeliveryService creates a new mock instance.
+func NewMockSyncDeliveryService(ctrl *gomock.Controller) *MockSyncDeliveryService {
+	mock := &MockSyncDeliveryService{ctrl: ctrl}
+	mock.recorder = &MockSyncDeliveryServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSyncDeliveryService) EXPECT() *MockSyncDeliveryServiceMockRecorder {
+	return m.recorder
+}
+
+// Deliver mocks base method.
+func (m_2 *MockSyncDeliveryService) Deliver(ctx context.Context, sink *models.Sink, m *models.Message) (*models.SinkResponse, error) {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "Deliver", ctx, sink, m)
+	ret0, _ := ret[0].(*models.SinkResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Deliver indicates an expected call of Deliver.
+func (mr *MockSyncDeliveryServiceMockRecorder) Deliver(ctx, sink, m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Deliver", reflect.TypeOf((*MockSyncDeliveryService)(nil).Deliver), ctx, sink, m)
+}
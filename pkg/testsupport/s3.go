@@ -0,0 +1,79 @@
+package testsupport
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// S3Server is an in-memory stand-in for an S3 compatible object storage such as MinIO, supporting path-style PUT, GET and DELETE object requests
+type S3Server struct {
+	*httptest.Server
+	accessKeyID string
+
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+// NewS3Server starts an S3Server accepting requests signed with accessKeyID. Close it when done.
+func NewS3Server(accessKeyID string) *S3Server {
+	s := &S3Server{
+		accessKeyID: accessKeyID,
+		objects:     map[string][]byte{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+
+	return s
+}
+
+// Object returns the object stored at the bucket path, e.g. /bucket/key
+func (s *S3Server) Object(path string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	object, ok := s.objects[path]
+	return object, ok
+}
+
+func (s *S3Server) handle(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential="+s.accessKeyID+"/") {
+		http.Error(w, "AccessDenied", http.StatusForbidden)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "InternalError", http.StatusInternalServerError)
+		return
+	}
+	bodyHash := sha256.Sum256(body)
+	if r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(bodyHash[:]) {
+		http.Error(w, "XAmzContentSHA256Mismatch", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		s.objects[r.URL.Path] = body
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet:
+		object, ok := s.objects[r.URL.Path]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(object)
+	case http.MethodDelete:
+		delete(s.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "MethodNotAllowed", http.StatusMethodNotAllowed)
+	}
+}